@@ -0,0 +1,353 @@
+// Package analytics derives market data out of the pool program events: candles,
+// pool volumes, fees and yields, and position performance.
+package analytics
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	dammv2gosdk "dammv2GoSDK"
+	"dammv2GoSDK/indexer"
+	"dammv2GoSDK/maths"
+	"dammv2GoSDK/types"
+	"fmt"
+	"math"
+	"slices"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+)
+
+// Orientation is the way a pool price is quoted.
+type Orientation uint8
+
+const (
+	// PriceAInB quotes token A, the base, in token B.
+	PriceAInB Orientation = iota
+	// PriceBInA quotes token B, the base, in token A.
+	PriceBInA
+)
+
+// PriceSource is the price a swap is charted at.
+type PriceSource uint8
+
+const (
+	// PricePool is the pool price after the swap, out of SwapResult.NextSqrtPrice.
+	PricePool PriceSource = iota
+	// PriceExecution is the average price the swap executed at, out of its amounts.
+	PriceExecution
+)
+
+// Candle aggregates the swaps of a pool over an interval. Prices and volumes are in UI units.
+type Candle struct {
+	Pool        solana.PublicKey
+	Interval    time.Duration
+	Orientation Orientation
+	Start       time.Time
+	Open        float64
+	High        float64
+	Low         float64
+	Close       float64
+	// BaseVolume and QuoteVolume are the amounts of the base and quote tokens swapped.
+	BaseVolume  float64
+	QuoteVolume float64
+	Trades      int
+	// Final reports every swap of the candle is finalized, it won't change anymore.
+	Final bool
+}
+
+// CandleOpts configures a CandleBuilder.
+type CandleOpts struct {
+	// Intervals candles are built for, defaults to 1m, 1h and 1d. Intervals that aren't
+	// whole seconds are ignored.
+	Intervals []time.Duration
+	// PriceSource is the price swaps are charted at, defaults to PricePool.
+	PriceSource PriceSource
+}
+
+// CandleBuilder charts the EvtSwap events of the pools it tracks as OHLCV candles of
+// every interval of its CandleOpts. Dropping the swaps of an abandoned fork takes them
+// out of their candles, which are only rebuilt when read. Finalized swaps are folded into
+// their candles and let go of, only their keys are kept to skip them when put again.
+//
+// Swaps come through AddSwap or, the builder being an indexer.Store, out of an indexer.
+// It is safe for concurrent use by multiple goroutines.
+type CandleBuilder struct {
+	eventStore[*trade]
+	opts  CandleOpts
+	pools map[solana.PublicKey]*candlePool
+}
+
+type candlePool struct {
+	decimalsA, decimalsB uint8
+	// buckets of every interval, by start time in unix seconds.
+	buckets map[time.Duration]map[int64]*bucket
+}
+
+type bucket struct {
+	// trades are the trades not finalized yet, folded the finalized ones.
+	trades map[indexer.Key]*trade
+	folded candleFold
+	candle Candle
+	dirty  bool
+}
+
+// candleFold aggregates trades in their chronological order.
+type candleFold struct {
+	first, last trade
+	high, low   float64
+	amountA     float64
+	amountB     float64
+	trades      int
+}
+
+// trade is a swap, priced and sized in UI units of token A in token B.
+type trade struct {
+	eventMeta
+	pool      solana.PublicKey
+	timestamp int64
+	price     float64
+	amountA   float64
+	amountB   float64
+}
+
+var _ indexer.Store = (*CandleBuilder)(nil)
+
+// NewCandleBuilder returns a builder tracking no pool yet, see Track.
+func NewCandleBuilder(opts CandleOpts) *CandleBuilder {
+	if len(opts.Intervals) == 0 {
+		opts.Intervals = []time.Duration{time.Minute, time.Hour, 24 * time.Hour}
+	}
+	opts.Intervals = slices.DeleteFunc(slices.Clone(opts.Intervals), func(interval time.Duration) bool {
+		return interval < time.Second || interval%time.Second != 0
+	})
+	b := &CandleBuilder{
+		eventStore: newEventStore[*trade](),
+		opts:       opts,
+		pools:      make(map[solana.PublicKey]*candlePool),
+	}
+	b.onFinalize = b.fold
+	b.onDrop = b.remove
+	return b
+}
+
+// Track builds candles for pool, whose tokens have the given decimals. Swaps of pools
+// not tracked are ignored.
+func (b *CandleBuilder) Track(pool solana.PublicKey, decimalsA, decimalsB uint8) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if _, ok := b.pools[pool]; ok {
+		return
+	}
+
+	buckets := make(map[time.Duration]map[int64]*bucket, len(b.opts.Intervals))
+	for _, interval := range b.opts.Intervals {
+		buckets[interval] = make(map[int64]*bucket)
+	}
+	b.pools[pool] = &candlePool{decimalsA: decimalsA, decimalsB: decimalsB, buckets: buckets}
+}
+
+// AddSwap adds the swap of update, e.g one out of SubscribeEvents or Backfill.
+// Updates carrying other events are ignored.
+func (b *CandleBuilder) AddSwap(update dammv2gosdk.EventUpdate, finalized bool) error {
+	return addUpdate(b, update, finalized, "EvtSwap")
+}
+
+func (b *CandleBuilder) Put(_ context.Context, records ...indexer.Record) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for _, rec := range records {
+		pool, ok := b.pools[rec.Pool]
+		if rec.Swap == nil || !ok {
+			continue
+		}
+		if b.has(rec.Key) {
+			continue
+		}
+
+		t := pool.trade(rec, b.opts.PriceSource)
+		b.add(t)
+		for interval, buckets := range pool.buckets {
+			start := bucketStart(t.timestamp, interval)
+			bkt, ok := buckets[start]
+			if !ok {
+				bkt = &bucket{trades: make(map[indexer.Key]*trade)}
+				buckets[start] = bkt
+			}
+			bkt.trades[t.key] = t
+			bkt.dirty = true
+		}
+		if t.final {
+			b.fold(t)
+		}
+	}
+	return nil
+}
+
+// Candles returns the candles of pool for interval starting in [from, to), oldest first.
+// Intervals without swaps have no candle.
+func (b *CandleBuilder) Candles(
+	pool solana.PublicKey,
+	interval time.Duration,
+	orientation Orientation,
+	from, to time.Time,
+) ([]Candle, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	p, ok := b.pools[pool]
+	if !ok {
+		return nil, fmt.Errorf("pool %s is not tracked", pool)
+	}
+	buckets, ok := p.buckets[interval]
+	if !ok {
+		return nil, fmt.Errorf("no candle is built for %s intervals", interval)
+	}
+
+	var res []Candle
+	for start, bkt := range buckets {
+		if start < from.Unix() || start >= to.Unix() {
+			continue
+		}
+		if bkt.dirty {
+			bkt.candle = buildCandle(pool, interval, start, bkt)
+			bkt.dirty = false
+		}
+		candle := bkt.candle
+		if orientation == PriceBInA {
+			candle = invert(candle)
+		}
+		res = append(res, candle)
+	}
+	slices.SortFunc(res, func(a, b Candle) int { return a.Start.Compare(b.Start) })
+	return res, nil
+}
+
+// remove takes t out of its buckets.
+func (b *CandleBuilder) remove(t *trade) {
+	for interval, buckets := range b.pools[t.pool].buckets {
+		start := bucketStart(t.timestamp, interval)
+		if bkt, ok := buckets[start]; ok {
+			delete(bkt.trades, t.key)
+			bkt.dirty = true
+			if len(bkt.trades) == 0 && bkt.folded.trades == 0 {
+				delete(buckets, start)
+			}
+		}
+	}
+}
+
+// fold folds t, finalized, into its buckets and lets go of it.
+func (b *CandleBuilder) fold(t *trade) {
+	for interval, buckets := range b.pools[t.pool].buckets {
+		if bkt, ok := buckets[bucketStart(t.timestamp, interval)]; ok {
+			delete(bkt.trades, t.key)
+			bkt.folded.add(t)
+			bkt.dirty = true
+		}
+	}
+	b.release(t)
+}
+
+func (p *candlePool) trade(rec indexer.Record, source PriceSource) *trade {
+	swap := rec.Swap
+	amountIn := uiAmount(swap.AmountIn, p.decimalsA)
+	amountOut := uiAmount(swap.AmountOut, p.decimalsB)
+	if swap.Direction == types.TradeDirectionBtoA {
+		amountIn = uiAmount(swap.AmountIn, p.decimalsB)
+		amountOut = uiAmount(swap.AmountOut, p.decimalsA)
+	}
+
+	t := &trade{
+		eventMeta: newEventMeta(rec),
+		pool:      rec.Pool,
+		timestamp: int64(swap.Timestamp),
+		amountA:   amountIn,
+		amountB:   amountOut,
+	}
+	if swap.Direction == types.TradeDirectionBtoA {
+		t.amountA, t.amountB = amountOut, amountIn
+	}
+
+	if source == PriceExecution && t.amountA > 0 {
+		t.price = t.amountB / t.amountA
+	} else {
+		t.price, _ = maths.GetPriceFromSqrtPrice(swap.NextSqrtPrice.BigInt(), p.decimalsA, p.decimalsB).Float64()
+	}
+	return t
+}
+
+// buildCandle aggregates the trades of bkt, priced A in B.
+func buildCandle(pool solana.PublicKey, interval time.Duration, start int64, bkt *bucket) Candle {
+	fold := bkt.folded
+	for _, t := range bkt.trades {
+		fold.add(t)
+	}
+
+	return Candle{
+		Pool:        pool,
+		Interval:    interval,
+		Start:       time.Unix(start, 0).UTC(),
+		Open:        fold.first.price,
+		High:        fold.high,
+		Low:         fold.low,
+		Close:       fold.last.price,
+		BaseVolume:  fold.amountA,
+		QuoteVolume: fold.amountB,
+		Trades:      fold.trades,
+		Final:       len(bkt.trades) == 0,
+	}
+}
+
+func (f *candleFold) add(t *trade) {
+	if f.trades == 0 {
+		f.first, f.last = *t, *t
+		f.high, f.low = math.Inf(-1), math.Inf(1)
+	}
+	if compareTrades(t, &f.first) < 0 {
+		f.first = *t
+	}
+	if compareTrades(t, &f.last) > 0 {
+		f.last = *t
+	}
+	f.high = max(f.high, t.price)
+	f.low = min(f.low, t.price)
+	f.amountA += t.amountA
+	f.amountB += t.amountB
+	f.trades++
+}
+
+// compareTrades orders trades chronologically.
+func compareTrades(a, b *trade) int {
+	return cmp.Or(
+		cmp.Compare(a.timestamp, b.timestamp),
+		cmp.Compare(a.slot, b.slot),
+		bytes.Compare(a.key.Signature[:], b.key.Signature[:]),
+		cmp.Compare(a.key.Index, b.key.Index),
+	)
+}
+
+// invert quotes candle, priced A in B, B in A.
+func invert(candle Candle) Candle {
+	inverse := func(price float64) float64 {
+		if price == 0 {
+			return 0
+		}
+		return 1 / price
+	}
+	candle.Orientation = PriceBInA
+	candle.Open, candle.Close = inverse(candle.Open), inverse(candle.Close)
+	candle.High, candle.Low = inverse(candle.Low), inverse(candle.High)
+	candle.BaseVolume, candle.QuoteVolume = candle.QuoteVolume, candle.BaseVolume
+	return candle
+}
+
+func bucketStart(timestamp int64, interval time.Duration) int64 {
+	seconds := int64(interval / time.Second)
+	return timestamp - timestamp%seconds
+}
+
+func uiAmount(amount uint64, decimals uint8) float64 {
+	return float64(amount) / math.Pow10(int(decimals))
+}
@@ -0,0 +1,180 @@
+package analytics
+
+import (
+	"context"
+	dammv2gosdk "dammv2GoSDK"
+	cp_amm "dammv2GoSDK/generated/cpAmm"
+	"dammv2GoSDK/helpers"
+	"dammv2GoSDK/internal/test/eventtest"
+	"dammv2GoSDK/types"
+	"math"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+)
+
+// swapUpdate swaps amountIn for amountOut, raw amounts, at timestamp.
+func swapUpdate(
+	pool solana.PublicKey,
+	sig byte,
+	slot uint64,
+	timestamp uint64,
+	direction types.TradeDirection,
+	amountIn, amountOut uint64,
+) dammv2gosdk.EventUpdate {
+	return eventtest.Update(sig, slot, "EvtSwap", &cp_amm.EvtSwapEventData{
+		Pool:             pool,
+		TradeDirection:   uint8(direction),
+		ActualAmountIn:   amountIn,
+		SwapResult:       cp_amm.SwapResult{OutputAmount: amountOut},
+		CurrentTimestamp: timestamp,
+	})
+}
+
+func TestCandleBuilder(t *testing.T) {
+	ctx := context.Background()
+	pool := solana.NewWallet().PublicKey()
+	b := NewCandleBuilder(CandleOpts{Intervals: []time.Duration{time.Minute}, PriceSource: PriceExecution})
+	// token A has 9 decimals, token B 6.
+	b.Track(pool, 9, 6)
+
+	const sol, usdc = 1_000_000_000, 1_000_000
+	for _, update := range []dammv2gosdk.EventUpdate{
+		swapUpdate(pool, 4, 14, 100, types.TradeDirectionBtoA, 160*usdc, sol),
+		swapUpdate(pool, 1, 10, 60, types.TradeDirectionAtoB, sol, 150*usdc),
+		swapUpdate(pool, 3, 12, 70, types.TradeDirectionAtoB, 2*sol, 280*usdc),
+		swapUpdate(pool, 5, 15, 130, types.TradeDirectionAtoB, sol, 145*usdc),
+		// untracked pool.
+		swapUpdate(solana.NewWallet().PublicKey(), 6, 15, 130, types.TradeDirectionAtoB, sol, 1),
+	} {
+		for range 2 {
+			if err := b.AddSwap(update, false); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	candles, err := b.Candles(pool, time.Minute, PriceAInB, time.Unix(0, 0), time.Unix(3600, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(candles) != 2 {
+		t.Fatalf("candles = %+v", candles)
+	}
+	want := Candle{
+		Pool:        pool,
+		Interval:    time.Minute,
+		Start:       time.Unix(60, 0).UTC(),
+		Open:        150,
+		High:        160,
+		Low:         140,
+		Close:       160,
+		BaseVolume:  4,
+		QuoteVolume: 590,
+		Trades:      3,
+	}
+	if candles[0] != want {
+		t.Fatalf("candle = %+v\nwant     %+v", candles[0], want)
+	}
+
+	// the last swap of the first candle was on a fork, the first one got finalized.
+	if err := b.Drop(ctx, solana.Signature{4}); err != nil {
+		t.Fatal(err)
+	}
+	pending, err := b.Pending(ctx, 10)
+	if err != nil || len(pending) != 1 {
+		t.Fatalf("pending = %v err = %v", pending, err)
+	}
+	if err := b.Finalize(ctx, map[solana.Signature]uint64{{1}: 10, {3}: 12}); err != nil {
+		t.Fatal(err)
+	}
+
+	candles, err = b.Candles(pool, time.Minute, PriceBInA, time.Unix(60, 0), time.Unix(120, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := candles[0]
+	if len(candles) != 1 || !got.Final || got.Trades != 2 || got.Orientation != PriceBInA ||
+		got.Open != 1.0/150 || got.Close != 1.0/140 || got.High != 1.0/140 || got.Low != 1.0/150 ||
+		got.BaseVolume != 430 || got.QuoteVolume != 3 {
+		t.Fatalf("candle = %+v", got)
+	}
+
+	if _, err := b.Candles(pool, time.Hour, PriceAInB, time.Time{}, time.Now()); err == nil {
+		t.Fatal("hourly candles aren't built")
+	}
+}
+
+func TestCandleBuilderPoolPrice(t *testing.T) {
+	pool := solana.NewWallet().PublicKey()
+	b := NewCandleBuilder(CandleOpts{})
+	b.Track(pool, 9, 6)
+
+	// 150 USDC per SOL is a raw price of 0.15.
+	sqrtPrice := new(big.Float).Mul(big.NewFloat(math.Sqrt(0.15)), big.NewFloat(math.Pow(2, 64)))
+	sqrtPriceInt, _ := sqrtPrice.Int(nil)
+	update := swapUpdate(pool, 1, 10, 60, types.TradeDirectionAtoB, 1, 1)
+	update.Event.Data.(*cp_amm.EvtSwapEventData).SwapResult.NextSqrtPrice = helpers.MustBigIntToUint128(sqrtPriceInt)
+	if err := b.AddSwap(update, true); err != nil {
+		t.Fatal(err)
+	}
+
+	candles, err := b.Candles(pool, 24*time.Hour, PriceAInB, time.Time{}, time.Unix(1<<40, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(candles) != 1 || math.Abs(candles[0].Close-150) > 1e-9 || !candles[0].Final {
+		t.Fatalf("candles = %+v", candles)
+	}
+}
+
+func TestCandleBuilderFoldsFinalSwaps(t *testing.T) {
+	ctx := context.Background()
+	pool := solana.NewWallet().PublicKey()
+	b := NewCandleBuilder(CandleOpts{Intervals: []time.Duration{time.Minute, time.Hour}, PriceSource: PriceExecution})
+	b.Track(pool, 0, 0)
+
+	for _, tt := range []struct {
+		update    dammv2gosdk.EventUpdate
+		finalized bool
+	}{
+		{swapUpdate(pool, 2, 12, 70, types.TradeDirectionAtoB, 1, 3), true},
+		{swapUpdate(pool, 3, 13, 80, types.TradeDirectionAtoB, 1, 4), false},
+		// backfilled after the others were folded, it opens the candle.
+		{swapUpdate(pool, 1, 11, 60, types.TradeDirectionAtoB, 1, 2), true},
+	} {
+		if err := b.AddSwap(tt.update, tt.finalized); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := b.Finalize(ctx, map[solana.Signature]uint64{{3}: 13}); err != nil {
+		t.Fatal(err)
+	}
+
+	// the finalized swaps are let go of, putting them again changes nothing.
+	if len(b.events) != 0 {
+		t.Fatalf("%d transactions kept", len(b.events))
+	}
+	if err := b.AddSwap(swapUpdate(pool, 2, 12, 70, types.TradeDirectionAtoB, 1, 3), true); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, interval := range []time.Duration{time.Minute, time.Hour} {
+		candles, err := b.Candles(pool, interval, PriceAInB, time.Time{}, time.Unix(3600, 0))
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := Candle{
+			Pool: pool, Interval: interval, Start: time.Unix(0, 0).UTC(),
+			Open: 2, High: 4, Low: 2, Close: 4, BaseVolume: 3, QuoteVolume: 9, Trades: 3, Final: true,
+		}
+		if interval == time.Minute {
+			want.Start = time.Unix(60, 0).UTC()
+		}
+		if len(candles) != 1 || candles[0] != want {
+			t.Fatalf("candles = %+v\nwant      %+v", candles, want)
+		}
+	}
+}
@@ -0,0 +1,499 @@
+package analytics
+
+import (
+	"context"
+	dammv2gosdk "dammv2GoSDK"
+	cp_amm "dammv2GoSDK/generated/cpAmm"
+	"dammv2GoSDK/helpers"
+	"dammv2GoSDK/indexer"
+	"dammv2GoSDK/maths"
+	"dammv2GoSDK/types"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"slices"
+	"time"
+
+	ag_binary "github.com/gagliardetto/binary"
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/programs/token"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+// Rolling windows pool stats are usually computed over.
+const (
+	Window24h = 24 * time.Hour
+	Window7d  = 7 * Window24h
+)
+
+const year = 365 * Window24h
+
+// PoolInfo describes the tokens of a pool.
+type PoolInfo struct {
+	DecimalsA uint8
+	DecimalsB uint8
+	// CollectFeeMode tells the token swap fees are charged in, see helpers.GetFeeMode.
+	CollectFeeMode types.CollectFeeMode
+}
+
+// Prices value one UI unit of token A and of token B in a common unit of account, e.g USD.
+// The zero Prices value token B at 1 and token A at the pool price, i.e in token B.
+type Prices struct {
+	A float64
+	B float64
+}
+
+// PoolSnapshot is the state of a pool at some point in time, see FetchPoolSnapshot.
+type PoolSnapshot struct {
+	Time      time.Time
+	Slot      uint64
+	Metrics   cp_amm.PoolMetrics
+	SqrtPrice ag_binary.Uint128
+	// ReserveA and ReserveB are the balances of the pool vaults, raw amounts. Besides the
+	// liquidity, they hold the protocol and partner fees not claimed yet.
+	ReserveA uint64
+	ReserveB uint64
+}
+
+// PoolStats is the activity of a pool over the window [From, To]. Amounts are in UI units,
+// values in the unit of account of the Prices they were computed with.
+type PoolStats struct {
+	Pool solana.PublicKey
+	From time.Time
+	To   time.Time
+	// VolumeA and VolumeB are the amounts of token A and B swapped in either direction, and
+	// Volume the value of the swapped inputs. Only events carry volumes: they're left 0, as
+	// Swaps, by stats out of snapshots.
+	VolumeA float64
+	VolumeB float64
+	Volume  float64
+	Swaps   int
+	// LpFees, ProtocolFees, PartnerFees and ReferralFees are the values of the fees charged.
+	// Pool metrics don't track referral fees.
+	LpFees       float64
+	ProtocolFees float64
+	PartnerFees  float64
+	ReferralFees float64
+	// TVL is the value of the pool reserves at To, 0 when they're unknown.
+	TVL float64
+	// FeeAPR is the LP fees of the window, annualized, over TVL. It's 0 without a TVL.
+	FeeAPR float64
+}
+
+// FetchPoolSnapshot fetches the state of pool and the balances of its vaults. conn is the
+// client cp was built with.
+func FetchPoolSnapshot(
+	ctx context.Context,
+	cp *dammv2gosdk.CpAMM,
+	conn *rpc.Client,
+	pool solana.PublicKey,
+) (PoolSnapshot, error) {
+	poolState, err := cp.FetchPoolState(ctx, pool)
+	if err != nil {
+		return PoolSnapshot{}, err
+	}
+
+	vaults, err := conn.GetMultipleAccountsWithOpts(
+		ctx,
+		[]solana.PublicKey{poolState.TokenAVault, poolState.TokenBVault},
+		&rpc.GetMultipleAccountsOpts{Commitment: rpc.CommitmentConfirmed},
+	)
+	if err != nil {
+		return PoolSnapshot{}, fmt.Errorf("err fetching the vaults of %s: %w", pool, err)
+	}
+
+	var reserves [2]uint64
+	for i, acc := range vaults.Value {
+		if acc == nil || len(acc.Data.GetBinary()) == 0 {
+			return PoolSnapshot{}, fmt.Errorf("vault of %s not found", pool)
+		}
+		var vault token.Account
+		if err := ag_binary.NewBorshDecoder(acc.Data.GetBinary()).Decode(&vault); err != nil {
+			return PoolSnapshot{}, fmt.Errorf("err decoding the vaults of %s: %w", pool, err)
+		}
+		reserves[i] = vault.Amount
+	}
+
+	return PoolSnapshot{
+		Time:      time.Now(),
+		Slot:      vaults.Context.Slot,
+		Metrics:   poolState.Metrics,
+		SqrtPrice: poolState.SqrtPrice,
+		ReserveA:  reserves[0],
+		ReserveB:  reserves[1],
+	}, nil
+}
+
+// TVL values the reserves of snapshot.
+func TVL(info PoolInfo, snapshot PoolSnapshot, prices Prices) float64 {
+	prices = prices.or(info, snapshot.SqrtPrice)
+	return uiAmount(snapshot.ReserveA, info.DecimalsA)*prices.A + uiAmount(snapshot.ReserveB, info.DecimalsB)*prices.B
+}
+
+// FeeAPR annualizes lpFees, earned over window, against tvl.
+func FeeAPR(lpFees, tvl float64, window time.Duration) float64 {
+	if tvl <= 0 || window <= 0 {
+		return 0
+	}
+	return lpFees / tvl * float64(year) / float64(window)
+}
+
+// SnapshotStats computes the stats of pool over window, ending at the latest of snapshots,
+// out of the growth of the pool metrics. The window starts at the latest snapshot taken
+// at least window before, or the earliest one when there is none: From tells the actual
+// start, FeeAPR is annualized over it.
+func SnapshotStats(
+	pool solana.PublicKey,
+	info PoolInfo,
+	snapshots []PoolSnapshot,
+	window time.Duration,
+	prices Prices,
+) (PoolStats, error) {
+	if len(snapshots) < 2 {
+		return PoolStats{}, errors.New("at least two snapshots are needed")
+	}
+	sorted := sortSnapshots(snapshots)
+	return snapshotStats(pool, info, sorted, len(sorted)-1, window, prices), nil
+}
+
+// SnapshotSeries computes the stats of pool over window ending at every snapshot but the
+// earliest, oldest first. See SnapshotStats.
+func SnapshotSeries(
+	pool solana.PublicKey,
+	info PoolInfo,
+	snapshots []PoolSnapshot,
+	window time.Duration,
+	prices Prices,
+) []PoolStats {
+	sorted := sortSnapshots(snapshots)
+	var res []PoolStats
+	for end := 1; end < len(sorted); end++ {
+		res = append(res, snapshotStats(pool, info, sorted, end, window, prices))
+	}
+	return res
+}
+
+func snapshotStats(
+	pool solana.PublicKey,
+	info PoolInfo,
+	sorted []PoolSnapshot,
+	end int,
+	window time.Duration,
+	prices Prices,
+) PoolStats {
+	last := sorted[end]
+	first := sorted[0]
+	for _, s := range sorted[:end] {
+		if s.Time.After(last.Time.Add(-window)) {
+			break
+		}
+		first = s
+	}
+
+	prices = prices.or(info, last.SqrtPrice)
+	value := func(a, b *big.Int) float64 {
+		return uiBigAmount(a, info.DecimalsA)*prices.A + uiBigAmount(b, info.DecimalsB)*prices.B
+	}
+	delta := func(to, from uint64) *big.Int {
+		return new(big.Int).Sub(new(big.Int).SetUint64(to), new(big.Int).SetUint64(from))
+	}
+	m, m0 := last.Metrics, first.Metrics
+
+	stats := PoolStats{
+		Pool: pool,
+		From: first.Time,
+		To:   last.Time,
+		LpFees: value(
+			new(big.Int).Sub(m.TotalLpAFee.BigInt(), m0.TotalLpAFee.BigInt()),
+			new(big.Int).Sub(m.TotalLpBFee.BigInt(), m0.TotalLpBFee.BigInt()),
+		),
+		ProtocolFees: value(
+			delta(m.TotalProtocolAFee, m0.TotalProtocolAFee),
+			delta(m.TotalProtocolBFee, m0.TotalProtocolBFee),
+		),
+		PartnerFees: value(
+			delta(m.TotalPartnerAFee, m0.TotalPartnerAFee),
+			delta(m.TotalPartnerBFee, m0.TotalPartnerBFee),
+		),
+		TVL: TVL(info, last, prices),
+	}
+	stats.FeeAPR = FeeAPR(stats.LpFees, stats.TVL, stats.To.Sub(stats.From))
+	return stats
+}
+
+// PoolActivityOpts configures a PoolActivity.
+type PoolActivityOpts struct {
+	// Retention is the longest window stats are computed over, defaults to Window7d.
+	// Finalized swaps older than Retention before the latest swap of their pool are let go
+	// of, only their keys are kept to skip them when put again.
+	Retention time.Duration
+}
+
+// PoolActivity keeps the EvtSwap events of the pools it tracks to compute their volumes,
+// fees and fee APR over rolling windows, see Stats and Series.
+// It is safe for concurrent use by multiple goroutines.
+type PoolActivity struct {
+	eventStore[*poolSwap]
+	opts  PoolActivityOpts
+	pools map[solana.PublicKey]*activityPool
+}
+
+type activityPool struct {
+	info  PoolInfo
+	swaps map[indexer.Key]*poolSwap
+	// latest is the timestamp of the latest swap, kept the number of swaps the last prune left.
+	latest int64
+	kept   int
+}
+
+// poolSwap is a swap sized in UI units, with its fees in the token they were charged in.
+type poolSwap struct {
+	eventMeta
+	pool      solana.PublicKey
+	timestamp int64
+	aToB      bool
+	amountA   float64
+	amountB   float64
+	// price is the pool price after the swap, A in B.
+	price       float64
+	feesOnA     bool
+	lpFee       float64
+	protocolFee float64
+	partnerFee  float64
+	referralFee float64
+}
+
+var _ indexer.Store = (*PoolActivity)(nil)
+
+// NewPoolActivity returns a PoolActivity tracking no pool yet, see Track.
+func NewPoolActivity(opts PoolActivityOpts) *PoolActivity {
+	if opts.Retention <= 0 {
+		opts.Retention = Window7d
+	}
+	a := &PoolActivity{
+		eventStore: newEventStore[*poolSwap](),
+		opts:       opts,
+		pools:      make(map[solana.PublicKey]*activityPool),
+	}
+	a.onDrop = func(s *poolSwap) { delete(a.pools[s.pool].swaps, s.key) }
+	return a
+}
+
+// Track aggregates the swaps of pool. Swaps of pools not tracked are ignored.
+func (a *PoolActivity) Track(pool solana.PublicKey, info PoolInfo) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if _, ok := a.pools[pool]; !ok {
+		a.pools[pool] = &activityPool{info: info, swaps: make(map[indexer.Key]*poolSwap)}
+	}
+}
+
+// AddSwap adds the swap of update, e.g one out of SubscribeEvents or Backfill.
+// Updates carrying other events are ignored.
+func (a *PoolActivity) AddSwap(update dammv2gosdk.EventUpdate, finalized bool) error {
+	return addUpdate(a, update, finalized, "EvtSwap")
+}
+
+func (a *PoolActivity) Put(_ context.Context, records ...indexer.Record) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	for _, rec := range records {
+		pool, ok := a.pools[rec.Pool]
+		if rec.Swap == nil || !ok {
+			continue
+		}
+		if a.has(rec.Key) {
+			continue
+		}
+		s := pool.swap(rec)
+		pool.swaps[rec.Key] = s
+		pool.latest = max(pool.latest, s.timestamp)
+		a.add(s)
+
+		// pruning every time the swaps doubled keeps it linear.
+		if len(pool.swaps) >= 2*max(pool.kept, 64) {
+			a.prune(pool)
+		}
+	}
+	return nil
+}
+
+func (a *PoolActivity) Finalize(ctx context.Context, slots map[solana.Signature]uint64) error {
+	if err := a.eventStore.Finalize(ctx, slots); err != nil {
+		return err
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for _, pool := range a.pools {
+		a.prune(pool)
+	}
+	return nil
+}
+
+// prune lets go of the finalized swaps of pool past the retention.
+func (a *PoolActivity) prune(pool *activityPool) {
+	horizon := pool.latest - int64(a.opts.Retention/time.Second)
+	for key, s := range pool.swaps {
+		if s.final && s.timestamp <= horizon {
+			delete(pool.swaps, key)
+			a.release(s)
+		}
+	}
+	pool.kept = len(pool.swaps)
+}
+
+// Stats computes the stats of pool over the swaps of the window (at-window, at], which
+// shouldn't start before the retention of the latest swap. snapshot,
+// when not nil, provides the reserves TVL and FeeAPR are computed with. Zero prices value
+// token A at the pool price after each swap, or at the snapshot price for TVL.
+func (a *PoolActivity) Stats(
+	pool solana.PublicKey,
+	window time.Duration,
+	at time.Time,
+	snapshot *PoolSnapshot,
+	prices Prices,
+) (PoolStats, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	p, ok := a.pools[pool]
+	if !ok {
+		return PoolStats{}, fmt.Errorf("pool %s is not tracked", pool)
+	}
+	return p.stats(pool, window, at, snapshot, prices), nil
+}
+
+// Series computes the stats of pool over window ending at from, from+step, ... up to to,
+// oldest first. The TVL of each is computed out of the latest of snapshots taken at or
+// before its end, if any. See Stats.
+func (a *PoolActivity) Series(
+	pool solana.PublicKey,
+	window, step time.Duration,
+	from, to time.Time,
+	snapshots []PoolSnapshot,
+	prices Prices,
+) ([]PoolStats, error) {
+	if step <= 0 {
+		return nil, fmt.Errorf("step %s must be positive", step)
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	p, ok := a.pools[pool]
+	if !ok {
+		return nil, fmt.Errorf("pool %s is not tracked", pool)
+	}
+
+	sorted := sortSnapshots(snapshots)
+	var res []PoolStats
+	for at := from; !at.After(to); at = at.Add(step) {
+		var snapshot *PoolSnapshot
+		for i := range sorted {
+			if sorted[i].Time.After(at) {
+				break
+			}
+			snapshot = &sorted[i]
+		}
+		res = append(res, p.stats(pool, window, at, snapshot, prices))
+	}
+	return res, nil
+}
+
+func (p *activityPool) stats(
+	pool solana.PublicKey,
+	window time.Duration,
+	at time.Time,
+	snapshot *PoolSnapshot,
+	prices Prices,
+) PoolStats {
+	stats := PoolStats{Pool: pool, From: at.Add(-window), To: at}
+	from, to := stats.From.Unix(), at.Unix()
+
+	for _, s := range p.swaps {
+		if s.timestamp <= from || s.timestamp > to {
+			continue
+		}
+		swapPrices := prices
+		if swapPrices == (Prices{}) {
+			swapPrices = Prices{A: s.price, B: 1}
+		}
+		feePrice := swapPrices.B
+		if s.feesOnA {
+			feePrice = swapPrices.A
+		}
+
+		stats.Swaps++
+		stats.VolumeA += s.amountA
+		stats.VolumeB += s.amountB
+		if s.aToB {
+			stats.Volume += s.amountA * swapPrices.A
+		} else {
+			stats.Volume += s.amountB * swapPrices.B
+		}
+		stats.LpFees += s.lpFee * feePrice
+		stats.ProtocolFees += s.protocolFee * feePrice
+		stats.PartnerFees += s.partnerFee * feePrice
+		stats.ReferralFees += s.referralFee * feePrice
+	}
+
+	if snapshot != nil {
+		stats.TVL = TVL(p.info, *snapshot, prices)
+		stats.FeeAPR = FeeAPR(stats.LpFees, stats.TVL, window)
+	}
+	return stats
+}
+
+func (p *activityPool) swap(rec indexer.Record) *poolSwap {
+	swap := rec.Swap
+	aToB := swap.Direction == types.TradeDirectionAtoB
+	s := &poolSwap{
+		eventMeta: newEventMeta(rec),
+		pool:      rec.Pool,
+		timestamp: int64(swap.Timestamp),
+		aToB:      aToB,
+		feesOnA:   helpers.GetFeeMode(p.info.CollectFeeMode, !aToB).FeesOnTokenA,
+	}
+	s.price, _ = maths.GetPriceFromSqrtPrice(swap.NextSqrtPrice.BigInt(), p.info.DecimalsA, p.info.DecimalsB).Float64()
+
+	if aToB {
+		s.amountA = uiAmount(swap.AmountIn, p.info.DecimalsA)
+		s.amountB = uiAmount(swap.AmountOut, p.info.DecimalsB)
+	} else {
+		s.amountA = uiAmount(swap.AmountOut, p.info.DecimalsA)
+		s.amountB = uiAmount(swap.AmountIn, p.info.DecimalsB)
+	}
+
+	feeDecimals := p.info.DecimalsB
+	if s.feesOnA {
+		feeDecimals = p.info.DecimalsA
+	}
+	s.lpFee = uiAmount(swap.LpFee, feeDecimals)
+	s.protocolFee = uiAmount(swap.ProtocolFee, feeDecimals)
+	s.partnerFee = uiAmount(swap.PartnerFee, feeDecimals)
+	s.referralFee = uiAmount(swap.ReferralFee, feeDecimals)
+	return s
+}
+
+// or returns prices, or the zero Prices resolved at the pool price sqrtPrice.
+func (prices Prices) or(info PoolInfo, sqrtPrice ag_binary.Uint128) Prices {
+	if prices != (Prices{}) {
+		return prices
+	}
+	price, _ := maths.GetPriceFromSqrtPrice(sqrtPrice.BigInt(), info.DecimalsA, info.DecimalsB).Float64()
+	return Prices{A: price, B: 1}
+}
+
+func sortSnapshots(snapshots []PoolSnapshot) []PoolSnapshot {
+	sorted := slices.Clone(snapshots)
+	slices.SortStableFunc(sorted, func(a, b PoolSnapshot) int { return a.Time.Compare(b.Time) })
+	return sorted
+}
+
+func uiBigAmount(amount *big.Int, decimals uint8) float64 {
+	res, _ := new(big.Float).Quo(new(big.Float).SetInt(amount), big.NewFloat(math.Pow10(int(decimals)))).Float64()
+	return res
+}
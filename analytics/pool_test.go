@@ -0,0 +1,148 @@
+package analytics
+
+import (
+	"context"
+	dammv2gosdk "dammv2GoSDK"
+	cp_amm "dammv2GoSDK/generated/cpAmm"
+	"dammv2GoSDK/types"
+	"math"
+	"testing"
+	"time"
+
+	ag_binary "github.com/gagliardetto/binary"
+	"github.com/gagliardetto/solana-go"
+)
+
+func TestPoolActivity(t *testing.T) {
+	pool := solana.NewWallet().PublicKey()
+	a := NewPoolActivity(PoolActivityOpts{})
+	// token A has 9 decimals, token B 6, fees are charged in both.
+	a.Track(pool, PoolInfo{DecimalsA: 9, DecimalsB: 6, CollectFeeMode: types.CollectFeeModeBothToken})
+
+	const sol, usdc = 1_000_000_000, 1_000_000
+	sell := swapUpdate(pool, 1, 10, 100_000, types.TradeDirectionAtoB, 2*sol, 300*usdc)
+	sell.Event.Data.(*cp_amm.EvtSwapEventData).SwapResult.LpFee = 3 * usdc
+	buy := swapUpdate(pool, 2, 11, 101_000, types.TradeDirectionBtoA, 150*usdc, sol)
+	buy.Event.Data.(*cp_amm.EvtSwapEventData).SwapResult.LpFee = sol / 100
+	buy.Event.Data.(*cp_amm.EvtSwapEventData).SwapResult.ProtocolFee = sol / 200
+	old := swapUpdate(pool, 3, 9, 100_000-86_400, types.TradeDirectionAtoB, sol, 150*usdc)
+	for _, update := range []dammv2gosdk.EventUpdate{sell, buy, old} {
+		if err := a.AddSwap(update, false); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	snapshot := &PoolSnapshot{Time: time.Unix(101_000, 0), ReserveA: 10 * sol, ReserveB: 1_500 * usdc}
+	prices := Prices{A: 150, B: 1}
+	stats, err := a.Stats(pool, Window24h, time.Unix(101_000, 0), snapshot, prices)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the fees of the buy are charged in token A.
+	if stats.Swaps != 2 || stats.VolumeA != 3 || stats.VolumeB != 450 || stats.Volume != 450 ||
+		stats.LpFees != 4.5 || stats.ProtocolFees != 0.75 || stats.TVL != 3_000 {
+		t.Fatalf("stats = %+v", stats)
+	}
+	if want := 4.5 / 3_000 * 365; math.Abs(stats.FeeAPR-want) > 1e-9 {
+		t.Fatalf("fee APR = %v, want %v", stats.FeeAPR, want)
+	}
+
+	// the buy was on an abandoned fork. The old swap is out of the first window, the sell
+	// out of the second one.
+	if err := a.Drop(context.Background(), solana.Signature{2}); err != nil {
+		t.Fatal(err)
+	}
+	series, err := a.Series(pool, Window24h, Window24h, time.Unix(100_000, 0), time.Unix(100_000+86_400, 0), nil, prices)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(series) != 2 || series[0].Swaps != 1 || series[0].Volume != 300 || series[1].Swaps != 0 || series[1].TVL != 0 {
+		t.Fatalf("series = %+v", series)
+	}
+}
+
+func TestPoolActivityPrunes(t *testing.T) {
+	pool := solana.NewWallet().PublicKey()
+	a := NewPoolActivity(PoolActivityOpts{Retention: Window24h})
+	a.Track(pool, PoolInfo{DecimalsA: 9, DecimalsB: 6})
+
+	const sol, usdc = 1_000_000_000, 1_000_000
+	old := swapUpdate(pool, 1, 9, 100_000-86_400, types.TradeDirectionAtoB, sol, 150*usdc)
+	recent := swapUpdate(pool, 2, 10, 100_000, types.TradeDirectionAtoB, sol, 150*usdc)
+	for _, update := range []dammv2gosdk.EventUpdate{old, recent} {
+		if err := a.AddSwap(update, false); err != nil {
+			t.Fatal(err)
+		}
+	}
+	swaps := func() int {
+		stats, err := a.Stats(pool, Window7d, time.Unix(100_000, 0), nil, Prices{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return stats.Swaps
+	}
+
+	// the old swap is past the retention but only let go of once final.
+	if n := swaps(); n != 2 {
+		t.Fatalf("%d swaps before finalizing, want 2", n)
+	}
+	slots := map[solana.Signature]uint64{{1}: 9, {2}: 10}
+	if err := a.Finalize(context.Background(), slots); err != nil {
+		t.Fatal(err)
+	}
+	if n := swaps(); n != 1 {
+		t.Fatalf("%d swaps after finalizing, want 1", n)
+	}
+
+	// putting it again doesn't bring it back.
+	if err := a.AddSwap(old, true); err != nil {
+		t.Fatal(err)
+	}
+	if n := swaps(); n != 1 {
+		t.Fatalf("%d swaps after putting it again, want 1", n)
+	}
+}
+
+func TestSnapshotStats(t *testing.T) {
+	pool := solana.NewWallet().PublicKey()
+	info := PoolInfo{DecimalsA: 9, DecimalsB: 6}
+	snapshot := func(day int64, lpFeeA, lpFeeB, protocolFeeB uint64) PoolSnapshot {
+		return PoolSnapshot{
+			Time: time.Unix(day*86_400, 0),
+			Metrics: cp_amm.PoolMetrics{
+				TotalLpAFee:       ag_binary.Uint128{Lo: lpFeeA},
+				TotalLpBFee:       ag_binary.Uint128{Lo: lpFeeB},
+				TotalProtocolBFee: protocolFeeB,
+			},
+			// 1 raw token A is 4 raw token B, 1 token A 4000 token B.
+			SqrtPrice: ag_binary.Uint128{Hi: 2},
+			ReserveA:  1_000_000_000,
+			ReserveB:  4_000_000,
+		}
+	}
+	snapshots := []PoolSnapshot{
+		snapshot(10, 2_000_000_000, 10_000_000, 1_000_000),
+		snapshot(1, 0, 0, 0),
+		snapshot(3, 1_000_000_000, 2_000_000, 500_000),
+	}
+
+	stats, err := SnapshotStats(pool, info, snapshots, Window7d, Prices{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !stats.From.Equal(time.Unix(3*86_400, 0)) || math.Abs(stats.LpFees-4_008) > 1e-9 ||
+		math.Abs(stats.ProtocolFees-0.5) > 1e-9 || math.Abs(stats.TVL-4_004) > 1e-9 {
+		t.Fatalf("stats = %+v", stats)
+	}
+	if want := 4_008.0 / 4_004 * 365 / 7; math.Abs(stats.FeeAPR-want) > 1e-9 {
+		t.Fatalf("fee APR = %v, want %v", stats.FeeAPR, want)
+	}
+
+	if series := SnapshotSeries(pool, info, snapshots, Window24h, Prices{A: 4, B: 1}); len(series) != 2 ||
+		series[0].LpFees != 6 || !series[0].From.Equal(time.Unix(86_400, 0)) {
+		t.Fatalf("series = %+v", series)
+	}
+	if _, err := SnapshotStats(pool, info, snapshots[:1], Window24h, Prices{}); err == nil {
+		t.Fatal("a single snapshot has no window")
+	}
+}
@@ -0,0 +1,440 @@
+package analytics
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	dammv2gosdk "dammv2GoSDK"
+	"dammv2GoSDK/constants"
+	cp_amm "dammv2GoSDK/generated/cpAmm"
+	"dammv2GoSDK/helpers"
+	"dammv2GoSDK/indexer"
+	"dammv2GoSDK/maths"
+	"dammv2GoSDK/types"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"slices"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+)
+
+// Unit is the token values are expressed in.
+type Unit uint8
+
+const (
+	// UnitTokenB values amounts in token B.
+	UnitTokenB Unit = iota
+	// UnitTokenA values amounts in token A.
+	UnitTokenA
+)
+
+// PositionReward is a farming reward of a position, raw amounts of the reward mint.
+// Rewards split into the position weren't earned by it and are in neither amount.
+type PositionReward struct {
+	Mint solana.PublicKey
+	// Claimed is the reward claimed, or split out of the position.
+	Claimed uint64
+	// Pending is the reward settled on the position, or accrued since its checkpoint, and
+	// not claimed yet.
+	Pending uint64
+}
+
+// PositionPnL is the performance of a position. Amounts are in UI units, values in Unit:
+// deposits and withdrawals are valued at the pool price they were made at, the rest at
+// the current pool price.
+type PositionPnL struct {
+	Position solana.PublicKey
+	Owner    solana.PublicKey
+	Unit     Unit
+	// Price is the current pool price, token A in token B.
+	Price float64
+	// Liquidity is the liquidity of the position, unlocked, vesting and permanently locked.
+	Liquidity *big.Int
+	// VestingLiquidity and PermanentLockedLiquidity are the liquidity locked by the events.
+	// Vesting liquidity is released over time, without events.
+	VestingLiquidity         *big.Int
+	PermanentLockedLiquidity *big.Int
+	// AmountA and AmountB are the tokens Liquidity is worth, Value their value.
+	AmountA float64
+	AmountB float64
+	Value   float64
+
+	// DepositedA and DepositedB are the tokens added, CostBasis their value. Liquidity
+	// received through a split counts as deposited.
+	DepositedA float64
+	DepositedB float64
+	CostBasis  float64
+	// WithdrawnA and WithdrawnB are the tokens removed, Withdrawn their value. Liquidity
+	// split out counts as withdrawn.
+	WithdrawnA float64
+	WithdrawnB float64
+	Withdrawn  float64
+
+	// RealizedFeesA and RealizedFeesB are the fees claimed, or split out of the position,
+	// UnrealizedFeesA and UnrealizedFeesB the ones earned and not claimed yet. Fees is the
+	// value of all of them. Fees split into the position weren't earned by it and are in
+	// neither amount.
+	RealizedFeesA   float64
+	RealizedFeesB   float64
+	UnrealizedFeesA float64
+	UnrealizedFeesB float64
+	Fees            float64
+	// Rewards are not valued, their mints aren't priced by the pool.
+	Rewards [2]PositionReward
+
+	// HoldValue is the current value of the deposited tokens less the withdrawn ones, had
+	// they been held instead.
+	HoldValue float64
+	// ImpermanentLoss is Value less HoldValue, fees excluded. It is negative on a loss.
+	ImpermanentLoss float64
+	// PnL is Value, Withdrawn and Fees less CostBasis.
+	PnL float64
+}
+
+// PositionTracker keeps the EvtCreatePosition, EvtAddLiquidity, EvtRemoveLiquidity,
+// EvtClaimPositionFee, EvtClaimReward, EvtSplitPosition and EvtLockPosition events of a
+// single position, and replays them in slot order to compute its PnL. The events of
+// other positions are skipped, so the tracker can share an indexer with other stores.
+// It is safe for concurrent use by multiple goroutines.
+type PositionTracker struct {
+	eventStore[*positionEvent]
+	position solana.PublicKey
+	info     PoolInfo
+}
+
+// positionEvent is an event of the position, as written.
+type positionEvent struct {
+	eventMeta
+	rec indexer.Record
+}
+
+var _ indexer.Store = (*PositionTracker)(nil)
+
+// NewPositionTracker returns a tracker of position, of a pool whose tokens are described
+// by info.
+func NewPositionTracker(position solana.PublicKey, info PoolInfo) *PositionTracker {
+	return &PositionTracker{
+		eventStore: newEventStore[*positionEvent](),
+		position:   position,
+		info:       info,
+	}
+}
+
+// AddEvent adds the event of update, e.g one out of SubscribeEvents or Backfill.
+// Updates carrying events of other positions are ignored.
+func (t *PositionTracker) AddEvent(update dammv2gosdk.EventUpdate, finalized bool) error {
+	return addUpdate(t, update, finalized, "")
+}
+
+func (t *PositionTracker) Put(_ context.Context, records ...indexer.Record) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for _, rec := range records {
+		if t.has(rec.Key) || !t.concerns(rec) {
+			continue
+		}
+		t.add(&positionEvent{eventMeta: newEventMeta(rec), rec: rec})
+	}
+	return nil
+}
+
+// PnL replays the history of the position against pool, its current state, valuing it in
+// unit. position is the current state of the position, nil once it's closed: unrealized
+// fees and rewards are computed out of it, and its liquidity, when set, is authoritative
+// over the replayed one. Rewards are accrued up to at, e.g the block time the states were
+// fetched at.
+func (t *PositionTracker) PnL(
+	pool *cp_amm.PoolAccount,
+	position *cp_amm.PositionAccount,
+	at time.Time,
+	unit Unit,
+) (PositionPnL, error) {
+	if pool == nil {
+		return PositionPnL{}, errors.New("pool state is required")
+	}
+
+	t.mu.Lock()
+	var events []positionEvent
+	for _, byTx := range t.events {
+		for _, e := range byTx {
+			events = append(events, *e)
+		}
+	}
+	t.mu.Unlock()
+	slices.SortFunc(events, func(a, b positionEvent) int {
+		return cmp.Or(
+			cmp.Compare(a.slot, b.slot),
+			bytes.Compare(a.key.Signature[:], b.key.Signature[:]),
+			cmp.Compare(a.key.Index, b.key.Index),
+		)
+	})
+
+	v := positionValuer{
+		info:         t.info,
+		unit:         unit,
+		sqrtMinPrice: pool.SqrtMinPrice.BigInt(),
+		sqrtMaxPrice: pool.SqrtMaxPrice.BigInt(),
+	}
+	sqrtPrice := pool.SqrtPrice.BigInt()
+	price := v.price(sqrtPrice)
+
+	res := PositionPnL{
+		Position:                 t.position,
+		Unit:                     unit,
+		Price:                    price,
+		Liquidity:                new(big.Int),
+		VestingLiquidity:         new(big.Int),
+		PermanentLockedLiquidity: new(big.Int),
+	}
+	for i := range res.Rewards {
+		res.Rewards[i].Mint = pool.RewardInfos[i].Mint
+	}
+
+	var in splitIn
+	for i := range events {
+		if err := t.apply(&res, v, &in, &events[i].rec); err != nil {
+			return PositionPnL{}, err
+		}
+	}
+
+	if position != nil {
+		res.Liquidity = positionLiquidity(position)
+		feeA, feeB := unclaimedFees(pool, position, res.Liquidity)
+		res.UnrealizedFeesA = uiBigAmount(earned(feeA, in.feeA), t.info.DecimalsA)
+		res.UnrealizedFeesB = uiBigAmount(earned(feeB, in.feeB), t.info.DecimalsB)
+		for i, reward := range unclaimedRewards(pool, position, res.Liquidity, at) {
+			res.Rewards[i].Pending = earned(reward, in.rewards[i]).Uint64()
+		}
+	}
+
+	res.AmountA, res.AmountB = v.amounts(res.Liquidity, sqrtPrice)
+	res.Value = v.value(res.AmountA, res.AmountB, price)
+	res.Fees = v.value(res.RealizedFeesA+res.UnrealizedFeesA, res.RealizedFeesB+res.UnrealizedFeesB, price)
+	res.HoldValue = v.value(res.DepositedA-res.WithdrawnA, res.DepositedB-res.WithdrawnB, price)
+	res.ImpermanentLoss = res.Value - res.HoldValue
+	res.PnL = res.Value + res.Withdrawn + res.Fees - res.CostBasis
+	return res, nil
+}
+
+// splitIn are the fees and rewards split into the position, not claimed yet.
+type splitIn struct {
+	feeA, feeB uint64
+	rewards    [2]uint64
+}
+
+// take returns the part of amount, claimed or split out, that was split in, taking it off
+// *in: claims and splits take the pending fees and rewards as a whole.
+func take(in *uint64, amount uint64) uint64 {
+	taken := min(*in, amount)
+	*in -= taken
+	return taken
+}
+
+// apply replays rec on res.
+func (t *PositionTracker) apply(res *PositionPnL, v positionValuer, in *splitIn, rec *indexer.Record) error {
+	switch {
+	case rec.Name == "EvtCreatePosition":
+		var evt cp_amm.EvtCreatePositionEventData
+		if err := json.Unmarshal(rec.Data, &evt); err != nil {
+			return fmt.Errorf("err decoding the event of %s: %w", rec.Signature, err)
+		}
+		res.Owner = evt.Owner
+
+	case rec.Liquidity != nil:
+		change := rec.Liquidity
+		delta := change.LiquidityDelta.BigInt()
+		amountA := uiAmount(change.AmountA, t.info.DecimalsA)
+		amountB := uiAmount(change.AmountB, t.info.DecimalsB)
+		price := v.price(v.eventSqrtPrice(delta, change.AmountB))
+		if change.Action == indexer.LiquidityAdd {
+			res.Liquidity.Add(res.Liquidity, delta)
+			res.DepositedA += amountA
+			res.DepositedB += amountB
+			res.CostBasis += v.value(amountA, amountB, price)
+		} else {
+			res.Liquidity.Sub(res.Liquidity, delta)
+			res.WithdrawnA += amountA
+			res.WithdrawnB += amountB
+			res.Withdrawn += v.value(amountA, amountB, price)
+		}
+
+	case rec.FeeClaim != nil:
+		claim := rec.FeeClaim
+		res.RealizedFeesA += uiAmount(claim.AmountA-take(&in.feeA, claim.AmountA), t.info.DecimalsA)
+		res.RealizedFeesB += uiAmount(claim.AmountB-take(&in.feeB, claim.AmountB), t.info.DecimalsB)
+
+	case rec.Reward != nil:
+		if i := rec.Reward.RewardIndex; int(i) < len(res.Rewards) {
+			res.Rewards[i].Claimed += rec.Reward.Amount - take(&in.rewards[i], rec.Reward.Amount)
+		}
+
+	case rec.Lock != nil:
+		if rec.Lock.Action == indexer.LockPermanent {
+			res.PermanentLockedLiquidity.Add(res.PermanentLockedLiquidity, rec.Lock.Liquidity.BigInt())
+		} else {
+			res.VestingLiquidity.Add(res.VestingLiquidity, rec.Lock.Liquidity.BigInt())
+		}
+
+	case rec.Split != nil:
+		var evt cp_amm.EvtSplitPositionEventData
+		if err := json.Unmarshal(rec.Data, &evt); err != nil {
+			return fmt.Errorf("err decoding the event of %s: %w", rec.Signature, err)
+		}
+		split := rec.Split
+		permanent := split.PermanentLockedLiquidity.BigInt()
+		liquidity := new(big.Int).Add(split.UnlockedLiquidity.BigInt(), permanent)
+		sqrtPrice := evt.CurrentSqrtPrice.BigInt()
+		amountA, amountB := v.amounts(liquidity, sqrtPrice)
+		value := v.value(amountA, amountB, v.price(sqrtPrice))
+		rewards := [2]uint64{split.Reward0, split.Reward1}
+		if split.FirstPosition == t.position {
+			res.Liquidity.Sub(res.Liquidity, liquidity)
+			res.PermanentLockedLiquidity.Sub(res.PermanentLockedLiquidity, permanent)
+			res.WithdrawnA += amountA
+			res.WithdrawnB += amountB
+			res.Withdrawn += value
+			// the fees and rewards split out were earned, as if claimed.
+			res.RealizedFeesA += uiAmount(split.FeeA-take(&in.feeA, split.FeeA), t.info.DecimalsA)
+			res.RealizedFeesB += uiAmount(split.FeeB-take(&in.feeB, split.FeeB), t.info.DecimalsB)
+			for i, reward := range rewards {
+				res.Rewards[i].Claimed += reward - take(&in.rewards[i], reward)
+			}
+		} else {
+			res.Owner = split.SecondOwner
+			res.Liquidity.Add(res.Liquidity, liquidity)
+			res.PermanentLockedLiquidity.Add(res.PermanentLockedLiquidity, permanent)
+			res.DepositedA += amountA
+			res.DepositedB += amountB
+			res.CostBasis += value
+			in.feeA += split.FeeA
+			in.feeB += split.FeeB
+			for i, reward := range rewards {
+				in.rewards[i] += reward
+			}
+		}
+	}
+	return nil
+}
+
+// concerns reports whether rec is an event of the position that changes its PnL.
+func (t *PositionTracker) concerns(rec indexer.Record) bool {
+	switch {
+	case rec.Name == "EvtCreatePosition":
+		var evt cp_amm.EvtCreatePositionEventData
+		return json.Unmarshal(rec.Data, &evt) == nil && evt.Position == t.position
+	case rec.Liquidity != nil:
+		return rec.Liquidity.Position == t.position
+	case rec.FeeClaim != nil:
+		return rec.FeeClaim.Action == indexer.FeeClaimPosition && rec.FeeClaim.Position == t.position
+	case rec.Reward != nil:
+		return rec.Reward.Action == indexer.RewardClaim && rec.Reward.Position == t.position
+	case rec.Lock != nil:
+		return rec.Lock.Position == t.position
+	case rec.Split != nil:
+		return rec.Split.FirstPosition == t.position || rec.Split.SecondPosition == t.position
+	}
+	return false
+}
+
+// positionValuer values the tokens of a position in a unit.
+type positionValuer struct {
+	info         PoolInfo
+	unit         Unit
+	sqrtMinPrice *big.Int
+	sqrtMaxPrice *big.Int
+}
+
+// price returns the UI price, token A in token B, of sqrtPrice.
+func (v positionValuer) price(sqrtPrice *big.Int) float64 {
+	price, _ := maths.GetPriceFromSqrtPrice(sqrtPrice, v.info.DecimalsA, v.info.DecimalsB).Float64()
+	return price
+}
+
+// value returns the value of amountA and amountB at price, token A in token B.
+func (v positionValuer) value(amountA, amountB, price float64) float64 {
+	if v.unit == UnitTokenA {
+		if price == 0 {
+			return amountA
+		}
+		return amountA + amountB/price
+	}
+	return amountA*price + amountB
+}
+
+// amounts returns the tokens liquidity is worth at sqrtPrice, in UI units.
+func (v positionValuer) amounts(liquidity, sqrtPrice *big.Int) (float64, float64) {
+	if liquidity.Sign() <= 0 || sqrtPrice.Sign() == 0 {
+		return 0, 0
+	}
+	amountA := helpers.GetAmountAFromLiquidityDelta(liquidity, sqrtPrice, v.sqrtMaxPrice, types.RoundingDown)
+	amountB := helpers.GetAmountBFromLiquidityDelta(liquidity, sqrtPrice, v.sqrtMinPrice, types.RoundingDown)
+	return uiBigAmount(amountA, v.info.DecimalsA), uiBigAmount(amountB, v.info.DecimalsB)
+}
+
+// eventSqrtPrice returns the pool price a liquidity change of liquidity for amountB of
+// token B was made at, out of Δb = L * (√P - √P_min).
+func (v positionValuer) eventSqrtPrice(liquidity *big.Int, amountB uint64) *big.Int {
+	if liquidity.Sign() == 0 {
+		return new(big.Int).Set(v.sqrtMinPrice)
+	}
+	sqrtPrice := new(big.Int).Lsh(new(big.Int).SetUint64(amountB), 128)
+	sqrtPrice.Div(sqrtPrice, liquidity)
+	return sqrtPrice.Add(sqrtPrice, v.sqrtMinPrice)
+}
+
+func positionLiquidity(position *cp_amm.PositionAccount) *big.Int {
+	liquidity := new(big.Int).Add(position.UnlockedLiquidity.BigInt(), position.VestedLiquidity.BigInt())
+	return liquidity.Add(liquidity, position.PermanentLockedLiquidity.BigInt())
+}
+
+// unclaimedFees returns the fees of position not claimed yet, pending ones and the ones
+// accrued since its checkpoints: liquidity * (fee per liquidity - checkpoint) >> 128.
+func unclaimedFees(pool *cp_amm.PoolAccount, position *cp_amm.PositionAccount, liquidity *big.Int) (*big.Int, *big.Int) {
+	accrued := func(perLiquidity, checkpoint [32]uint8, pending uint64) *big.Int {
+		delta := new(big.Int).Sub(helpers.U256ToBigInt(perLiquidity), helpers.U256ToBigInt(checkpoint))
+		fee := new(big.Int).Rsh(delta.Mul(delta, liquidity), constants.LiquidityScale)
+		return fee.Add(fee, new(big.Int).SetUint64(pending))
+	}
+	return accrued(pool.FeeAPerLiquidity, position.FeeAPerTokenCheckpoint, position.FeeAPending),
+		accrued(pool.FeeBPerLiquidity, position.FeeBPerTokenCheckpoint, position.FeeBPending)
+}
+
+// unclaimedRewards returns the rewards of position not claimed yet at, pending ones and the
+// ones accrued since its checkpoints. The reward per token of the pool is brought up to at
+// first, as the program does: + rate * seconds << 128 / pool liquidity, the rate being
+// scaled by 2^64. Then liquidity * (reward per token - checkpoint) >> 192.
+func unclaimedRewards(pool *cp_amm.PoolAccount, position *cp_amm.PositionAccount, liquidity *big.Int, at time.Time) [2]*big.Int {
+	now := uint64(max(at.Unix(), 0))
+	poolLiquidity := pool.Liquidity.BigInt()
+
+	var res [2]*big.Int
+	for i, reward := range pool.RewardInfos {
+		perToken := helpers.U256ToBigInt(reward.RewardPerTokenStored)
+		end := min(now, reward.RewardDurationEnd)
+		if reward.Initialized != 0 && end > reward.LastUpdateTime && poolLiquidity.Sign() > 0 {
+			accrued := new(big.Int).SetUint64(end - reward.LastUpdateTime)
+			accrued.Mul(accrued, reward.RewardRate.BigInt())
+			accrued.Lsh(accrued, constants.LiquidityScale)
+			perToken.Add(perToken, accrued.Div(accrued, poolLiquidity))
+		}
+
+		checkpoint := position.RewardInfos[i]
+		delta := perToken.Sub(perToken, helpers.U256ToBigInt(checkpoint.RewardPerTokenCheckpoint))
+		amount := delta.Rsh(delta.Mul(delta, liquidity), constants.LiquidityScale+constants.ScaleOffset)
+		res[i] = amount.Add(amount, new(big.Int).SetUint64(checkpoint.RewardPendings))
+	}
+	return res
+}
+
+// earned returns amount less the part of it split in, in.
+func earned(amount *big.Int, in uint64) *big.Int {
+	amount.Sub(amount, new(big.Int).SetUint64(in))
+	if amount.Sign() < 0 {
+		amount.SetInt64(0)
+	}
+	return amount
+}
@@ -0,0 +1,165 @@
+package analytics
+
+import (
+	dammv2gosdk "dammv2GoSDK"
+	cp_amm "dammv2GoSDK/generated/cpAmm"
+	"dammv2GoSDK/internal/test/eventtest"
+	"math"
+	"math/big"
+	"testing"
+	"time"
+
+	ag_binary "github.com/gagliardetto/binary"
+	"github.com/gagliardetto/solana-go"
+)
+
+func TestPositionTracker(t *testing.T) {
+	pool := solana.NewWallet().PublicKey()
+	position, owner := solana.NewWallet().PublicKey(), solana.NewWallet().PublicKey()
+	// tokens without decimals, to make the amounts the curve's.
+	tracker := NewPositionTracker(position, PoolInfo{})
+
+	// a liquidity of 1200 << 64 is worth 600 A and 2400 B at a price of 4 (√P = 2 << 64),
+	// 400 A and 3600 B at a price of 9.
+	for _, update := range []dammv2gosdk.EventUpdate{
+		eventtest.Update(5, 50, "EvtClaimPositionFee", &cp_amm.EvtClaimPositionFeeEventData{
+			Pool: pool, Position: position, FeeAClaimed: 10, FeeBClaimed: 50,
+		}),
+		eventtest.Update(1, 10, "EvtCreatePosition", &cp_amm.EvtCreatePositionEventData{
+			Pool: pool, Position: position, Owner: owner,
+		}),
+		eventtest.Update(2, 10, "EvtAddLiquidity", &cp_amm.EvtAddLiquidityEventData{
+			Pool: pool, Position: position, Owner: owner,
+			Params:       cp_amm.AddLiquidityParameters{LiquidityDelta: ag_binary.Uint128{Hi: 1200}},
+			TokenAAmount: 600,
+			TokenBAmount: 2400,
+		}),
+		eventtest.Update(4, 40, "EvtRemoveLiquidity", &cp_amm.EvtRemoveLiquidityEventData{
+			Pool: pool, Position: position, Owner: owner,
+			Params:       cp_amm.RemoveLiquidityParameters{LiquidityDelta: ag_binary.Uint128{Hi: 600}},
+			TokenAAmount: 200,
+			TokenBAmount: 1800,
+		}),
+		eventtest.Update(6, 60, "EvtClaimReward", &cp_amm.EvtClaimRewardEventData{
+			Pool: pool, Position: position, Owner: owner, TotalReward: 7,
+		}),
+		// another position.
+		eventtest.Update(7, 70, "EvtClaimPositionFee", &cp_amm.EvtClaimPositionFeeEventData{
+			Pool: pool, Position: solana.NewWallet().PublicKey(), FeeAClaimed: 1_000,
+		}),
+	} {
+		if err := tracker.AddEvent(update, false); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	poolState := &cp_amm.PoolAccount{
+		SqrtMinPrice: ag_binary.Uint128{},
+		SqrtMaxPrice: ag_binary.Uint128{Hi: 1 << 40},
+		SqrtPrice:    ag_binary.Uint128{Hi: 3},
+		Liquidity:    ag_binary.Uint128{Hi: 600},
+	}
+	// 12 reward tokens a second, scaled by 2^64, accrued for 50s since the last update,
+	// all to the position.
+	poolState.RewardInfos[0] = cp_amm.RewardInfo{
+		Initialized:       1,
+		RewardRate:        ag_binary.Uint128{Hi: 12},
+		LastUpdateTime:    100,
+		RewardDurationEnd: 150,
+	}
+	// 600 B fees accrued since the checkpoint of the remaining liquidity, 600 << 64.
+	poolState.FeeBPerLiquidity[8] = 1
+	positionState := &cp_amm.PositionAccount{
+		UnlockedLiquidity: ag_binary.Uint128{Hi: 600},
+		FeeAPending:       1,
+		FeeBPending:       5,
+		RewardInfos:       [2]cp_amm.UserRewardInfo{{RewardPendings: 3}},
+	}
+
+	pnl, err := tracker.PnL(poolState, positionState, time.Unix(200, 0), UnitTokenB)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the curve rounds the amounts of the liquidity down.
+	near := func(got, want float64) bool { return math.Abs(got-want) <= 10 }
+	if pnl.Owner != owner || pnl.Liquidity.Cmp(new(big.Int).Lsh(big.NewInt(600), 64)) != 0 || !near(pnl.Price, 9) ||
+		!near(pnl.AmountA, 200) || !near(pnl.AmountB, 1800) || !near(pnl.Value, 3600) {
+		t.Fatalf("pnl = %+v", pnl)
+	}
+	if pnl.CostBasis != 4800 || pnl.Withdrawn != 3600 || pnl.HoldValue != 4200 ||
+		pnl.RealizedFeesA != 10 || pnl.RealizedFeesB != 50 || pnl.UnrealizedFeesA != 1 || pnl.UnrealizedFeesB != 605 {
+		t.Fatalf("pnl = %+v", pnl)
+	}
+	// holding would have made 600 more than providing liquidity, fees made up for it.
+	if !near(pnl.ImpermanentLoss, -600) || !near(pnl.Fees, 754) || !near(pnl.PnL, 3600+3600+754-4800) {
+		t.Fatalf("pnl = %+v", pnl)
+	}
+	if pnl.Rewards[0].Claimed != 7 || pnl.Rewards[0].Pending != 603 {
+		t.Fatalf("rewards = %+v", pnl.Rewards)
+	}
+
+	pnl, err = tracker.PnL(poolState, positionState, time.Unix(200, 0), UnitTokenA)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !near(pnl.Value, 400) || !near(pnl.CostBasis, 600+2400.0/4) {
+		t.Fatalf("pnl = %+v", pnl)
+	}
+}
+
+func TestPositionTrackerSplit(t *testing.T) {
+	pool := solana.NewWallet().PublicKey()
+	first, second := solana.NewWallet().PublicKey(), solana.NewWallet().PublicKey()
+	owner := solana.NewWallet().PublicKey()
+	firstTracker := NewPositionTracker(first, PoolInfo{})
+	secondTracker := NewPositionTracker(second, PoolInfo{})
+
+	// a liquidity of 600 << 64 is worth 300 A and 1200 B at a price of 4, along with the
+	// pending fees and rewards split.
+	for _, update := range []dammv2gosdk.EventUpdate{
+		eventtest.Update(1, 10, "EvtSplitPosition", &cp_amm.EvtSplitPositionEventData{
+			Pool: pool, FirstOwner: owner, SecondOwner: owner, FirstPosition: first, SecondPosition: second,
+			CurrentSqrtPrice: ag_binary.Uint128{Hi: 2},
+			AmountSplits: cp_amm.SplitAmountInfo{
+				UnlockedLiquidity: ag_binary.Uint128{Hi: 600},
+				FeeA:              4,
+				FeeB:              20,
+				Reward0:           5,
+			},
+		}),
+		// the claim takes the fees split in along with the ones earned since.
+		eventtest.Update(2, 20, "EvtClaimPositionFee", &cp_amm.EvtClaimPositionFeeEventData{
+			Pool: pool, Position: second, FeeAClaimed: 6, FeeBClaimed: 30,
+		}),
+	} {
+		for _, tracker := range []*PositionTracker{firstTracker, secondTracker} {
+			if err := tracker.AddEvent(update, false); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	poolState := &cp_amm.PoolAccount{SqrtMaxPrice: ag_binary.Uint128{Hi: 1 << 40}, SqrtPrice: ag_binary.Uint128{Hi: 2}}
+	pnl, err := firstTracker.PnL(poolState, nil, time.Time{}, UnitTokenB)
+	if err != nil {
+		t.Fatal(err)
+	}
+	near := func(got, want float64) bool { return math.Abs(got-want) <= 10 }
+	if !near(pnl.Withdrawn, 2400) || pnl.RealizedFeesA != 4 || pnl.RealizedFeesB != 20 || pnl.Rewards[0].Claimed != 5 {
+		t.Fatalf("first pnl = %+v", pnl)
+	}
+
+	// the reward split in is still pending, along with 2 earned since.
+	positionState := &cp_amm.PositionAccount{
+		UnlockedLiquidity: ag_binary.Uint128{Hi: 600},
+		RewardInfos:       [2]cp_amm.UserRewardInfo{{RewardPendings: 7}},
+	}
+	pnl, err = secondTracker.PnL(poolState, positionState, time.Time{}, UnitTokenB)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pnl.Owner != owner || !near(pnl.CostBasis, 2400) || pnl.RealizedFeesA != 2 || pnl.RealizedFeesB != 10 ||
+		pnl.UnrealizedFeesA != 0 || pnl.UnrealizedFeesB != 0 || pnl.Rewards[0].Claimed != 0 || pnl.Rewards[0].Pending != 2 {
+		t.Fatalf("second pnl = %+v", pnl)
+	}
+}
@@ -0,0 +1,138 @@
+package analytics
+
+import (
+	"context"
+	dammv2gosdk "dammv2GoSDK"
+	"dammv2GoSDK/indexer"
+	"slices"
+	"sync"
+
+	"github.com/gagliardetto/solana-go"
+)
+
+// eventMeta identifies an event a store is derived from, along with the slot it was
+// written, or finalized, in.
+type eventMeta struct {
+	key   indexer.Key
+	slot  uint64
+	final bool
+}
+
+func (m *eventMeta) meta() *eventMeta { return m }
+
+func newEventMeta(rec indexer.Record) eventMeta {
+	return eventMeta{key: rec.Key, slot: rec.Slot, final: rec.Finalized}
+}
+
+// storedEvent is an event a store keeps, embedding its eventMeta.
+type storedEvent interface {
+	meta() *eventMeta
+}
+
+// eventStore implements the indexer.Store methods settling the events a store keeps:
+// Pending, Finalize, Drop and Close. Stores embed it, add their events with add, and guard
+// their own state with mu too.
+type eventStore[E storedEvent] struct {
+	mu     sync.Mutex
+	events map[solana.Signature][]E
+	// released are the keys of the events let go of with release, still skipped by has.
+	released map[indexer.Key]struct{}
+
+	// onFinalize and onDrop, when set, are called with mu held for every event finalized,
+	// and dropped, respectively.
+	onFinalize func(E)
+	onDrop     func(E)
+}
+
+func newEventStore[E storedEvent]() eventStore[E] {
+	return eventStore[E]{
+		events:   make(map[solana.Signature][]E),
+		released: make(map[indexer.Key]struct{}),
+	}
+}
+
+// has reports whether the event of key was added, whether it was released since or not.
+func (s *eventStore[E]) has(key indexer.Key) bool {
+	if _, ok := s.released[key]; ok {
+		return true
+	}
+	return slices.ContainsFunc(s.events[key.Signature], func(e E) bool { return e.meta().key == key })
+}
+
+func (s *eventStore[E]) add(e E) {
+	sig := e.meta().key.Signature
+	s.events[sig] = append(s.events[sig], e)
+}
+
+// release lets go of e, e.g once it's folded into the aggregates of the store. It can
+// neither be finalized nor dropped anymore.
+func (s *eventStore[E]) release(e E) {
+	key := e.meta().key
+	events := slices.DeleteFunc(s.events[key.Signature], func(other E) bool { return other.meta().key == key })
+	if len(events) == 0 {
+		delete(s.events, key.Signature)
+	} else {
+		s.events[key.Signature] = events
+	}
+	s.released[key] = struct{}{}
+}
+
+func (s *eventStore[E]) Pending(_ context.Context, slot uint64) (map[solana.Signature]uint64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	res := make(map[solana.Signature]uint64)
+	for sig, events := range s.events {
+		if m := events[0].meta(); !m.final && m.slot <= slot {
+			res[sig] = m.slot
+		}
+	}
+	return res, nil
+}
+
+func (s *eventStore[E]) Finalize(_ context.Context, slots map[solana.Signature]uint64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for sig, slot := range slots {
+		// onFinalize may release the events.
+		for _, e := range slices.Clone(s.events[sig]) {
+			m := e.meta()
+			m.final, m.slot = true, slot
+			if s.onFinalize != nil {
+				s.onFinalize(e)
+			}
+		}
+	}
+	return nil
+}
+
+func (s *eventStore[E]) Drop(_ context.Context, signatures ...solana.Signature) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, sig := range signatures {
+		if s.onDrop != nil {
+			for _, e := range s.events[sig] {
+				s.onDrop(e)
+			}
+		}
+		delete(s.events, sig)
+	}
+	return nil
+}
+
+func (s *eventStore[E]) Close() error { return nil }
+
+// addUpdate puts the event of update into store, unless update carries no event or, when
+// name is set, another one.
+func addUpdate(store indexer.Store, update dammv2gosdk.EventUpdate, finalized bool, name string) error {
+	if update.Event == nil || (name != "" && update.Event.Name != name) {
+		return nil
+	}
+	rec, err := indexer.NewRecord(update, finalized)
+	if err != nil {
+		return err
+	}
+	return store.Put(context.Background(), rec)
+}
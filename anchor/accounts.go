@@ -3,16 +3,12 @@ package anchor
 import (
 	"bytes"
 	"context"
-	"errors"
 	"fmt"
-	"runtime"
 	"slices"
 
-	"github.com/btcsuite/btcutil/base58"
 	ag_binary "github.com/gagliardetto/binary"
 	"github.com/gagliardetto/solana-go"
 	"github.com/gagliardetto/solana-go/rpc"
-	"golang.org/x/sync/errgroup"
 )
 
 type PgAccountI interface {
@@ -21,8 +17,10 @@ type PgAccountI interface {
 }
 
 type PgAccounts[T PgAccountI] struct {
-	conn    *rpc.Client
-	account func() T
+	conn          *rpc.Client
+	account       func() T
+	cache         *AccountCache
+	discriminator []byte
 }
 
 func NewPgAccounts[T PgAccountI](conn *rpc.Client, account func() T) *PgAccounts[T] {
@@ -32,6 +30,20 @@ func NewPgAccounts[T PgAccountI](conn *rpc.Client, account func() T) *PgAccounts
 	}
 }
 
+// WithCache makes Fetch and FetchWithRpcCtx read through cache.
+// A nil cache disables caching.
+func (ac *PgAccounts[T]) WithCache(cache *AccountCache) *PgAccounts[T] {
+	ac.cache = cache
+	return ac
+}
+
+// WithDiscriminator makes FetchMultipleResults check accounts start with discriminator
+// before decoding them, and report the others with ErrDiscriminatorMismatch.
+func (ac *PgAccounts[T]) WithDiscriminator(discriminator [8]byte) *PgAccounts[T] {
+	ac.discriminator = discriminator[:]
+	return ac
+}
+
 func (ac *PgAccounts[T]) fetchNullable(ctx context.Context, address solana.PublicKey, opts *rpc.GetAccountInfoOpts) (T, error) {
 	account, _, err := ac.fetchNullableAndContext(ctx, address, opts)
 
@@ -40,8 +52,20 @@ func (ac *PgAccounts[T]) fetchNullable(ctx context.Context, address solana.Publi
 }
 
 func (ac *PgAccounts[T]) fetchNullableAndContext(ctx context.Context, address solana.PublicKey, opts *rpc.GetAccountInfoOpts) (T, *rpc.RPCContext, error) {
-	accoutnInfo, rpcCtx, err := ac.conn.GetAccountInfoWithRpcContext(ctx, address, opts)
 	var zeroValue T
+	if ac.cache != nil {
+		var minContextSlot uint64
+		if opts != nil && opts.MinContextSlot != nil {
+			minContextSlot = *opts.MinContextSlot
+		}
+		if cached, slot, ok := ac.cache.Get(address, minContextSlot); ok {
+			if concrete, ok := cached.(T); ok {
+				return concrete, &rpc.RPCContext{Context: rpc.Context{Slot: slot}}, nil
+			}
+		}
+	}
+
+	accoutnInfo, rpcCtx, err := ac.conn.GetAccountInfoWithRpcContext(ctx, address, opts)
 	if err != nil {
 		return zeroValue, nil, err
 	}
@@ -53,6 +77,10 @@ func (ac *PgAccounts[T]) fetchNullableAndContext(ctx context.Context, address so
 		return zeroValue, nil, err
 	}
 
+	if ac.cache != nil && rpcCtx != nil {
+		ac.cache.Set(address, concrate, rpcCtx.Context.Slot)
+	}
+
 	return concrate, rpcCtx, nil
 
 }
@@ -64,52 +92,22 @@ func (ac *PgAccounts[T]) FetchWithRpcCtx(ctx context.Context, address solana.Pub
 	return ac.fetchNullableAndContext(ctx, address, opts)
 }
 
+// FetchMultiple fetches addresses, index-aligned with them. Missing accounts are left as a
+// zero account and undecodable ones as nil, use FetchMultipleResults to tell them apart.
 func (ac *PgAccounts[T]) FetchMultiple(ctx context.Context, addresses []solana.PublicKey, opts *rpc.GetMultipleAccountsOpts) ([]T, error) {
-	var (
-		batchSize = 99
-		res       = make([]T, len(addresses))
-		g, newCtx = errgroup.WithContext(ctx)
-	)
-
-	for i := 0; i < len(addresses); i += batchSize {
-		batchStart := i // for Go version below 1.22
-		end := min(batchStart+batchSize, len(addresses))
-		batchKeys := addresses[batchStart:end]
-
-		g.SetLimit(runtime.NumCPU())
-
-		g.Go(func() error {
-			out, err := ac.conn.GetMultipleAccountsWithOpts(newCtx, batchKeys, opts)
-			if err != nil {
-				return err
-			}
-
-			if out == nil || len(out.Value) == 0 {
-				return errors.New("empty result from GetMultipleAccounts")
-			}
-
-			if len(out.Value) > len(batchKeys) {
-				return errors.New("GetMultipleAccounts returned more result than expected")
-			}
-
-			for j, value := range out.Value {
-
-				concrete := ac.account()
-				if value != nil && value.Data != nil && len(value.Data.GetBinary()) != 0 {
-					if err := concrete.UnmarshalWithDecoder(ag_binary.NewBorshDecoder(value.Data.GetBinary())); err != nil {
-						continue
-					}
-				}
-
-				res[batchStart+j] = concrete
-			}
-
-			return nil
-		})
+	results, err := ac.FetchMultipleResults(ctx, addresses, opts, FetchModeLenient)
+	if err != nil {
+		return nil, err
 	}
 
-	if err := g.Wait(); err != nil {
-		return nil, err
+	res := make([]T, len(results))
+	for i, result := range results {
+		switch {
+		case !result.Exists:
+			res[i] = ac.account()
+		case result.Err == nil:
+			res[i] = result.Value
+		}
 	}
 
 	return res, nil
@@ -134,9 +132,7 @@ func (ac *PgAccounts[T]) All(
 			{
 				Memcmp: &rpc.RPCFilterMemcmp{
 					Offset: 0,
-					// Bytes:  base58.Decode(apppendData.String()),
-					// Bytes: apppendData.Bytes(),
-					Bytes: solana.Base58(base58.Encode(apppendData.Bytes())),
+					Bytes:  apppendData.Bytes(),
 				},
 			},
 		},
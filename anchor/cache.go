@@ -0,0 +1,143 @@
+package anchor
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/gagliardetto/solana-go/rpc/ws"
+)
+
+// AccountCache is a slot-aware store of decoded accounts keyed by pubkey.
+//
+// Every entry remembers the RPCContext slot it was read at, so callers can ask
+// for a value that is at least as fresh as a given slot (the same semantics as
+// the minContextSlot rpc option). Entries also expire after the configured TTL.
+// It is safe for concurrent use by multiple goroutines.
+//
+// Cached values are shared between callers and must be treated as read-only.
+type AccountCache struct {
+	mu      sync.RWMutex
+	entries map[solana.PublicKey]cacheEntry
+	ttl     time.Duration
+	now     func() time.Time
+}
+
+type cacheEntry struct {
+	value    any
+	slot     uint64
+	storedAt time.Time
+}
+
+// NewAccountCache creates an empty cache. A zero ttl disables time-based expiry,
+// entries are then only dropped through invalidation.
+func NewAccountCache(ttl time.Duration) *AccountCache {
+	return &AccountCache{
+		entries: make(map[solana.PublicKey]cacheEntry),
+		ttl:     ttl,
+		now:     time.Now,
+	}
+}
+
+// TTL returns the time entries are kept for, zero when they don't expire.
+func (c *AccountCache) TTL() time.Duration {
+	return c.ttl
+}
+
+// Get returns the value stored for key along with the slot it was read at.
+// The lookup misses when the entry is expired or older than minContextSlot.
+func (c *AccountCache) Get(key solana.PublicKey, minContextSlot uint64) (any, uint64, bool) {
+	c.mu.RLock()
+	entry, ok := c.entries[key]
+	c.mu.RUnlock()
+
+	if !ok || entry.slot < minContextSlot {
+		return nil, 0, false
+	}
+
+	if c.ttl > 0 && c.now().Sub(entry.storedAt) > c.ttl {
+		c.mu.Lock()
+		// re-check under the write lock, a fresher value may have landed meanwhile.
+		if current, ok := c.entries[key]; ok && current.storedAt.Equal(entry.storedAt) {
+			delete(c.entries, key)
+		}
+		c.mu.Unlock()
+		return nil, 0, false
+	}
+
+	return entry.value, entry.slot, true
+}
+
+// Set stores value for key as read at slot.
+// A value read at an older slot never replaces a newer one.
+func (c *AccountCache) Set(key solana.PublicKey, value any, slot uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if current, ok := c.entries[key]; ok && current.slot > slot {
+		return
+	}
+
+	c.entries[key] = cacheEntry{
+		value:    value,
+		slot:     slot,
+		storedAt: c.now(),
+	}
+}
+
+// Invalidate drops the entry stored for key.
+func (c *AccountCache) Invalidate(key solana.PublicKey) {
+	c.mu.Lock()
+	delete(c.entries, key)
+	c.mu.Unlock()
+}
+
+// InvalidateBefore drops the entry stored for key if it was read before slot,
+// i.e the account is known to have changed at slot.
+func (c *AccountCache) InvalidateBefore(key solana.PublicKey, slot uint64) {
+	c.mu.Lock()
+	if entry, ok := c.entries[key]; ok && entry.slot < slot {
+		delete(c.entries, key)
+	}
+	c.mu.Unlock()
+}
+
+// Clear drops every entry.
+func (c *AccountCache) Clear() {
+	c.mu.Lock()
+	clear(c.entries)
+	c.mu.Unlock()
+}
+
+// Len returns the number of entries currently held, expired ones included.
+func (c *AccountCache) Len() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return len(c.entries)
+}
+
+// Watch subscribes to account updates for key and invalidates the cached entry
+// every time the account changes on-chain. It blocks until ctx is done or the
+// subscription fails.
+func (c *AccountCache) Watch(
+	ctx context.Context,
+	wsClient *ws.Client,
+	key solana.PublicKey,
+	commitment rpc.CommitmentType,
+) error {
+	sub, err := wsClient.AccountSubscribe(key, commitment)
+	if err != nil {
+		return err
+	}
+	defer sub.Unsubscribe()
+
+	for {
+		update, err := sub.Recv(ctx)
+		if err != nil {
+			return err
+		}
+		c.InvalidateBefore(key, update.Context.Slot)
+	}
+}
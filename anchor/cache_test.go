@@ -0,0 +1,223 @@
+package anchor
+
+import (
+	"context"
+	"dammv2GoSDK/internal/test/rpctest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/gagliardetto/solana-go/rpc/ws"
+)
+
+func TestAccountCache(t *testing.T) {
+	key := solana.NewWallet().PublicKey()
+	for _, tt := range []struct {
+		name           string
+		ttl            time.Duration
+		setup          func(c *AccountCache, advance func(time.Duration))
+		minContextSlot uint64
+		want           any
+		wantSlot       uint64
+	}{
+		{
+			name:  "hit",
+			setup: func(c *AccountCache, _ func(time.Duration)) { c.Set(key, "a", 10) },
+			want:  "a", wantSlot: 10,
+		},
+		{
+			name:  "miss",
+			setup: func(*AccountCache, func(time.Duration)) {},
+		},
+		{
+			name: "within ttl",
+			ttl:  time.Minute,
+			setup: func(c *AccountCache, advance func(time.Duration)) {
+				c.Set(key, "a", 10)
+				advance(time.Minute)
+			},
+			want: "a", wantSlot: 10,
+		},
+		{
+			name: "expired",
+			ttl:  time.Minute,
+			setup: func(c *AccountCache, advance func(time.Duration)) {
+				c.Set(key, "a", 10)
+				advance(time.Minute + time.Second)
+			},
+		},
+		{
+			name: "no ttl",
+			setup: func(c *AccountCache, advance func(time.Duration)) {
+				c.Set(key, "a", 10)
+				advance(24 * time.Hour)
+			},
+			want: "a", wantSlot: 10,
+		},
+		{
+			name:           "at minContextSlot",
+			setup:          func(c *AccountCache, _ func(time.Duration)) { c.Set(key, "a", 10) },
+			minContextSlot: 10,
+			want:           "a", wantSlot: 10,
+		},
+		{
+			name:           "older than minContextSlot",
+			setup:          func(c *AccountCache, _ func(time.Duration)) { c.Set(key, "a", 10) },
+			minContextSlot: 11,
+		},
+		{
+			name: "newer set",
+			setup: func(c *AccountCache, _ func(time.Duration)) {
+				c.Set(key, "a", 10)
+				c.Set(key, "b", 11)
+			},
+			want: "b", wantSlot: 11,
+		},
+		{
+			name: "stale set",
+			setup: func(c *AccountCache, _ func(time.Duration)) {
+				c.Set(key, "a", 10)
+				c.Set(key, "b", 9)
+			},
+			want: "a", wantSlot: 10,
+		},
+		{
+			name: "set at the same slot",
+			setup: func(c *AccountCache, _ func(time.Duration)) {
+				c.Set(key, "a", 10)
+				c.Set(key, "b", 10)
+			},
+			want: "b", wantSlot: 10,
+		},
+		{
+			name: "invalidated before a later slot",
+			setup: func(c *AccountCache, _ func(time.Duration)) {
+				c.Set(key, "a", 10)
+				c.InvalidateBefore(key, 11)
+			},
+		},
+		{
+			name: "invalidated before the same slot",
+			setup: func(c *AccountCache, _ func(time.Duration)) {
+				c.Set(key, "a", 10)
+				c.InvalidateBefore(key, 10)
+			},
+			want: "a", wantSlot: 10,
+		},
+		{
+			name: "invalidated",
+			setup: func(c *AccountCache, _ func(time.Duration)) {
+				c.Set(key, "a", 10)
+				c.Invalidate(key)
+			},
+		},
+		{
+			name: "cleared",
+			setup: func(c *AccountCache, _ func(time.Duration)) {
+				c.Set(key, "a", 10)
+				c.Clear()
+			},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewAccountCache(tt.ttl)
+			now := time.Unix(1_000, 0)
+			c.now = func() time.Time { return now }
+			tt.setup(c, func(d time.Duration) { now = now.Add(d) })
+
+			value, slot, ok := c.Get(key, tt.minContextSlot)
+			if ok != (tt.want != nil) || value != tt.want || slot != tt.wantSlot {
+				t.Fatalf("Get = %v, %d, %v, want %v, %d", value, slot, ok, tt.want, tt.wantSlot)
+			}
+		})
+	}
+}
+
+func TestAccountCacheExpiredEntryDropped(t *testing.T) {
+	key := solana.NewWallet().PublicKey()
+	c := NewAccountCache(time.Minute)
+	now := time.Unix(1_000, 0)
+	c.now = func() time.Time { return now }
+
+	c.Set(key, "a", 10)
+	now = now.Add(2 * time.Minute)
+	if _, _, ok := c.Get(key, 0); ok || c.Len() != 0 {
+		t.Fatalf("expired entry kept, len = %d", c.Len())
+	}
+}
+
+func TestAccountCacheConcurrent(t *testing.T) {
+	keys := make([]solana.PublicKey, 4)
+	for i := range keys {
+		keys[i] = solana.NewWallet().PublicKey()
+	}
+	c := NewAccountCache(time.Millisecond)
+
+	var wg sync.WaitGroup
+	for writer := range 8 {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for slot := range uint64(500) {
+				key := keys[int(slot)%len(keys)]
+				c.Set(key, slot, slot)
+				if slot%50 == 0 {
+					c.InvalidateBefore(key, slot)
+				}
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for i := range 500 {
+				key := keys[(i+writer)%len(keys)]
+				if value, slot, ok := c.Get(key, uint64(i)); ok && (value != slot || slot < uint64(i)) {
+					t.Errorf("Get = %v at slot %d", value, slot)
+					return
+				}
+				c.Len()
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestAccountCacheWatch(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	key := solana.NewWallet().PublicKey()
+	c := NewAccountCache(0)
+	c.Set(key, "a", 10)
+
+	server := rpctest.NewWSServer(t)
+	client, err := ws.Connect(ctx, server.URL())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	done := make(chan error, 1)
+	go func() { done <- c.Watch(ctx, client, key, rpc.CommitmentConfirmed) }()
+
+	conn := server.Accept(t)
+	method, _, sub := conn.Subscribe(t)
+	if method != "accountSubscribe" {
+		t.Fatalf("method = %s", method)
+	}
+	// the account changed after the entry was read.
+	conn.Notify(t, "accountNotification", sub, 11, rpctest.Account(solana.SystemProgramID, nil))
+	deadline := time.Now().Add(5 * time.Second)
+	for c.Len() != 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("entry not invalidated")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	cancel()
+	if err := <-done; err == nil {
+		t.Fatal("Watch returned without error once ctx is done")
+	}
+}
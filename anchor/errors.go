@@ -0,0 +1,341 @@
+package anchor
+
+import (
+	cp_amm "dammv2GoSDK/generated/cpAmm"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc/jsonrpc"
+)
+
+// TransactionError is a failed transaction or simulation, decoded out of the err field
+// the node reports along with the logs of the transaction. It unwraps to its Err, so
+// errors.Is(err, cp_amm.ErrExceededSlippage) or errors.Is(err, ErrTokenInsufficientFunds)
+// work on any error wrapping it.
+type TransactionError struct {
+	// Instruction is the index of the failing instruction, -1 when the transaction
+	// failed as a whole, e.g on ErrBlockhashNotFound.
+	Instruction int
+	// Program that failed, the one the instruction invokes or one it invokes in turn.
+	// Nil when the transaction failed as a whole or the program cannot be told.
+	Program *solana.PublicKey
+	// Err is a cp_amm error value for the pool program, a *ProgramError for the token and
+	// system programs, a RuntimeError otherwise.
+	Err error
+	// Logs of the failing instruction, every log line when they cannot be told apart.
+	Logs []string
+	// Retryable reports whether sending the same instructions again in a new transaction,
+	// with a fresh blockhash or quote, may succeed.
+	Retryable bool
+	// Raw is the err field as reported by the node.
+	Raw any
+}
+
+func (e *TransactionError) Error() string {
+	if e.Instruction < 0 {
+		return fmt.Sprintf("transaction failed: %v", e.Err)
+	}
+	if e.Program == nil {
+		return fmt.Sprintf("instruction %d failed: %v", e.Instruction, e.Err)
+	}
+	return fmt.Sprintf("instruction %d failed in program %s: %v", e.Instruction, e.Program, e.Err)
+}
+
+func (e *TransactionError) Unwrap() error {
+	return e.Err
+}
+
+// RuntimeError is an error of the runtime, named as the node reports it, e.g
+// "BlockhashNotFound" or "InvalidAccountData".
+type RuntimeError string
+
+func (e RuntimeError) Error() string {
+	return string(e)
+}
+
+const (
+	ErrBlockhashNotFound           = RuntimeError("BlockhashNotFound")
+	ErrAlreadyProcessed            = RuntimeError("AlreadyProcessed")
+	ErrInsufficientFundsForFee     = RuntimeError("InsufficientFundsForFee")
+	ErrAccountInUse                = RuntimeError("AccountInUse")
+	ErrComputationalBudgetExceeded = RuntimeError("ComputationalBudgetExceeded")
+	ErrMissingRequiredSignature    = RuntimeError("MissingRequiredSignature")
+)
+
+// retryableRuntimeErrors are the runtime errors a new transaction may not run into.
+var retryableRuntimeErrors = map[RuntimeError]bool{
+	ErrBlockhashNotFound: true,
+	ErrAccountInUse:      true,
+	RuntimeError("WouldExceedMaxBlockCostLimit"):     true,
+	RuntimeError("WouldExceedMaxAccountCostLimit"):   true,
+	RuntimeError("WouldExceedMaxVoteCostLimit"):      true,
+	RuntimeError("WouldExceedAccountDataBlockLimit"): true,
+	RuntimeError("ClusterMaintenance"):               true,
+}
+
+// ProgramError is a custom error of a program other than the pool program.
+type ProgramError struct {
+	// Program is the name of the program, e.g "token", or its address when unknown.
+	Program string
+	Code    uint32
+	// Name of the error, empty when unknown.
+	Name string
+}
+
+func (e *ProgramError) Error() string {
+	if e.Name == "" {
+		return fmt.Sprintf("%s program error %d", e.Program, e.Code)
+	}
+	return fmt.Sprintf("%s program error %d: %s", e.Program, e.Code, e.Name)
+}
+
+// Errors of the token programs, token-2022 shares the codes of the original program.
+var (
+	ErrTokenNotRentExempt     = &ProgramError{Program: "token", Code: 0, Name: "NotRentExempt"}
+	ErrTokenInsufficientFunds = &ProgramError{Program: "token", Code: 1, Name: "InsufficientFunds"}
+	ErrTokenInvalidMint       = &ProgramError{Program: "token", Code: 2, Name: "InvalidMint"}
+	ErrTokenMintMismatch      = &ProgramError{Program: "token", Code: 3, Name: "MintMismatch"}
+	ErrTokenOwnerMismatch     = &ProgramError{Program: "token", Code: 4, Name: "OwnerMismatch"}
+	ErrTokenAlreadyInUse      = &ProgramError{Program: "token", Code: 6, Name: "AlreadyInUse"}
+	ErrTokenUninitialized     = &ProgramError{Program: "token", Code: 9, Name: "UninitializedState"}
+	ErrTokenAccountFrozen     = &ProgramError{Program: "token", Code: 17, Name: "AccountFrozen"}
+
+	tokenErrors = []*ProgramError{
+		ErrTokenNotRentExempt,
+		ErrTokenInsufficientFunds,
+		ErrTokenInvalidMint,
+		ErrTokenMintMismatch,
+		ErrTokenOwnerMismatch,
+		{Program: "token", Code: 5, Name: "FixedSupply"},
+		ErrTokenAlreadyInUse,
+		{Program: "token", Code: 7, Name: "InvalidNumberOfProvidedSigners"},
+		{Program: "token", Code: 8, Name: "InvalidNumberOfRequiredSigners"},
+		ErrTokenUninitialized,
+		{Program: "token", Code: 10, Name: "NativeNotSupported"},
+		{Program: "token", Code: 11, Name: "NonNativeHasBalance"},
+		{Program: "token", Code: 12, Name: "InvalidInstruction"},
+		{Program: "token", Code: 13, Name: "InvalidState"},
+		{Program: "token", Code: 14, Name: "Overflow"},
+		{Program: "token", Code: 15, Name: "AuthorityTypeNotSupported"},
+		{Program: "token", Code: 16, Name: "MintCannotFreeze"},
+		ErrTokenAccountFrozen,
+		{Program: "token", Code: 18, Name: "MintDecimalsMismatch"},
+		{Program: "token", Code: 19, Name: "NonNativeNotSupported"},
+	}
+)
+
+// Errors of the system program.
+var (
+	ErrSystemAccountAlreadyInUse   = &ProgramError{Program: "system", Code: 0, Name: "AccountAlreadyInUse"}
+	ErrSystemInsufficientLamports  = &ProgramError{Program: "system", Code: 1, Name: "ResultWithNegativeLamports"}
+	ErrSystemNonceBlockhashExpired = &ProgramError{Program: "system", Code: 8, Name: "NonceUnexpectedBlockhashValue"}
+
+	systemErrors = []*ProgramError{
+		ErrSystemAccountAlreadyInUse,
+		ErrSystemInsufficientLamports,
+		{Program: "system", Code: 2, Name: "InvalidProgramId"},
+		{Program: "system", Code: 3, Name: "InvalidAccountDataLength"},
+		{Program: "system", Code: 4, Name: "MaxSeedLengthExceeded"},
+		{Program: "system", Code: 5, Name: "AddressWithSeedMismatch"},
+		{Program: "system", Code: 6, Name: "NonceNoRecentBlockhashes"},
+		{Program: "system", Code: 7, Name: "NonceBlockhashNotExpired"},
+		ErrSystemNonceBlockhashExpired,
+	}
+)
+
+// NewTransactionError decodes txErr, the err field of a transaction status or simulation.
+// Custom errors of programID are mapped to the cp_amm error values. message, the message
+// of the failed transaction, and logs are optional, they tell the failing program apart.
+func NewTransactionError(
+	txErr any,
+	programID solana.PublicKey,
+	message *solana.Message,
+	logs []string,
+) *TransactionError {
+	res := &TransactionError{
+		Instruction: -1,
+		Logs:        logs,
+		Raw:         txErr,
+	}
+
+	index, detail, ok := instructionError(txErr)
+	if !ok {
+		runtimeErr := runtimeError(txErr)
+		res.Err, res.Retryable = runtimeErr, retryableRuntimeErrors[runtimeErr]
+		return res
+	}
+
+	res.Instruction = index
+	res.Logs = instructionLogs(logs, index)
+	res.Program = failedProgram(res.Logs)
+	if res.Program == nil && message != nil && index < len(message.Instructions) {
+		if program, err := message.Program(message.Instructions[index].ProgramIDIndex); err == nil {
+			res.Program = &program
+		}
+	}
+
+	code, ok := customCode(detail)
+	if !ok {
+		runtimeErr := runtimeError(detail)
+		res.Err, res.Retryable = runtimeErr, retryableRuntimeErrors[runtimeErr]
+		return res
+	}
+
+	res.Err = programError(res.Program, programID, code)
+	res.Retryable = errors.Is(res.Err, cp_amm.ErrExceededSlippage)
+	return res
+}
+
+// DecodeTransactionError decodes txErr with no message nor logs at hand,
+// see NewTransactionError.
+func DecodeTransactionError(txErr any, programID solana.PublicKey) error {
+	return NewTransactionError(txErr, programID, nil, nil)
+}
+
+// DecodeRPCError turns the error of a sendTransaction failing preflight into a
+// *TransactionError, with the logs of the preflight simulation. Other errors are
+// returned as they are.
+func DecodeRPCError(err error, programID solana.PublicKey, message *solana.Message) error {
+	var rpcErr *jsonrpc.RPCError
+	if !errors.As(err, &rpcErr) {
+		return err
+	}
+	data, ok := rpcErr.Data.(map[string]any)
+	if !ok || data["err"] == nil {
+		return err
+	}
+
+	var logs []string
+	if rawLogs, ok := data["logs"].([]any); ok {
+		for _, line := range rawLogs {
+			if s, ok := line.(string); ok {
+				logs = append(logs, s)
+			}
+		}
+	}
+	return NewTransactionError(data["err"], programID, message, logs)
+}
+
+// instructionError unpacks {"InstructionError": [index, detail]}.
+func instructionError(txErr any) (int, any, bool) {
+	root, ok := txErr.(map[string]any)
+	if !ok {
+		return 0, nil, false
+	}
+	items, ok := root["InstructionError"].([]any)
+	if !ok || len(items) != 2 {
+		return 0, nil, false
+	}
+	index, ok := jsonInt(items[0])
+	if !ok {
+		return 0, nil, false
+	}
+	return int(index), items[1], true
+}
+
+// customCode unpacks {"Custom": code}.
+func customCode(detail any) (uint32, bool) {
+	root, ok := detail.(map[string]any)
+	if !ok {
+		return 0, false
+	}
+	code, ok := jsonInt(root["Custom"])
+	return uint32(code), ok
+}
+
+func jsonInt(v any) (int64, bool) {
+	switch n := v.(type) {
+	case json.Number:
+		i, err := n.Int64()
+		return i, err == nil
+	case float64:
+		return int64(n), true
+	case int:
+		return int64(n), true
+	case int64:
+		return n, true
+	}
+	return 0, false
+}
+
+// runtimeError names errors such as "BlockhashNotFound" or {"InsufficientFundsForRent": {...}}.
+func runtimeError(v any) RuntimeError {
+	switch e := v.(type) {
+	case string:
+		return RuntimeError(e)
+	case map[string]any:
+		for name := range e {
+			return RuntimeError(name)
+		}
+	}
+	raw, _ := json.Marshal(v)
+	return RuntimeError(raw)
+}
+
+func programError(program *solana.PublicKey, programID solana.PublicKey, code uint32) error {
+	if program == nil {
+		// nothing but the err field to go by, assumed to be the pool program.
+		program = &programID
+	}
+
+	switch {
+	case program.Equals(programID):
+		if customErr, ok := cp_amm.Errors[int(code)]; ok {
+			return customErr
+		}
+	case program.Equals(solana.TokenProgramID) || program.Equals(solana.Token2022ProgramID):
+		if int(code) < len(tokenErrors) {
+			return tokenErrors[code]
+		}
+		return &ProgramError{Program: "token", Code: code}
+	case program.Equals(solana.SystemProgramID):
+		if int(code) < len(systemErrors) {
+			return systemErrors[code]
+		}
+		return &ProgramError{Program: "system", Code: code}
+	}
+
+	return &ProgramError{Program: program.String(), Code: code}
+}
+
+// instructionLogs returns the log lines of the top-level instruction index, or all of
+// logs when they do not hold that many instructions, e.g when truncated.
+func instructionLogs(logs []string, index int) []string {
+	start, n := -1, -1
+	for i, line := range logs {
+		if !strings.HasPrefix(line, "Program ") || !strings.HasSuffix(line, " invoke [1]") {
+			continue
+		}
+		n++
+		if n == index {
+			start = i
+		} else if n == index+1 {
+			return logs[start:i]
+		}
+	}
+	if start < 0 {
+		return logs
+	}
+	return logs[start:]
+}
+
+// failedProgram returns the innermost program that failed, the first to log its failure.
+func failedProgram(logs []string) *solana.PublicKey {
+	for _, line := range logs {
+		rest, ok := strings.CutPrefix(line, "Program ")
+		if !ok {
+			continue
+		}
+		program, _, ok := strings.Cut(rest, " failed: ")
+		if !ok {
+			continue
+		}
+		if key, err := solana.PublicKeyFromBase58(program); err == nil {
+			return &key
+		}
+	}
+	return nil
+}
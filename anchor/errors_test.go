@@ -0,0 +1,138 @@
+package anchor
+
+import (
+	cp_amm "dammv2GoSDK/generated/cpAmm"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc/jsonrpc"
+)
+
+func TestNewTransactionError(t *testing.T) {
+	var (
+		programID = cp_amm.ProgramID
+		tokenID   = solana.TokenProgramID
+		computeID = solana.MustPublicKeyFromBase58("ComputeBudget111111111111111111111111111111")
+	)
+	logs := []string{
+		"Program " + computeID.String() + " invoke [1]",
+		"Program " + computeID.String() + " success",
+		"Program " + programID.String() + " invoke [1]",
+		"Program log: Instruction: Swap",
+		"Program " + tokenID.String() + " invoke [2]",
+		"Program log: Error: insufficient funds",
+		"Program " + tokenID.String() + " failed: custom program error: 0x1",
+		"Program " + programID.String() + " failed: custom program error: 0x1",
+	}
+	instructionErr := func(index int, detail any) any {
+		return map[string]any{"InstructionError": []any{json.Number(fmt.Sprint(index)), detail}}
+	}
+
+	tests := []struct {
+		name        string
+		txErr       any
+		logs        []string
+		want        error
+		instruction int
+		program     *solana.PublicKey
+		logLines    int
+		retryable   bool
+	}{
+		{
+			name:        "token error in a cpi",
+			txErr:       instructionErr(1, map[string]any{"Custom": json.Number("1")}),
+			logs:        logs,
+			want:        ErrTokenInsufficientFunds,
+			instruction: 1,
+			program:     &tokenID,
+			logLines:    6,
+		},
+		{
+			name:        "pool program error",
+			txErr:       instructionErr(0, map[string]any{"Custom": float64(6002)}),
+			want:        cp_amm.ErrExceededSlippage,
+			instruction: 0,
+			retryable:   true,
+		},
+		{
+			name:        "runtime instruction error",
+			txErr:       instructionErr(1, "MissingRequiredSignature"),
+			logs:        logs,
+			want:        ErrMissingRequiredSignature,
+			instruction: 1,
+			program:     &tokenID,
+			logLines:    6,
+		},
+		{
+			name:        "transaction error",
+			txErr:       "BlockhashNotFound",
+			want:        ErrBlockhashNotFound,
+			instruction: -1,
+			retryable:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := NewTransactionError(tt.txErr, programID, nil, tt.logs)
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("err = %v, want %v", err, tt.want)
+			}
+			if err.Instruction != tt.instruction || (err.Program == nil) != (tt.program == nil) ||
+				(tt.program != nil && !err.Program.Equals(*tt.program)) {
+				t.Fatalf("instruction = %d program = %s", err.Instruction, err.Program)
+			}
+			if len(err.Logs) != tt.logLines || err.Retryable != tt.retryable {
+				t.Fatalf("logs = %d retryable = %v", len(err.Logs), err.Retryable)
+			}
+		})
+	}
+}
+
+func TestNewTransactionErrorProgramFromMessage(t *testing.T) {
+	payer := solana.NewWallet().PublicKey()
+	tx, err := solana.NewTransaction([]solana.Instruction{
+		solana.NewInstruction(solana.SystemProgramID, solana.AccountMetaSlice{solana.Meta(payer).WRITE().SIGNER()}, []byte{2}),
+	}, solana.Hash{}, solana.TransactionPayer(payer))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	res := NewTransactionError(
+		map[string]any{"InstructionError": []any{float64(0), map[string]any{"Custom": float64(1)}}},
+		cp_amm.ProgramID,
+		&tx.Message,
+		nil,
+	)
+	if res.Program == nil || !res.Program.Equals(solana.SystemProgramID) || !errors.Is(res, ErrSystemInsufficientLamports) {
+		t.Fatalf("err = %v", res)
+	}
+}
+
+func TestDecodeRPCError(t *testing.T) {
+	preflight := fmt.Errorf("wrapped: %w", &jsonrpc.RPCError{
+		Code:    -32002,
+		Message: "Transaction simulation failed",
+		Data: map[string]any{
+			"err":  map[string]any{"InstructionError": []any{json.Number("0"), map[string]any{"Custom": json.Number("6003")}}},
+			"logs": []any{"Program " + cp_amm.ProgramID.String() + " invoke [1]"},
+		},
+	})
+
+	err := DecodeRPCError(preflight, cp_amm.ProgramID, nil)
+	var txErr *TransactionError
+	if !errors.As(err, &txErr) || !errors.Is(err, cp_amm.ErrPoolDisabled) {
+		t.Fatalf("err = %v", err)
+	}
+	if len(txErr.Logs) != 1 {
+		t.Fatalf("logs = %v", txErr.Logs)
+	}
+
+	transport := errors.New("connection refused")
+	if DecodeRPCError(transport, cp_amm.ProgramID, nil) != transport {
+		t.Fatal("transport errors are returned as they are")
+	}
+}
@@ -0,0 +1,213 @@
+package anchor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/gagliardetto/solana-go/rpc/jsonrpc"
+)
+
+// AccountLoader is a dataloader-style rpc.JSONRPCClient: getAccountInfo calls issued
+// within the same short window are gathered into one getMultipleAccounts call, identical
+// keys are fetched once and every caller gets the answer it would have got on its own.
+// Every other method is passed through to the wrapped client.
+//
+// Since it sits below rpc.Client, every account read going through the client is
+// coalesced, PgAccounts fetches and helpers alike:
+//
+//	conn := anchor.NewLoaderClient(rpc.MainNetBeta_RPC, 2*time.Millisecond)
+//	cpAmm := dammv2gosdk.NewCpAMM(conn)
+//
+// It is safe for concurrent use by multiple goroutines.
+type AccountLoader struct {
+	inner    rpc.JSONRPCClient
+	wait     time.Duration
+	maxBatch int
+
+	mu      sync.Mutex
+	batches map[string]*loaderBatch
+}
+
+// loaderBatch is the set of keys sharing the same getAccountInfo config.
+type loaderBatch struct {
+	config     any
+	keys       []solana.PublicKey
+	index      map[solana.PublicKey]int
+	timer      *time.Timer
+	dispatched bool
+
+	done    chan struct{}
+	context json.RawMessage
+	values  []json.RawMessage
+	err     error
+}
+
+// NewAccountLoader wraps inner, holding getAccountInfo calls for at most wait before
+// sending them. Batches are sent early once they hit the getMultipleAccounts limit of 100 keys.
+func NewAccountLoader(inner rpc.JSONRPCClient, wait time.Duration) *AccountLoader {
+	return &AccountLoader{
+		inner:    inner,
+		wait:     wait,
+		maxBatch: 100,
+		batches:  make(map[string]*loaderBatch),
+	}
+}
+
+// NewLoaderClient returns an rpc.Client for rpcEndpoint whose account reads go through an AccountLoader.
+func NewLoaderClient(rpcEndpoint string, wait time.Duration) *rpc.Client {
+	return rpc.NewWithCustomRPCClient(NewAccountLoader(jsonrpc.NewClient(rpcEndpoint), wait))
+}
+
+func (l *AccountLoader) CallForInto(ctx context.Context, out any, method string, params []any) error {
+	if method != "getAccountInfo" || len(params) == 0 || len(params) > 2 {
+		return l.inner.CallForInto(ctx, out, method, params)
+	}
+	key, ok := params[0].(solana.PublicKey)
+	if !ok {
+		return l.inner.CallForInto(ctx, out, method, params)
+	}
+
+	var config any
+	if len(params) == 2 {
+		config = params[1]
+	}
+
+	batch, idx, err := l.enqueue(key, config)
+	if err != nil {
+		return err
+	}
+
+	select {
+	case <-batch.done:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	if batch.err != nil {
+		return batch.err
+	}
+
+	// re-shape the getMultipleAccounts entry into a getAccountInfo result.
+	raw, err := json.Marshal(struct {
+		Context json.RawMessage `json:"context"`
+		Value   json.RawMessage `json:"value"`
+	}{
+		Context: batch.context,
+		Value:   batch.values[idx],
+	})
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(raw, out)
+}
+
+func (l *AccountLoader) CallWithCallback(
+	ctx context.Context,
+	method string,
+	params []any,
+	callback func(*http.Request, *http.Response) error,
+) error {
+	return l.inner.CallWithCallback(ctx, method, params, callback)
+}
+
+func (l *AccountLoader) CallBatch(ctx context.Context, requests jsonrpc.RPCRequests) (jsonrpc.RPCResponses, error) {
+	return l.inner.CallBatch(ctx, requests)
+}
+
+// Close closes the wrapped client when it can be closed.
+func (l *AccountLoader) Close() error {
+	if c, ok := l.inner.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+// enqueue adds key to the pending batch of its config and returns the batch and
+// the position the key's account will be found at.
+func (l *AccountLoader) enqueue(key solana.PublicKey, config any) (*loaderBatch, int, error) {
+	groupKey, err := json.Marshal(config)
+	if err != nil {
+		return nil, 0, fmt.Errorf("encoding getAccountInfo config: %w", err)
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	batch, ok := l.batches[string(groupKey)]
+	if !ok {
+		batch = &loaderBatch{
+			config: config,
+			index:  make(map[solana.PublicKey]int),
+			done:   make(chan struct{}),
+		}
+		l.batches[string(groupKey)] = batch
+		batch.timer = time.AfterFunc(l.wait, func() {
+			l.dispatch(string(groupKey), batch)
+		})
+	}
+
+	idx, ok := batch.index[key]
+	if !ok {
+		idx = len(batch.keys)
+		batch.index[key] = idx
+		batch.keys = append(batch.keys, key)
+	}
+
+	if len(batch.keys) >= l.maxBatch {
+		batch.timer.Stop()
+		l.dispatchLocked(string(groupKey), batch)
+	}
+
+	return batch, idx, nil
+}
+
+func (l *AccountLoader) dispatch(groupKey string, batch *loaderBatch) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.dispatchLocked(groupKey, batch)
+}
+
+// dispatchLocked closes batch to new keys and sends it, l.mu must be held.
+func (l *AccountLoader) dispatchLocked(groupKey string, batch *loaderBatch) {
+	if batch.dispatched {
+		return
+	}
+	batch.dispatched = true
+	if l.batches[groupKey] == batch {
+		delete(l.batches, groupKey)
+	}
+
+	go l.fetch(batch)
+}
+
+func (l *AccountLoader) fetch(batch *loaderBatch) {
+	defer close(batch.done)
+
+	params := []any{batch.keys}
+	if batch.config != nil {
+		params = append(params, batch.config)
+	}
+
+	var out struct {
+		Context json.RawMessage   `json:"context"`
+		Value   []json.RawMessage `json:"value"`
+	}
+	// the batch outlives any single caller, so it is not bound to a caller context.
+	if err := l.inner.CallForInto(context.Background(), &out, "getMultipleAccounts", params); err != nil {
+		batch.err = err
+		return
+	}
+	if len(out.Value) != len(batch.keys) {
+		batch.err = fmt.Errorf("getMultipleAccounts returned %d accounts for %d keys", len(out.Value), len(batch.keys))
+		return
+	}
+
+	batch.context = out.Context
+	batch.values = out.Value
+}
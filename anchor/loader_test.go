@@ -0,0 +1,111 @@
+package anchor
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/gagliardetto/solana-go/rpc/jsonrpc"
+)
+
+// fakeRPC answers getMultipleAccounts with one byte of data per key, the key's first byte.
+type fakeRPC struct {
+	calls   atomic.Int32
+	missing solana.PublicKey
+}
+
+func (f *fakeRPC) CallForInto(_ context.Context, out any, method string, params []any) error {
+	if method != "getMultipleAccounts" {
+		return errors.New("unexpected method " + method)
+	}
+	f.calls.Add(1)
+
+	keys := params[0].([]solana.PublicKey)
+	values := make([]any, len(keys))
+	for i, key := range keys {
+		if key.Equals(f.missing) {
+			continue
+		}
+		values[i] = map[string]any{
+			"data":       []string{base64.StdEncoding.EncodeToString(key[:1]), "base64"},
+			"owner":      solana.SystemProgramID.String(),
+			"lamports":   1,
+			"executable": false,
+			"rentEpoch":  0,
+		}
+	}
+
+	raw, err := json.Marshal(map[string]any{
+		"context": map[string]any{"slot": 42},
+		"value":   values,
+	})
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(raw, out)
+}
+
+func (f *fakeRPC) CallWithCallback(context.Context, string, []any, func(*http.Request, *http.Response) error) error {
+	return errors.New("not implemented")
+}
+
+func (f *fakeRPC) CallBatch(context.Context, jsonrpc.RPCRequests) (jsonrpc.RPCResponses, error) {
+	return nil, errors.New("not implemented")
+}
+
+func TestAccountLoaderCoalesces(t *testing.T) {
+	var (
+		fake = &fakeRPC{}
+		conn = rpc.NewWithCustomRPCClient(NewAccountLoader(fake, 20*time.Millisecond))
+		keys = []solana.PublicKey{
+			solana.NewWallet().PublicKey(),
+			solana.NewWallet().PublicKey(),
+			solana.NewWallet().PublicKey(),
+		}
+		wg sync.WaitGroup
+	)
+
+	for i := range 30 {
+		key := keys[i%len(keys)]
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			account, rpcCtx, err := conn.GetAccountInfoWithRpcContext(context.Background(), key, nil)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if got := account.Data.GetBinary(); len(got) != 1 || got[0] != key[0] {
+				t.Errorf("account %s: got data %v", key, got)
+			}
+			if rpcCtx.Context.Slot != 42 {
+				t.Errorf("account %s: got slot %d", key, rpcCtx.Context.Slot)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if calls := fake.calls.Load(); calls != 1 {
+		t.Fatalf("expected 1 getMultipleAccounts call, got %d", calls)
+	}
+}
+
+func TestAccountLoaderMissingAccount(t *testing.T) {
+	var (
+		missing = solana.NewWallet().PublicKey()
+		conn    = rpc.NewWithCustomRPCClient(NewAccountLoader(&fakeRPC{missing: missing}, time.Millisecond))
+	)
+
+	_, err := conn.GetAccountInfo(context.Background(), missing)
+	if !errors.Is(err, rpc.ErrNotFound) {
+		t.Fatalf("expected rpc.ErrNotFound, got %v", err)
+	}
+}
@@ -1,7 +1,9 @@
 package anchor
 
 import (
+	"context"
 	cp_amm "dammv2GoSDK/generated/cpAmm"
+	"errors"
 
 	"github.com/gagliardetto/solana-go"
 	"github.com/gagliardetto/solana-go/rpc"
@@ -13,10 +15,231 @@ type PgMethodI interface {
 	ValidateAndBuild() (*cp_amm.Instruction, error)
 }
 
+// PgMethods builds instructions of type T, the Anchor `program.methods` way:
+// accounts the program can work out on its own are filled in by resolvers and
+// the caller only names the ones it has to.
 type PgMethods[T PgMethodI] struct {
 	programID            solana.PublicKey
 	accountDiscriminator [8]byte
 	conn                 *rpc.Client
 	account              func() T
+	resolvers            []AccountResolver
 }
 
+// NewPgMethods returns a builder factory for instructions created by newInstruction,
+// typically a generated New<Name>InstructionBuilder. DefaultResolver runs before resolvers.
+// conn is only needed for Transaction and Simulate, and by resolvers reading chain state.
+func NewPgMethods[T PgMethodI](
+	conn *rpc.Client,
+	programID solana.PublicKey,
+	newInstruction func() T,
+	resolvers ...AccountResolver,
+) *PgMethods[T] {
+	return &PgMethods[T]{
+		programID: programID,
+		conn:      conn,
+		account:   newInstruction,
+		resolvers: append([]AccountResolver{DefaultResolver}, resolvers...),
+	}
+}
+
+// Method starts building a new instruction.
+func (m *PgMethods[T]) Method() *MethodBuilder[T] {
+	return &MethodBuilder[T]{
+		methods:   m,
+		ix:        m.account(),
+		overrides: make(map[string]solana.PublicKey),
+	}
+}
+
+// MethodBuilder is a fluent builder for a single instruction.
+type MethodBuilder[T PgMethodI] struct {
+	methods   *PgMethods[T]
+	ix        T
+	args      []func(T)
+	overrides map[string]solana.PublicKey
+	resolvers []AccountResolver
+	pre, post []solana.Instruction
+}
+
+// Args runs set on the underlying instruction, typically to call its SetParams or other argument setters.
+func (b *MethodBuilder[T]) Args(set func(ix T)) *MethodBuilder[T] {
+	b.args = append(b.args, set)
+	return b
+}
+
+// Account sets the account name, either the IDL (snake_case) or the generated (CamelCase) name.
+// It takes precedence over anything a resolver would derive. Names the instruction does not
+// take are handed to resolvers as hints, e.g a position_nft_mint to derive the position from.
+func (b *MethodBuilder[T]) Account(name string, key solana.PublicKey) *MethodBuilder[T] {
+	b.overrides[normalizeAccountName(name)] = key
+	return b
+}
+
+// Accounts sets every account of accounts, see Account.
+func (b *MethodBuilder[T]) Accounts(accounts map[string]solana.PublicKey) *MethodBuilder[T] {
+	for name, key := range accounts {
+		b.Account(name, key)
+	}
+	return b
+}
+
+// Resolver adds a resolver for this instruction only, it runs after the PgMethods ones.
+func (b *MethodBuilder[T]) Resolver(resolver AccountResolver) *MethodBuilder[T] {
+	b.resolvers = append(b.resolvers, resolver)
+	return b
+}
+
+// PreInstructions are placed before the instruction by Transaction and Simulate.
+func (b *MethodBuilder[T]) PreInstructions(ixns ...solana.Instruction) *MethodBuilder[T] {
+	b.pre = append(b.pre, ixns...)
+	return b
+}
+
+// PostInstructions are placed after the instruction by Transaction and Simulate.
+func (b *MethodBuilder[T]) PostInstructions(ixns ...solana.Instruction) *MethodBuilder[T] {
+	b.post = append(b.post, ixns...)
+	return b
+}
+
+// Instruction resolves the accounts and builds the instruction.
+// Optional accounts nobody provided are passed as the program ID, Anchor's encoding of None.
+func (b *MethodBuilder[T]) Instruction(ctx context.Context) (*cp_amm.Instruction, error) {
+	for _, set := range b.args {
+		set(b.ix)
+	}
+
+	slots, err := accountSlots(b.ix)
+	if err != nil {
+		return nil, err
+	}
+
+	accounts := &ResolvedAccounts{
+		ProgramID: b.methods.programID,
+		Conn:      b.methods.conn,
+		slots:     make(map[string]int, len(slots)),
+		known:     make(map[string]solana.PublicKey, len(slots)),
+		hints:     make(map[string]solana.PublicKey),
+	}
+	for i, slot := range slots {
+		accounts.slots[slot.key] = i
+		if key, ok := b.overrides[slot.key]; ok {
+			accounts.known[slot.key] = key
+		} else if meta := slot.meta(); meta != nil {
+			// filled in by the generated builder, e.g fixed program addresses.
+			accounts.known[slot.key] = meta.PublicKey
+		}
+	}
+	for name, key := range b.overrides {
+		if _, ok := accounts.slots[name]; !ok {
+			// not an account of the instruction, but resolvers may derive others from it.
+			accounts.hints[name] = key
+		}
+	}
+
+	// resolvers may depend on each other's output, run them until nothing new comes out.
+	resolvers := append(append([]AccountResolver(nil), b.methods.resolvers...), b.resolvers...)
+	for {
+		before := len(accounts.known)
+		for _, resolve := range resolvers {
+			if err := resolve(ctx, accounts); err != nil {
+				return nil, err
+			}
+		}
+		if len(accounts.known) == before || len(accounts.known) == len(slots) {
+			break
+		}
+	}
+
+	for _, slot := range slots {
+		if key, ok := accounts.known[slot.key]; ok {
+			slot.set(key)
+		}
+	}
+
+	// required accounts left unset fail validation, the remaining ones are optional.
+	if _, err := b.ix.ValidateAndBuild(); err != nil {
+		return nil, err
+	}
+	for _, slot := range slots {
+		if slot.meta() == nil {
+			slot.setMeta(solana.Meta(b.methods.programID))
+		}
+	}
+
+	return b.ix.ValidateAndBuild()
+}
+
+// Transaction builds a legacy transaction holding the pre instructions, the instruction
+// and the post instructions, against the latest blockhash.
+func (b *MethodBuilder[T]) Transaction(ctx context.Context, feePayer solana.PublicKey) (*solana.Transaction, error) {
+	if b.methods.conn == nil {
+		return nil, errors.New("no rpc client to fetch a blockhash with")
+	}
+
+	ixns, err := b.instructions(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	latest, err := b.methods.conn.GetLatestBlockhash(ctx, rpc.CommitmentFinalized)
+	if err != nil {
+		return nil, err
+	}
+
+	return solana.NewTransaction(ixns, latest.Value.Blockhash, solana.TransactionPayer(feePayer))
+}
+
+// Simulate simulates the transaction built by Transaction, without signatures
+// and with the blockhash replaced by the rpc node.
+func (b *MethodBuilder[T]) Simulate(ctx context.Context, feePayer solana.PublicKey) (*rpc.SimulateTransactionResponse, error) {
+	if b.methods.conn == nil {
+		return nil, errors.New("no rpc client to simulate with")
+	}
+
+	ixns, err := b.instructions(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := solana.NewTransaction(ixns, solana.Hash{}, solana.TransactionPayer(feePayer))
+	if err != nil {
+		return nil, err
+	}
+
+	return b.methods.conn.SimulateTransactionWithOpts(ctx, tx, &rpc.SimulateTransactionOpts{
+		SigVerify:              false,
+		ReplaceRecentBlockhash: true,
+		Commitment:             rpc.CommitmentConfirmed,
+	})
+}
+
+// View runs the transaction built by Transaction through Views.Simulate, returning
+// the decoded events, error and the post-simulation state of accounts.
+func (b *MethodBuilder[T]) View(
+	ctx context.Context,
+	feePayer solana.PublicKey,
+	accounts ...solana.PublicKey,
+) (*ViewResult, error) {
+	ixns, err := b.instructions(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewViews(b.methods.conn, b.methods.programID).Simulate(ctx, feePayer, ixns, ViewOpts{
+		Accounts: accounts,
+	})
+}
+
+func (b *MethodBuilder[T]) instructions(ctx context.Context) ([]solana.Instruction, error) {
+	ix, err := b.Instruction(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	ixns := make([]solana.Instruction, 0, len(b.pre)+1+len(b.post))
+	ixns = append(ixns, b.pre...)
+	ixns = append(ixns, ix)
+	ixns = append(ixns, b.post...)
+	return ixns, nil
+}
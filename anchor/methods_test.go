@@ -0,0 +1,89 @@
+package anchor
+
+import (
+	"context"
+	"testing"
+
+	cp_amm "dammv2GoSDK/generated/cpAmm"
+
+	"github.com/gagliardetto/solana-go"
+)
+
+func TestMethodBuilderResolvesAccounts(t *testing.T) {
+	var (
+		pool       = solana.NewWallet().PublicKey()
+		payer      = solana.NewWallet().PublicKey()
+		tokenAMint = solana.NewWallet().PublicKey()
+		tokenBMint = solana.NewWallet().PublicKey()
+		vault      = solana.NewWallet().PublicKey()
+		input      = solana.NewWallet().PublicKey()
+		output     = solana.NewWallet().PublicKey()
+	)
+
+	methods := NewPgMethods(nil, cp_amm.ProgramID, cp_amm.NewSwapInstructionBuilder,
+		func(_ context.Context, accounts *ResolvedAccounts) error {
+			if _, ok := accounts.Get("token_a_mint"); ok {
+				accounts.Set("token_a_vault", vault)
+			}
+			return nil
+		},
+	)
+
+	ix, err := methods.Method().
+		Args(func(ix *cp_amm.SwapInstruction) {
+			ix.SetParams(cp_amm.SwapParameters{AmountIn: 10, MinimumAmountOut: 1})
+		}).
+		Accounts(map[string]solana.PublicKey{
+			"pool":                 pool,
+			"payer":                payer,
+			"input_token_account":  input,
+			"output_token_account": output,
+			"TokenAMint":           tokenAMint,
+			"token_b_mint":         tokenBMint,
+			"token_b_vault":        vault,
+			"token_a_program":      solana.TokenProgramID,
+			"token_b_program":      solana.TokenProgramID,
+		}).
+		Instruction(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	swap := ix.Impl.(cp_amm.SwapInstruction)
+	eventAuthority, _, _ := swap.FindEventAuthorityAddress()
+
+	for name, tc := range map[string]struct {
+		got  *solana.AccountMeta
+		want solana.PublicKey
+	}{
+		"pool":            {swap.GetPoolAccount(), pool},
+		"token_a_mint":    {swap.GetTokenAMintAccount(), tokenAMint},
+		"token_a_vault":   {swap.GetTokenAVaultAccount(), vault},
+		"event_authority": {swap.GetEventAuthorityAccount(), eventAuthority},
+		"program":         {swap.GetProgramAccount(), cp_amm.ProgramID},
+		// optional and not provided: Anchor's None.
+		"referral_token_account": {swap.GetReferralTokenAccountAccount(), cp_amm.ProgramID},
+	} {
+		if tc.got == nil || !tc.got.PublicKey.Equals(tc.want) {
+			t.Errorf("%s: got %v, want %s", name, tc.got, tc.want)
+		}
+	}
+	if !swap.GetPayerAccount().IsSigner {
+		t.Error("payer lost its signer flag")
+	}
+	if swap.GetReferralTokenAccountAccount().IsWritable {
+		t.Error("a None optional account must not be writable")
+	}
+}
+
+func TestMethodBuilderMissingAccount(t *testing.T) {
+	_, err := NewPgMethods(nil, cp_amm.ProgramID, cp_amm.NewSwapInstructionBuilder).
+		Method().
+		Args(func(ix *cp_amm.SwapInstruction) {
+			ix.SetParams(cp_amm.SwapParameters{})
+		}).
+		Instruction(context.Background())
+	if err == nil {
+		t.Fatal("expected missing required accounts to fail validation")
+	}
+}
@@ -0,0 +1,165 @@
+package anchor
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+// AccountResolver fills in the accounts of an instruction it knows how to derive.
+// Resolvers are run repeatedly until none of them adds an account, so a resolver
+// only has to handle the accounts whose inputs are already known.
+type AccountResolver func(ctx context.Context, accounts *ResolvedAccounts) error
+
+// ResolvedAccounts is the account set of an instruction being resolved.
+// Names are matched regardless of case and underscores, so "token_a_vault"
+// and "TokenAVault" are the same account.
+type ResolvedAccounts struct {
+	ProgramID solana.PublicKey
+	// Conn may be nil, resolvers needing chain state must then leave their accounts alone.
+	Conn *rpc.Client
+
+	slots map[string]int
+	known map[string]solana.PublicKey
+	hints map[string]solana.PublicKey
+}
+
+// Has reports whether the instruction takes the account name.
+func (r *ResolvedAccounts) Has(name string) bool {
+	_, ok := r.slots[normalizeAccountName(name)]
+	return ok
+}
+
+// Get returns the account name when it is already known, or given as a hint.
+func (r *ResolvedAccounts) Get(name string) (solana.PublicKey, bool) {
+	name = normalizeAccountName(name)
+	if key, ok := r.known[name]; ok {
+		return key, true
+	}
+	key, ok := r.hints[name]
+	return key, ok
+}
+
+// Missing reports whether the instruction takes the account name and it is still unknown.
+func (r *ResolvedAccounts) Missing(name string) bool {
+	name = normalizeAccountName(name)
+	if _, ok := r.slots[name]; !ok {
+		return false
+	}
+	_, ok := r.known[name]
+	return !ok
+}
+
+// Set records key for the account name if it is missing, known accounts are never overwritten.
+func (r *ResolvedAccounts) Set(name string, key solana.PublicKey) {
+	if r.Missing(name) {
+		r.known[normalizeAccountName(name)] = key
+	}
+}
+
+// DefaultResolver fills in the accounts every Anchor program shares: the program itself,
+// its event authority and the well-known system programs.
+func DefaultResolver(_ context.Context, accounts *ResolvedAccounts) error {
+	accounts.Set("program", accounts.ProgramID)
+	accounts.Set("system_program", solana.SystemProgramID)
+	accounts.Set("associated_token_program", solana.SPLAssociatedTokenAccountProgramID)
+	accounts.Set("rent", solana.SysVarRentPubkey)
+
+	if accounts.Missing("event_authority") {
+		eventAuthority, _, err := solana.FindProgramAddress(
+			[][]byte{[]byte("__event_authority")},
+			accounts.ProgramID,
+		)
+		if err != nil {
+			return err
+		}
+		accounts.Set("event_authority", eventAuthority)
+	}
+
+	return nil
+}
+
+func normalizeAccountName(name string) string {
+	return strings.ToLower(strings.ReplaceAll(name, "_", ""))
+}
+
+var (
+	publicKeyType   = reflect.TypeOf(solana.PublicKey{})
+	accountMetaType = reflect.TypeOf(&solana.AccountMeta{})
+)
+
+// accountSlot gives access to one account of a generated instruction builder
+// through its Set<Name>Account and Get<Name>Account methods.
+type accountSlot struct {
+	key  string
+	set  func(solana.PublicKey)
+	meta func() *solana.AccountMeta
+	// setMeta stores meta as is, bypassing the writable and signer flags of set.
+	setMeta func(*solana.AccountMeta)
+}
+
+func accountSlots(ix any) ([]accountSlot, error) {
+	v := reflect.ValueOf(ix)
+	if v.Kind() != reflect.Pointer || v.IsNil() {
+		return nil, errors.New("instruction builder must be a non-nil pointer")
+	}
+
+	metas := v.Elem().FieldByName("AccountMetaSlice")
+	if !metas.IsValid() {
+		return nil, errors.New("instruction builder has no AccountMetaSlice")
+	}
+
+	var slots []accountSlot
+	t := v.Type()
+	for i := range t.NumMethod() {
+		method := t.Method(i)
+		name, ok := strings.CutPrefix(method.Name, "Get")
+		if !ok || !strings.HasSuffix(name, "Account") {
+			continue
+		}
+		name = strings.TrimSuffix(name, "Account")
+
+		getter := v.Method(i)
+		setter := v.MethodByName("Set" + name + "Account")
+		if getter.Type().NumIn() != 0 || getter.Type().NumOut() != 1 || getter.Type().Out(0) != accountMetaType ||
+			!setter.IsValid() || setter.Type().NumIn() != 1 || setter.Type().In(0) != publicKeyType {
+			continue
+		}
+
+		// locate the slot index by setting a marker key and looking for it.
+		var index = -1
+		previous := getter.Call(nil)[0].Interface().(*solana.AccountMeta)
+		marker := solana.PublicKey{0xff, byte(i)}
+		setter.Call([]reflect.Value{reflect.ValueOf(marker)})
+		for j := range metas.Len() {
+			if meta, _ := metas.Index(j).Interface().(*solana.AccountMeta); meta != nil && meta.PublicKey.Equals(marker) {
+				index = j
+				break
+			}
+		}
+		if index < 0 {
+			continue
+		}
+		metas.Index(index).Set(reflect.ValueOf(previous))
+
+		slots = append(slots, accountSlot{
+			key: normalizeAccountName(name),
+			set: func(key solana.PublicKey) {
+				setter.Call([]reflect.Value{reflect.ValueOf(key)})
+			},
+			meta: func() *solana.AccountMeta {
+				meta, _ := metas.Index(index).Interface().(*solana.AccountMeta)
+				return meta
+			},
+			setMeta: func(meta *solana.AccountMeta) {
+				metas.Index(index).Set(reflect.ValueOf(meta))
+			},
+		})
+	}
+
+	return slots, nil
+}
@@ -0,0 +1,203 @@
+package anchor
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"runtime"
+
+	ag_binary "github.com/gagliardetto/binary"
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+	"golang.org/x/sync/errgroup"
+)
+
+var (
+	// ErrAccountNotFound reports an address with no account behind it.
+	ErrAccountNotFound = errors.New("account not found")
+	// ErrDiscriminatorMismatch reports an account of another type than the one requested.
+	ErrDiscriminatorMismatch = errors.New("account discriminator mismatch")
+)
+
+// FetchMode selects how FetchMultipleResults and AllResults treat per-account failures.
+type FetchMode uint8
+
+const (
+	// FetchModeLenient records failures on the item and keeps going.
+	FetchModeLenient FetchMode = iota
+	// FetchModeStrict returns an *AccountError for the first missing or undecodable account.
+	FetchModeStrict
+)
+
+// AccountResult is the outcome of reading a single account.
+type AccountResult[T any] struct {
+	PublicKey solana.PublicKey
+	// Value is the decoded account, only meaningful when Exists is set and Err is nil.
+	Value  T
+	Exists bool
+	// Err is ErrAccountNotFound for missing accounts, or the decoding error
+	// (wrapping ErrDiscriminatorMismatch when applicable).
+	Err error
+	// Slot is the rpc context slot the account was read at.
+	Slot uint64
+}
+
+// Ok reports whether the account exists and decoded cleanly.
+func (r AccountResult[T]) Ok() bool {
+	return r.Exists && r.Err == nil
+}
+
+// AccountError is returned in strict mode for the first failing account.
+type AccountError struct {
+	PublicKey solana.PublicKey
+	Err       error
+}
+
+func (e *AccountError) Error() string {
+	return fmt.Sprintf("account %s: %s", e.PublicKey, e.Err)
+}
+
+func (e *AccountError) Unwrap() error {
+	return e.Err
+}
+
+// decode decodes data, first checking it starts with discriminator when one is given.
+func (ac *PgAccounts[T]) decode(data []byte, discriminator []byte) (T, error) {
+	var zeroValue T
+	if discriminator != nil && !bytes.HasPrefix(data, discriminator) {
+		return zeroValue, fmt.Errorf("%w: wanted %v, got %v", ErrDiscriminatorMismatch, discriminator, data[:min(len(data), len(discriminator))])
+	}
+
+	concrete := ac.account()
+	if err := concrete.UnmarshalWithDecoder(ag_binary.NewBorshDecoder(data)); err != nil {
+		return zeroValue, err
+	}
+	return concrete, nil
+}
+
+// FetchMultipleResults fetches addresses in batches of getMultipleAccounts and returns
+// one result per address, index-aligned with addresses. Accounts of another type are only
+// told apart, with ErrDiscriminatorMismatch, when WithDiscriminator was given.
+func (ac *PgAccounts[T]) FetchMultipleResults(
+	ctx context.Context,
+	addresses []solana.PublicKey,
+	opts *rpc.GetMultipleAccountsOpts,
+	mode FetchMode,
+) ([]AccountResult[T], error) {
+	var (
+		batchSize = 99
+		res       = make([]AccountResult[T], len(addresses))
+		g, newCtx = errgroup.WithContext(ctx)
+	)
+
+	g.SetLimit(runtime.NumCPU())
+	for i := 0; i < len(addresses); i += batchSize {
+		batchStart := i
+		end := min(batchStart+batchSize, len(addresses))
+		batchKeys := addresses[batchStart:end]
+
+		g.Go(func() error {
+			out, err := ac.conn.GetMultipleAccountsWithOpts(newCtx, batchKeys, opts)
+			if err != nil {
+				return err
+			}
+
+			if out == nil || len(out.Value) != len(batchKeys) {
+				return errors.New("GetMultipleAccounts returned an unexpected number of results")
+			}
+
+			for j, value := range out.Value {
+				item := AccountResult[T]{
+					PublicKey: batchKeys[j],
+					Slot:      out.Context.Slot,
+				}
+				if value == nil || value.Data == nil || len(value.Data.GetBinary()) == 0 {
+					item.Err = ErrAccountNotFound
+				} else {
+					item.Exists = true
+					item.Value, item.Err = ac.decode(value.Data.GetBinary(), ac.discriminator)
+				}
+
+				if mode == FetchModeStrict && item.Err != nil {
+					return &AccountError{PublicKey: item.PublicKey, Err: item.Err}
+				}
+				res[batchStart+j] = item
+			}
+
+			return nil
+		})
+	}
+
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// AllResults is All returning a result for every account matched by the filters,
+// undecodable ones included.
+func (ac *PgAccounts[T]) AllResults(
+	ctx context.Context,
+	programID solana.PublicKey,
+	accountDiscriminator [8]byte,
+	filtersParam []rpc.RPCFilter,
+	bufferParam []byte,
+	mode FetchMode,
+) ([]AccountResult[T], error) {
+	prefix := bytes.NewBuffer(bytes.Clone(accountDiscriminator[:]))
+	if bufferParam != nil {
+		prefix.Write(bufferParam)
+	}
+
+	params := []any{
+		programID,
+		rpc.M{
+			"encoding":    solana.EncodingBase64,
+			"withContext": true,
+			"filters": append(
+				[]rpc.RPCFilter{{
+					Memcmp: &rpc.RPCFilterMemcmp{
+						Offset: 0,
+						Bytes:  prefix.Bytes(),
+					},
+				}},
+				filtersParam...,
+			),
+		},
+	}
+
+	var out struct {
+		Context rpc.Context                  `json:"context"`
+		Value   rpc.GetProgramAccountsResult `json:"value"`
+	}
+	if err := ac.conn.RPCCallForInto(ctx, &out, "getProgramAccounts", params); err != nil {
+		return nil, err
+	}
+
+	res := make([]AccountResult[T], 0, len(out.Value))
+	for _, v := range out.Value {
+		if v == nil {
+			continue
+		}
+
+		item := AccountResult[T]{
+			PublicKey: v.Pubkey,
+			Slot:      out.Context.Slot,
+		}
+		if v.Account == nil || v.Account.Data == nil || len(v.Account.Data.GetBinary()) == 0 {
+			item.Err = ErrAccountNotFound
+		} else {
+			item.Exists = true
+			item.Value, item.Err = ac.decode(v.Account.Data.GetBinary(), accountDiscriminator[:])
+		}
+
+		if mode == FetchModeStrict && item.Err != nil {
+			return nil, &AccountError{PublicKey: item.PublicKey, Err: item.Err}
+		}
+		res = append(res, item)
+	}
+
+	return res, nil
+}
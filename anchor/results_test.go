@@ -0,0 +1,131 @@
+package anchor
+
+import (
+	"bytes"
+	"context"
+	cp_amm "dammv2GoSDK/generated/cpAmm"
+	"dammv2GoSDK/internal/test/rpctest"
+	"errors"
+	"testing"
+
+	ag_binary "github.com/gagliardetto/binary"
+	"github.com/gagliardetto/solana-go"
+)
+
+func encode(t *testing.T, account PgAccountI) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := account.MarshalWithEncoder(ag_binary.NewBorshEncoder(&buf)); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestResults(t *testing.T) {
+	ctx := context.Background()
+	var (
+		pool     = solana.NewWallet().PublicKey()
+		missing  = solana.NewWallet().PublicKey()
+		corrupt  = solana.NewWallet().PublicKey()
+		position = solana.NewWallet().PublicKey()
+		poolData = encode(t, &cp_amm.PoolAccount{Liquidity: ag_binary.Uint128{Lo: 7}})
+		accounts = &rpctest.Accounts{
+			Slot:  42,
+			Owner: solana.SystemProgramID,
+			Data: map[solana.PublicKey][]byte{
+				pool: poolData,
+				// a pool account cut short, with the right discriminator.
+				corrupt:  poolData[:40],
+				position: encode(t, &cp_amm.PositionAccount{Pool: pool}),
+			},
+		}
+		conn    = rpctest.NewClient(accounts.Handle)
+		newPool = func() *cp_amm.PoolAccount { return &cp_amm.PoolAccount{} }
+	)
+
+	t.Run("fetch lenient", func(t *testing.T) {
+		results, err := NewPgAccounts(conn, newPool).
+			WithDiscriminator(cp_amm.PoolAccountDiscriminator).
+			FetchMultipleResults(ctx, []solana.PublicKey{pool, missing, corrupt, position}, nil, FetchModeLenient)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(results) != 4 {
+			t.Fatalf("results = %+v", results)
+		}
+		for i, key := range []solana.PublicKey{pool, missing, corrupt, position} {
+			if results[i].PublicKey != key || results[i].Slot != 42 {
+				t.Errorf("result %d = %+v, want %s at slot 42", i, results[i], key)
+			}
+		}
+		if !results[0].Ok() || results[0].Value.Liquidity.Lo != 7 {
+			t.Errorf("pool = %+v", results[0])
+		}
+		if results[1].Exists || !errors.Is(results[1].Err, ErrAccountNotFound) {
+			t.Errorf("missing = %+v", results[1])
+		}
+		if !results[2].Exists || results[2].Err == nil || errors.Is(results[2].Err, ErrDiscriminatorMismatch) {
+			t.Errorf("corrupt = %+v, want a decoding error", results[2])
+		}
+		if !results[3].Exists || !errors.Is(results[3].Err, ErrDiscriminatorMismatch) {
+			t.Errorf("position = %+v, want a discriminator mismatch", results[3])
+		}
+	})
+
+	t.Run("fetch strict", func(t *testing.T) {
+		for _, tt := range []struct {
+			name    string
+			key     solana.PublicKey
+			wantErr error
+		}{
+			{"missing", missing, ErrAccountNotFound},
+			{"corrupt", corrupt, nil},
+			{"wrong discriminator", position, ErrDiscriminatorMismatch},
+		} {
+			t.Run(tt.name, func(t *testing.T) {
+				results, err := NewPgAccounts(conn, newPool).
+					WithDiscriminator(cp_amm.PoolAccountDiscriminator).
+					FetchMultipleResults(ctx, []solana.PublicKey{pool, tt.key}, nil, FetchModeStrict)
+				var accountErr *AccountError
+				if results != nil || !errors.As(err, &accountErr) || accountErr.PublicKey != tt.key {
+					t.Fatalf("results, err = %+v, %v, want an *AccountError for %s", results, err, tt.key)
+				}
+				if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+					t.Fatalf("err = %v, want %v", err, tt.wantErr)
+				}
+			})
+		}
+
+		results, err := NewPgAccounts(conn, newPool).
+			WithDiscriminator(cp_amm.PoolAccountDiscriminator).
+			FetchMultipleResults(ctx, []solana.PublicKey{pool}, nil, FetchModeStrict)
+		if err != nil || len(results) != 1 || !results[0].Ok() {
+			t.Fatalf("results, err = %+v, %v", results, err)
+		}
+	})
+
+	t.Run("all", func(t *testing.T) {
+		// the discriminator filter leaves the position out, the corrupt pool is kept.
+		results, err := NewPgAccounts(conn, newPool).AllResults(
+			ctx, solana.SystemProgramID, cp_amm.PoolAccountDiscriminator, nil, nil, FetchModeLenient,
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+		byKey := make(map[solana.PublicKey]AccountResult[*cp_amm.PoolAccount])
+		for _, result := range results {
+			byKey[result.PublicKey] = result
+		}
+		if len(results) != 2 || !byKey[pool].Ok() || byKey[corrupt].Err == nil {
+			t.Fatalf("results = %+v", results)
+		}
+
+		_, err = NewPgAccounts(conn, newPool).AllResults(
+			ctx, solana.SystemProgramID, cp_amm.PoolAccountDiscriminator, nil, nil, FetchModeStrict,
+		)
+		var accountErr *AccountError
+		if !errors.As(err, &accountErr) || accountErr.PublicKey != corrupt {
+			t.Fatalf("err = %v, want an *AccountError for %s", err, corrupt)
+		}
+	})
+}
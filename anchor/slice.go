@@ -0,0 +1,172 @@
+package anchor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+	"golang.org/x/sync/errgroup"
+)
+
+// FieldRange is a byte range of account data, Offset is counted from the start of the account,
+// the discriminator included.
+type FieldRange struct {
+	Offset uint64
+	Length uint64
+}
+
+// SpanOf returns the smallest range covering every range.
+// The rpc dataSlice option takes a single contiguous range, so requesting several
+// fields means requesting their span.
+func SpanOf(ranges ...FieldRange) FieldRange {
+	if len(ranges) == 0 {
+		return FieldRange{}
+	}
+
+	start, end := ranges[0].Offset, ranges[0].Offset+ranges[0].Length
+	for _, r := range ranges[1:] {
+		start = min(start, r.Offset)
+		end = max(end, r.Offset+r.Length)
+	}
+	return FieldRange{Offset: start, Length: end - start}
+}
+
+// AccountSlice is a window of account data read through dataSlice.
+type AccountSlice struct {
+	PublicKey solana.PublicKey
+	// Offset is where Data starts in the account.
+	Offset uint64
+	// Data is nil when the account does not exist.
+	Data []byte
+}
+
+// Field returns the bytes of r, which must lie within the fetched window.
+func (s AccountSlice) Field(r FieldRange) ([]byte, error) {
+	if r.Offset < s.Offset || r.Offset+r.Length > s.Offset+uint64(len(s.Data)) {
+		return nil, fmt.Errorf("range [%d, %d) outside of fetched window [%d, %d) of %s",
+			r.Offset, r.Offset+r.Length, s.Offset, s.Offset+uint64(len(s.Data)), s.PublicKey)
+	}
+
+	start := r.Offset - s.Offset
+	return s.Data[start : start+r.Length], nil
+}
+
+func (s AccountSlice) Exists() bool {
+	return s.Data != nil
+}
+
+// AllSlices is All reading only the span of ranges of every matching account.
+// Nothing is decoded, callers pick fields out of the windows with AccountSlice.Field.
+func (ac *PgAccounts[T]) AllSlices(
+	ctx context.Context,
+	programID solana.PublicKey,
+	accountDiscriminator [8]byte,
+	filtersParam []rpc.RPCFilter,
+	ranges ...FieldRange,
+) ([]AccountSlice, error) {
+	span := SpanOf(ranges...)
+
+	opts := rpc.GetProgramAccountsOpts{
+		Encoding: solana.EncodingBase64,
+		DataSlice: &rpc.DataSlice{
+			Offset: &span.Offset,
+			Length: &span.Length,
+		},
+		Filters: append(
+			[]rpc.RPCFilter{{
+				Memcmp: &rpc.RPCFilterMemcmp{
+					Offset: 0,
+					Bytes:  accountDiscriminator[:],
+				},
+			}},
+			filtersParam...,
+		),
+	}
+
+	out, err := ac.conn.GetProgramAccountsWithOpts(ctx, programID, &opts)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]AccountSlice, 0, len(out))
+	for _, v := range out {
+		if v == nil || v.Account == nil || v.Account.Data == nil {
+			continue
+		}
+		data := v.Account.Data.GetBinary()
+		if uint64(len(data)) != span.Length {
+			continue
+		}
+		res = append(res, AccountSlice{
+			PublicKey: v.Pubkey,
+			Offset:    span.Offset,
+			Data:      data,
+		})
+	}
+
+	return res, nil
+}
+
+// FetchMultipleSlices is FetchMultiple reading only the span of ranges of every address.
+// The result is index-aligned with addresses.
+func (ac *PgAccounts[T]) FetchMultipleSlices(
+	ctx context.Context,
+	addresses []solana.PublicKey,
+	commitment rpc.CommitmentType,
+	ranges ...FieldRange,
+) ([]AccountSlice, error) {
+	var (
+		span      = SpanOf(ranges...)
+		batchSize = 99
+		res       = make([]AccountSlice, len(addresses))
+		g, newCtx = errgroup.WithContext(ctx)
+	)
+
+	opts := rpc.GetMultipleAccountsOpts{
+		Encoding:   solana.EncodingBase64,
+		Commitment: commitment,
+		DataSlice: &rpc.DataSlice{
+			Offset: &span.Offset,
+			Length: &span.Length,
+		},
+	}
+
+	g.SetLimit(runtime.NumCPU())
+	for i := 0; i < len(addresses); i += batchSize {
+		batchStart := i
+		end := min(batchStart+batchSize, len(addresses))
+		batchKeys := addresses[batchStart:end]
+
+		g.Go(func() error {
+			out, err := ac.conn.GetMultipleAccountsWithOpts(newCtx, batchKeys, &opts)
+			if err != nil {
+				return err
+			}
+
+			if out == nil || len(out.Value) != len(batchKeys) {
+				return errors.New("GetMultipleAccounts returned an unexpected number of results")
+			}
+
+			for j, value := range out.Value {
+				res[batchStart+j] = AccountSlice{
+					PublicKey: batchKeys[j],
+					Offset:    span.Offset,
+				}
+				if value != nil && value.Data != nil && uint64(len(value.Data.GetBinary())) == span.Length {
+					res[batchStart+j].Data = value.Data.GetBinary()
+				}
+			}
+
+			return nil
+		})
+	}
+
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
@@ -0,0 +1,115 @@
+package anchor
+
+import (
+	"bytes"
+	"context"
+	cp_amm "dammv2GoSDK/generated/cpAmm"
+	"dammv2GoSDK/helpers/filters"
+	"dammv2GoSDK/internal/test/rpctest"
+	"testing"
+
+	ag_binary "github.com/gagliardetto/binary"
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+func TestSpanOf(t *testing.T) {
+	for _, tt := range []struct {
+		name   string
+		ranges []FieldRange
+		want   FieldRange
+	}{
+		{"none", nil, FieldRange{}},
+		{"one", []FieldRange{{Offset: 8, Length: 32}}, FieldRange{Offset: 8, Length: 32}},
+		{"apart, unordered", []FieldRange{{Offset: 100, Length: 1}, {Offset: 8, Length: 32}}, FieldRange{Offset: 8, Length: 93}},
+		{"overlapping", []FieldRange{{Offset: 8, Length: 32}, {Offset: 16, Length: 8}}, FieldRange{Offset: 8, Length: 32}},
+		{"adjacent", []FieldRange{{Offset: 40, Length: 16}, {Offset: 8, Length: 32}}, FieldRange{Offset: 8, Length: 48}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SpanOf(tt.ranges...); got != tt.want {
+				t.Fatalf("SpanOf = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAccountSliceField(t *testing.T) {
+	s := AccountSlice{Offset: 10, Data: []byte{1, 2, 3, 4}}
+	for _, tt := range []struct {
+		r    FieldRange
+		want []byte
+	}{
+		{FieldRange{Offset: 10, Length: 4}, []byte{1, 2, 3, 4}},
+		{FieldRange{Offset: 12, Length: 1}, []byte{3}},
+		{FieldRange{Offset: 9, Length: 2}, nil},
+		{FieldRange{Offset: 13, Length: 2}, nil},
+	} {
+		got, err := s.Field(tt.r)
+		if (err == nil) != (tt.want != nil) || !bytes.Equal(got, tt.want) {
+			t.Errorf("Field(%+v) = %v, %v, want %v", tt.r, got, err, tt.want)
+		}
+	}
+}
+
+func TestSlices(t *testing.T) {
+	ctx := context.Background()
+	var (
+		pool     = solana.NewWallet().PublicKey()
+		short    = solana.NewWallet().PublicKey()
+		missing  = solana.NewWallet().PublicKey()
+		mint     = FieldRange{Offset: filters.PoolTokenBMintOffset, Length: solana.PublicKeyLength}
+		price    = FieldRange{Offset: filters.PoolSqrtPriceOffset, Length: 16}
+		state    = &cp_amm.PoolAccount{TokenBMint: solana.NewWallet().PublicKey(), SqrtPrice: ag_binary.Uint128{Lo: 7, Hi: 9}}
+		encoded  bytes.Buffer
+		accounts = &rpctest.Accounts{Owner: solana.SystemProgramID, Data: map[solana.PublicKey][]byte{}}
+	)
+	if err := state.MarshalWithEncoder(ag_binary.NewBorshEncoder(&encoded)); err != nil {
+		t.Fatal(err)
+	}
+	accounts.Data[pool] = encoded.Bytes()
+	// a pool account cut before the sqrt price, which the window can't cover.
+	accounts.Data[short] = encoded.Bytes()[:price.Offset]
+
+	// the fields read through the window are the ones of the full decode.
+	assertFields := func(s AccountSlice) {
+		t.Helper()
+		var decoded cp_amm.PoolAccount
+		if err := decoded.UnmarshalWithDecoder(ag_binary.NewBorshDecoder(accounts.Data[pool])); err != nil {
+			t.Fatal(err)
+		}
+		gotMint, err := s.Field(mint)
+		if err != nil {
+			t.Fatal(err)
+		}
+		gotPrice, err := s.Field(price)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var wantPrice bytes.Buffer
+		if err := ag_binary.NewBorshEncoder(&wantPrice).Encode(decoded.SqrtPrice); err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(gotMint, decoded.TokenBMint[:]) || !bytes.Equal(gotPrice, wantPrice.Bytes()) {
+			t.Fatalf("fields = %x, %x, want %x, %x", gotMint, gotPrice, decoded.TokenBMint[:], wantPrice.Bytes())
+		}
+	}
+
+	pgAccounts := NewPgAccounts(rpctest.NewClient(accounts.Handle), func() *cp_amm.PoolAccount { return &cp_amm.PoolAccount{} })
+	all, err := pgAccounts.AllSlices(ctx, solana.SystemProgramID, cp_amm.PoolAccountDiscriminator, nil, price, mint)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != 1 || all[0].PublicKey != pool || all[0].Offset != mint.Offset {
+		t.Fatalf("slices = %+v", all)
+	}
+	assertFields(all[0])
+
+	fetched, err := pgAccounts.FetchMultipleSlices(ctx, []solana.PublicKey{missing, pool, short}, rpc.CommitmentConfirmed, mint, price)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fetched) != 3 || fetched[0].Exists() || fetched[0].PublicKey != missing || fetched[2].Exists() {
+		t.Fatalf("slices = %+v", fetched)
+	}
+	assertFields(fetched[1])
+}
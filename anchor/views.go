@@ -0,0 +1,226 @@
+package anchor
+
+import (
+	"context"
+	cp_amm "dammv2GoSDK/generated/cpAmm"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	ag_binary "github.com/gagliardetto/binary"
+	"github.com/gagliardetto/solana-go"
+	addresslookuptable "github.com/gagliardetto/solana-go/programs/address-lookup-table"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+// Views runs instructions through simulateTransaction and decodes what came out,
+// nothing is ever sent. Signatures are not verified and the blockhash is replaced
+// by the rpc node, so no signer is needed.
+type Views struct {
+	conn      *rpc.Client
+	programID solana.PublicKey
+}
+
+func NewViews(conn *rpc.Client, programID solana.PublicKey) *Views {
+	return &Views{
+		conn:      conn,
+		programID: programID,
+	}
+}
+
+// ViewOpts configures Views.Simulate.
+type ViewOpts struct {
+	// Accounts whose post-simulation state is returned.
+	Accounts []solana.PublicKey
+	// AddressTables, when set, compiles a v0 transaction against these lookup tables.
+	AddressTables map[solana.PublicKey]solana.PublicKeySlice
+	// Commitment the simulation runs at, defaults to confirmed.
+	Commitment rpc.CommitmentType
+}
+
+// ViewResult is the decoded outcome of a simulation.
+type ViewResult struct {
+	// Slot the simulation ran at.
+	Slot uint64
+	// Err is nil when the simulation succeeded, a *TransactionError otherwise, so
+	// errors.Is(res.Err, cp_amm.ErrExceededSlippage) works.
+	Err           error
+	Events        []*cp_amm.Event
+	UnitsConsumed uint64
+	Logs          []string
+	// Accounts holds the post-simulation state of ViewOpts.Accounts, nil for accounts
+	// that do not exist after the simulation.
+	Accounts map[solana.PublicKey]*rpc.Account
+}
+
+// Event returns the data of the first event named name (e.g "EvtSwap"), or nil.
+func (r *ViewResult) Event(name string) cp_amm.EventData {
+	for _, evt := range r.Events {
+		if evt.Name == name {
+			return evt.Data
+		}
+	}
+	return nil
+}
+
+// DecodeViewAccount decodes the post-simulation state of key out of res.
+func DecodeViewAccount[T PgAccountI](res *ViewResult, key solana.PublicKey, account func() T) (T, error) {
+	var zeroValue T
+	acc := res.Accounts[key]
+	if acc == nil || acc.Data == nil || len(acc.Data.GetBinary()) == 0 {
+		return zeroValue, fmt.Errorf("%w: %s", ErrAccountNotFound, key)
+	}
+
+	concrete := account()
+	if err := concrete.UnmarshalWithDecoder(ag_binary.NewBorshDecoder(acc.Data.GetBinary())); err != nil {
+		return zeroValue, err
+	}
+	return concrete, nil
+}
+
+// simulateResult is the simulateTransaction response, requested with innerInstructions
+// so the emit_cpi events of the program are visible.
+type simulateResult struct {
+	Context rpc.Context `json:"context"`
+	Value   struct {
+		Err               any                    `json:"err"`
+		Logs              []string               `json:"logs"`
+		Accounts          []*rpc.Account         `json:"accounts"`
+		UnitsConsumed     *uint64                `json:"unitsConsumed"`
+		InnerInstructions []rpc.InnerInstruction `json:"innerInstructions"`
+	} `json:"value"`
+}
+
+// Simulate runs ixns in a single transaction paid by feePayer.
+// The returned error covers transport and decoding failures only,
+// a failing simulation is reported through ViewResult.Err.
+func (v *Views) Simulate(
+	ctx context.Context,
+	feePayer solana.PublicKey,
+	ixns []solana.Instruction,
+	opts ViewOpts,
+) (*ViewResult, error) {
+	if v.conn == nil {
+		return nil, errors.New("no rpc client to simulate with")
+	}
+	if opts.Commitment == "" {
+		opts.Commitment = rpc.CommitmentConfirmed
+	}
+
+	txOpts := []solana.TransactionOption{solana.TransactionPayer(feePayer)}
+	if len(opts.AddressTables) > 0 {
+		txOpts = append(txOpts, solana.TransactionAddressTables(opts.AddressTables))
+	}
+	tx, err := solana.NewTransaction(ixns, solana.Hash{}, txOpts...)
+	if err != nil {
+		return nil, err
+	}
+
+	txBase64, err := tx.ToBase64()
+	if err != nil {
+		return nil, err
+	}
+
+	config := rpc.M{
+		"encoding":               solana.EncodingBase64,
+		"sigVerify":              false,
+		"replaceRecentBlockhash": true,
+		"innerInstructions":      true,
+		"commitment":             opts.Commitment,
+	}
+	if len(opts.Accounts) > 0 {
+		config["accounts"] = rpc.M{
+			"encoding":  solana.EncodingBase64,
+			"addresses": opts.Accounts,
+		}
+	}
+
+	var out simulateResult
+	if err := v.conn.RPCCallForInto(ctx, &out, "simulateTransaction", []any{txBase64, config}); err != nil {
+		return nil, err
+	}
+
+	res := &ViewResult{
+		Slot:     out.Context.Slot,
+		Logs:     out.Value.Logs,
+		Accounts: make(map[solana.PublicKey]*rpc.Account, len(opts.Accounts)),
+	}
+	if out.Value.UnitsConsumed != nil {
+		res.UnitsConsumed = *out.Value.UnitsConsumed
+	}
+	for i, key := range opts.Accounts {
+		if i < len(out.Value.Accounts) {
+			res.Accounts[key] = out.Value.Accounts[i]
+		}
+	}
+
+	if out.Value.Err != nil {
+		res.Err = NewTransactionError(out.Value.Err, v.programID, &tx.Message, out.Value.Logs)
+	}
+
+	res.Events, err = decodeSimulatedEvents(txBase64, out, v.programID, opts.AddressTables)
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// decodeSimulatedEvents feeds the simulation to cp_amm.DecodeEvents, which works on
+// getTransaction results, by dressing it up as one.
+func decodeSimulatedEvents(
+	txBase64 string,
+	out simulateResult,
+	programID solana.PublicKey,
+	tables map[solana.PublicKey]solana.PublicKeySlice,
+) ([]*cp_amm.Event, error) {
+	envelope := &rpc.TransactionResultEnvelope{}
+	raw, err := json.Marshal([]string{txBase64, string(solana.EncodingBase64)})
+	if err != nil {
+		return nil, err
+	}
+	if err := envelope.UnmarshalJSON(raw); err != nil {
+		return nil, err
+	}
+
+	return cp_amm.DecodeEvents(
+		&rpc.GetTransactionResult{
+			Transaction: envelope,
+			Meta: &rpc.TransactionMeta{
+				LogMessages:       out.Value.Logs,
+				InnerInstructions: out.Value.InnerInstructions,
+			},
+		},
+		programID,
+		func(altAddresses []solana.PublicKey) (map[solana.PublicKey]solana.PublicKeySlice, error) {
+			res := make(map[solana.PublicKey]solana.PublicKeySlice, len(altAddresses))
+			for _, address := range altAddresses {
+				table, ok := tables[address]
+				if !ok {
+					return nil, fmt.Errorf("unknown address lookup table %s", address)
+				}
+				res[address] = table
+			}
+			return res, nil
+		},
+	)
+}
+
+// AddressTablesFetcher returns the getAddressTables callback of cp_amm.DecodeEvents,
+// reading the lookup tables through conn.
+func AddressTablesFetcher(
+	ctx context.Context,
+	conn *rpc.Client,
+) func(altAddresses []solana.PublicKey) (map[solana.PublicKey]solana.PublicKeySlice, error) {
+	return func(altAddresses []solana.PublicKey) (map[solana.PublicKey]solana.PublicKeySlice, error) {
+		res := make(map[solana.PublicKey]solana.PublicKeySlice, len(altAddresses))
+		for _, address := range altAddresses {
+			state, err := addresslookuptable.GetAddressLookupTable(ctx, conn, address)
+			if err != nil {
+				return nil, fmt.Errorf("err fetching address lookup table %s: %w", address, err)
+			}
+			res[address] = state.Addresses
+		}
+		return res, nil
+	}
+}
@@ -0,0 +1,113 @@
+package anchor
+
+import (
+	"bytes"
+	"context"
+	cp_amm "dammv2GoSDK/generated/cpAmm"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+
+	ag_binary "github.com/gagliardetto/binary"
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/gagliardetto/solana-go/rpc/jsonrpc"
+)
+
+// fakeSimulator answers simulateTransaction with a fixed result and records the request.
+type fakeSimulator struct {
+	result map[string]any
+	params []any
+}
+
+func (f *fakeSimulator) CallForInto(_ context.Context, out any, method string, params []any) error {
+	if method != "simulateTransaction" {
+		return errors.New("unexpected method " + method)
+	}
+	f.params = params
+
+	raw, err := json.Marshal(map[string]any{
+		"context": map[string]any{"slot": 7},
+		"value":   f.result,
+	})
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(raw, out)
+}
+
+func (f *fakeSimulator) CallWithCallback(context.Context, string, []any, func(*http.Request, *http.Response) error) error {
+	return errors.New("not implemented")
+}
+
+func (f *fakeSimulator) CallBatch(context.Context, jsonrpc.RPCRequests) (jsonrpc.RPCResponses, error) {
+	return nil, errors.New("not implemented")
+}
+
+func TestViewsSimulate(t *testing.T) {
+	var (
+		programID = solana.NewWallet().PublicKey()
+		feePayer  = solana.NewWallet().PublicKey()
+		pool      = solana.NewWallet().PublicKey()
+	)
+
+	var evt bytes.Buffer
+	if err := (cp_amm.EvtSwapEventData{
+		Pool:           pool,
+		ActualAmountIn: 1_000,
+		SwapResult:     cp_amm.SwapResult{OutputAmount: 990},
+	}).MarshalWithEncoder(ag_binary.NewBorshEncoder(&evt)); err != nil {
+		t.Fatal(err)
+	}
+
+	fake := &fakeSimulator{result: map[string]any{
+		"err": map[string]any{"InstructionError": []any{0, map[string]any{"Custom": 6002}}},
+		"logs": []string{
+			"Program " + programID.String() + " invoke [1]",
+			"Program data: " + base64.StdEncoding.EncodeToString(evt.Bytes()),
+		},
+		"unitsConsumed": 31_337,
+		"accounts": []any{map[string]any{
+			"data":       []string{base64.StdEncoding.EncodeToString([]byte{1, 2, 3}), "base64"},
+			"owner":      programID.String(),
+			"lamports":   1,
+			"executable": false,
+			"rentEpoch":  0,
+		}},
+	}}
+	views := NewViews(rpc.NewWithCustomRPCClient(fake), programID)
+
+	ix := solana.NewInstruction(programID, solana.AccountMetaSlice{solana.Meta(pool).WRITE()}, []byte{0})
+	res, err := views.Simulate(context.Background(), feePayer, []solana.Instruction{ix}, ViewOpts{
+		Accounts: []solana.PublicKey{pool},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	config := fake.params[1].(rpc.M)
+	if config["sigVerify"] != false || config["replaceRecentBlockhash"] != true {
+		t.Fatalf("simulation config = %v", config)
+	}
+
+	if !errors.Is(res.Err, cp_amm.ErrExceededSlippage) {
+		t.Fatalf("err = %v, want ErrExceededSlippage", res.Err)
+	}
+	if res.UnitsConsumed != 31_337 || res.Slot != 7 {
+		t.Fatalf("units = %d slot = %d", res.UnitsConsumed, res.Slot)
+	}
+
+	swap, ok := res.Event("EvtSwap").(*cp_amm.EvtSwapEventData)
+	if !ok {
+		t.Fatalf("events = %v, want an EvtSwap", res.Events)
+	}
+	if !swap.Pool.Equals(pool) || swap.SwapResult.OutputAmount != 990 {
+		t.Fatalf("swap event = %+v", swap)
+	}
+
+	if acc := res.Accounts[pool]; acc == nil || !bytes.Equal(acc.Data.GetBinary(), []byte{1, 2, 3}) {
+		t.Fatalf("pool account = %+v", acc)
+	}
+}
@@ -0,0 +1,405 @@
+package dammv2gosdk
+
+import (
+	"context"
+	"dammv2GoSDK/anchor"
+	cp_amm "dammv2GoSDK/generated/cpAmm"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"sync"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+// BackfillCheckpoint is the last transaction a backfill handled the events of.
+type BackfillCheckpoint struct {
+	Signature solana.Signature `json:"signature"`
+	Slot      uint64           `json:"slot"`
+}
+
+// CheckpointStore persists backfill progress, keyed by the backfilled address.
+type CheckpointStore interface {
+	// Load returns the checkpoint of address, nil when there is none.
+	Load(address solana.PublicKey) (*BackfillCheckpoint, error)
+	Save(address solana.PublicKey, checkpoint BackfillCheckpoint) error
+}
+
+// FileCheckpointStore is a CheckpointStore persisting checkpoints as JSON at a path.
+//
+// It is safe for concurrent use by multiple goroutines.
+type FileCheckpointStore struct {
+	path string
+
+	mu          sync.Mutex
+	checkpoints map[solana.PublicKey]BackfillCheckpoint
+}
+
+// NewFileCheckpointStore returns a store persisted at path, loading it if it exists.
+func NewFileCheckpointStore(path string) (*FileCheckpointStore, error) {
+	s := &FileCheckpointStore{
+		path:        path,
+		checkpoints: make(map[solana.PublicKey]BackfillCheckpoint),
+	}
+
+	raw, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("err reading checkpoints: %w", err)
+	}
+	if err := json.Unmarshal(raw, &s.checkpoints); err != nil {
+		return nil, fmt.Errorf("err decoding checkpoints %s: %w", path, err)
+	}
+	return s, nil
+}
+
+func (s *FileCheckpointStore) Load(address solana.PublicKey) (*BackfillCheckpoint, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	checkpoint, ok := s.checkpoints[address]
+	if !ok {
+		return nil, nil
+	}
+	return &checkpoint, nil
+}
+
+func (s *FileCheckpointStore) Save(address solana.PublicKey, checkpoint BackfillCheckpoint) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.checkpoints[address] = checkpoint
+
+	raw, err := json.MarshalIndent(s.checkpoints, "", "  ")
+	if err != nil {
+		return err
+	}
+	// write then rename, so a crash never leaves truncated checkpoints behind.
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, raw, 0o644); err != nil {
+		return fmt.Errorf("err writing checkpoints: %w", err)
+	}
+	return os.Rename(tmp, s.path)
+}
+
+// BackfillOpts configures Backfill.
+type BackfillOpts struct {
+	// Address whose history is backfilled, a pool, a position or, when zero, the program.
+	Address solana.PublicKey
+	// Until, when set, is the newest transaction not to backfill, e.g the last one indexed.
+	// A checkpoint of Address in Checkpoints takes precedence.
+	Until solana.Signature
+	// Filter the events are handed over through.
+	Filter EventFilter
+	// Commitment of the transactions, defaults to confirmed. Processed isn't served.
+	Commitment rpc.CommitmentType
+	// Concurrency is the number of transactions fetched at once, defaults to 8.
+	Concurrency int
+	// FromSlot and ToSlot, when set, bound the slots of the transactions backfilled, e.g to
+	// the ones of a SlotGap. The signatures are only walked back to FromSlot.
+	FromSlot uint64
+	ToSlot   uint64
+	// PageSize is the number of signatures listed per request, defaults to 1000, the most nodes serve.
+	PageSize int
+	// Checkpoints, when set, persists progress every CheckpointEvery transactions and when
+	// the backfill stops, so the next run for Address resumes where it stopped.
+	Checkpoints     CheckpointStore
+	CheckpointEvery int
+}
+
+func (o BackfillOpts) withDefaults() BackfillOpts {
+	if o.Address.IsZero() {
+		o.Address = CpAMMProgramId
+	}
+	if o.Commitment == "" || o.Commitment == rpc.CommitmentProcessed {
+		o.Commitment = rpc.CommitmentConfirmed
+	}
+	if o.Concurrency <= 0 {
+		o.Concurrency = 8
+	}
+	if o.PageSize <= 0 || o.PageSize > 1000 {
+		o.PageSize = 1000
+	}
+	if o.CheckpointEvery <= 0 {
+		o.CheckpointEvery = 100
+	}
+	return o
+}
+
+// Backfill hands the events of every successful transaction of opts.Address newer than
+// the checkpoint, or opts.Until, to handle in slot order, oldest first, along with their
+// signature and slot. Events of a transaction keep the order they were emitted in.
+//
+// Signatures are walked back page by page to the oldest one, keeping only where every page
+// starts, then the pages are listed again oldest first. The transactions of a page are
+// fetched opts.Concurrency at a time and their events decoded with cp_amm.DecodeEvents,
+// reading the lookup tables they use. Backfill stops at the first error, handle's
+// included, and returns it.
+//
+// A checkpoint is only moved past a transaction once handle returned for all its events,
+// but up to opts.CheckpointEvery transactions may be handed over again after a crash:
+// handle should be idempotent by signature.
+func (cp *CpAMM) Backfill(
+	ctx context.Context,
+	opts BackfillOpts,
+	handle func(EventUpdate) error,
+) error {
+	opts = opts.withDefaults()
+
+	until := opts.Until
+	if opts.Checkpoints != nil {
+		checkpoint, err := opts.Checkpoints.Load(opts.Address)
+		if err != nil {
+			return fmt.Errorf("err loading checkpoint of %s: %w", opts.Address, err)
+		}
+		if checkpoint != nil {
+			until = checkpoint.Signature
+		}
+	}
+
+	run := &backfillRun{cp: cp, opts: opts, handle: handle, tables: newLookupTableCache(cp.conn)}
+	for {
+		starts, page, err := cp.signaturePages(ctx, until, opts)
+		if err != nil {
+			return errors.Join(err, run.save())
+		}
+		if len(starts) == 1 {
+			return errors.Join(run.page(ctx, page), run.save())
+		}
+
+		// pages listed before a signature come back the same when listed again. The newest
+		// one, listed from the tip, is left to the next round along with what came since.
+		for i := len(starts) - 1; i > 0; i-- {
+			if i < len(starts)-1 {
+				if page, err = cp.signaturePage(ctx, starts[i], until, opts); err != nil {
+					return errors.Join(err, run.save())
+				}
+			}
+			if err := run.page(ctx, page); err != nil {
+				return errors.Join(err, run.save())
+			}
+			// the next round lists the signatures newer than the ones handled.
+			if len(page) > 0 {
+				until = page[0].Signature
+			}
+		}
+	}
+}
+
+// backfillRun is the progress of a Backfill.
+type backfillRun struct {
+	cp     *CpAMM
+	opts   BackfillOpts
+	handle func(EventUpdate) error
+	tables *lookupTableCache
+
+	last    *BackfillCheckpoint
+	handled int
+}
+
+func (r *backfillRun) save() error {
+	if r.opts.Checkpoints == nil || r.last == nil {
+		return nil
+	}
+	if err := r.opts.Checkpoints.Save(r.opts.Address, *r.last); err != nil {
+		return fmt.Errorf("err saving checkpoint of %s: %w", r.opts.Address, err)
+	}
+	return nil
+}
+
+// page handles the successful transactions of page, a page of signatures newest first,
+// of the slots of r.opts.
+func (r *backfillRun) page(ctx context.Context, page []*rpc.TransactionSignature) error {
+	signatures := make([]*rpc.TransactionSignature, 0, len(page))
+	for _, sig := range slices.Backward(page) {
+		if sig.Err == nil && sig.Slot >= r.opts.FromSlot && (r.opts.ToSlot == 0 || sig.Slot <= r.opts.ToSlot) {
+			signatures = append(signatures, sig)
+		}
+	}
+	if len(signatures) == 0 {
+		return nil
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	type fetched struct {
+		events []*cp_amm.Event
+		err    error
+	}
+	pending := make(chan chan fetched, r.opts.Concurrency)
+	go func() {
+		defer close(pending)
+		for _, sig := range signatures {
+			res := make(chan fetched, 1)
+			select {
+			case pending <- res:
+			case <-ctx.Done():
+				return
+			}
+			go func() {
+				events, err := r.cp.transactionEvents(ctx, sig.Signature, r.opts.Commitment, r.tables)
+				res <- fetched{events, err}
+			}()
+		}
+	}()
+
+	for _, sig := range signatures {
+		var res fetched
+		select {
+		case next, ok := <-pending:
+			if !ok {
+				return ctx.Err()
+			}
+			res = <-next
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+
+		if res.err != nil {
+			return res.err
+		}
+		for j, evt := range res.events {
+			if !r.opts.Filter.Match(evt) {
+				continue
+			}
+			if err := r.handle(EventUpdate{
+				Signature: sig.Signature,
+				Slot:      sig.Slot,
+				Event:     evt,
+				Index:     j,
+				Finalized: sig.ConfirmationStatus == rpc.ConfirmationStatusFinalized,
+			}); err != nil {
+				return err
+			}
+		}
+
+		r.last = &BackfillCheckpoint{Signature: sig.Signature, Slot: sig.Slot}
+		if r.handled++; r.handled%r.opts.CheckpointEvery == 0 {
+			if err := r.save(); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// signaturePages walks the signatures of opts.Address newer than until back to the oldest
+// one, or to opts.FromSlot. It returns the signature every page is listed before, newest
+// first, the zero one listing from the tip, and the oldest page.
+func (cp *CpAMM) signaturePages(
+	ctx context.Context,
+	until solana.Signature,
+	opts BackfillOpts,
+) ([]solana.Signature, []*rpc.TransactionSignature, error) {
+	var (
+		starts []solana.Signature
+		before solana.Signature
+		oldest []*rpc.TransactionSignature
+	)
+	for {
+		page, err := cp.signaturePage(ctx, before, until, opts)
+		if err != nil {
+			return nil, nil, err
+		}
+		// the last full page went all the way down to until.
+		if len(page) == 0 && len(starts) > 0 {
+			return starts, oldest, nil
+		}
+		starts, oldest = append(starts, before), page
+		if len(page) < opts.PageSize || page[len(page)-1].Slot < opts.FromSlot {
+			return starts, oldest, nil
+		}
+		before = page[len(page)-1].Signature
+	}
+}
+
+// signaturePage lists the signatures of opts.Address older than before and newer than
+// until, newest first, within a slot too.
+func (cp *CpAMM) signaturePage(
+	ctx context.Context,
+	before, until solana.Signature,
+	opts BackfillOpts,
+) ([]*rpc.TransactionSignature, error) {
+	limit := opts.PageSize
+	page, err := cp.conn.GetSignaturesForAddressWithOpts(ctx, opts.Address, &rpc.GetSignaturesForAddressOpts{
+		Limit:      &limit,
+		Before:     before,
+		Until:      until,
+		Commitment: opts.Commitment,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("err listing signatures of %s: %w", opts.Address, err)
+	}
+	return page, nil
+}
+
+// lookupTableCache reads lookup tables for cp_amm.DecodeEvents, once per table
+// unless a transaction uses addresses past the ones read.
+type lookupTableCache struct {
+	conn *rpc.Client
+
+	mu     sync.Mutex
+	tables map[solana.PublicKey]solana.PublicKeySlice
+}
+
+func newLookupTableCache(conn *rpc.Client) *lookupTableCache {
+	return &lookupTableCache{
+		conn:   conn,
+		tables: make(map[solana.PublicKey]solana.PublicKeySlice),
+	}
+}
+
+// getter returns the getAddressTables callback of the transaction of msg. Tables that
+// can't be read anymore, e.g closed ones, fall back to the addresses the transaction loaded.
+func (c *lookupTableCache) getter(
+	ctx context.Context,
+	msg solana.Message,
+	loaded rpc.LoadedAddresses,
+) func([]solana.PublicKey) (map[solana.PublicKey]solana.PublicKeySlice, error) {
+	return func(altAddresses []solana.PublicKey) (map[solana.PublicKey]solana.PublicKeySlice, error) {
+		res := make(map[solana.PublicKey]solana.PublicKeySlice, len(altAddresses))
+		var fallback map[solana.PublicKey]solana.PublicKeySlice
+
+		for _, lookup := range msg.AddressTableLookups {
+			size := 0
+			for _, idx := range append(slices.Clone(lookup.WritableIndexes), lookup.ReadonlyIndexes...) {
+				size = max(size, int(idx)+1)
+			}
+
+			c.mu.Lock()
+			table, ok := c.tables[lookup.AccountKey]
+			c.mu.Unlock()
+			if ok && len(table) >= size {
+				res[lookup.AccountKey] = table
+				continue
+			}
+
+			fetched, err := anchor.AddressTablesFetcher(ctx, c.conn)([]solana.PublicKey{lookup.AccountKey})
+			if err == nil && len(fetched[lookup.AccountKey]) >= size {
+				table = fetched[lookup.AccountKey]
+				c.mu.Lock()
+				c.tables[lookup.AccountKey] = table
+				c.mu.Unlock()
+				res[lookup.AccountKey] = table
+				continue
+			}
+
+			if err == nil {
+				err = errors.New("table is shorter than the transaction lookups")
+			}
+			if fallback == nil {
+				fallback = loadedTables(msg, loaded)
+			}
+			table, ok = fallback[lookup.AccountKey]
+			if !ok {
+				return nil, fmt.Errorf("err resolving address lookup table %s: %w", lookup.AccountKey, err)
+			}
+			res[lookup.AccountKey] = table
+		}
+		return res, nil
+	}
+}
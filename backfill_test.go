@@ -0,0 +1,245 @@
+package dammv2gosdk
+
+import (
+	"context"
+	cp_amm "dammv2GoSDK/generated/cpAmm"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/gagliardetto/solana-go/rpc/jsonrpc"
+)
+
+type fakeHistoryTx struct {
+	signature solana.Signature
+	slot      uint64
+	failed    bool
+	logs      []string
+}
+
+// fakeHistory serves getSignaturesForAddress and getTransaction out of txs, newest first.
+type fakeHistory struct {
+	txs []fakeHistoryTx
+	raw string
+	// lowest is the oldest slot listed.
+	lowest uint64
+	// finalized is the newest finalized slot, the later ones are confirmed.
+	finalized uint64
+}
+
+func (f *fakeHistory) CallForInto(_ context.Context, out any, method string, params []any) error {
+	var res any
+	switch method {
+	case "getSignaturesForAddress":
+		opts := params[1].(rpc.M)
+		limit := *opts["limit"].(*int)
+		before, _ := opts["before"].(solana.Signature)
+		until, _ := opts["until"].(solana.Signature)
+
+		var page []map[string]any
+		started := before.IsZero()
+		for _, tx := range f.txs {
+			if tx.signature == until || len(page) == limit {
+				break
+			}
+			if started {
+				var txErr any
+				if tx.failed {
+					txErr = map[string]any{"InstructionError": []any{0, "InvalidArgument"}}
+				}
+				status := rpc.ConfirmationStatusConfirmed
+				if tx.slot <= f.finalized {
+					status = rpc.ConfirmationStatusFinalized
+				}
+				page = append(page, map[string]any{
+					"signature":          tx.signature.String(),
+					"slot":               tx.slot,
+					"err":                txErr,
+					"confirmationStatus": status,
+				})
+				if f.lowest == 0 || tx.slot < f.lowest {
+					f.lowest = tx.slot
+				}
+			}
+			started = started || tx.signature == before
+		}
+		res = page
+	case "getTransaction":
+		signature := params[0].(solana.Signature)
+		for _, tx := range f.txs {
+			if tx.signature == signature {
+				res = map[string]any{
+					"slot":        tx.slot,
+					"transaction": []string{f.raw, "base64"},
+					"meta": map[string]any{
+						"err":             nil,
+						"fee":             5000,
+						"preBalances":     []uint64{},
+						"postBalances":    []uint64{},
+						"logMessages":     tx.logs,
+						"loadedAddresses": map[string]any{"writable": []string{}, "readonly": []string{}},
+					},
+				}
+			}
+		}
+	default:
+		return errors.New("unexpected method " + method)
+	}
+
+	raw, err := json.Marshal(res)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(raw, out)
+}
+
+func (f *fakeHistory) CallWithCallback(context.Context, string, []any, func(*http.Request, *http.Response) error) error {
+	return errors.New("not implemented")
+}
+
+func (f *fakeHistory) CallBatch(context.Context, jsonrpc.RPCRequests) (jsonrpc.RPCResponses, error) {
+	return nil, errors.New("not implemented")
+}
+
+// newFakeHistory returns the history of a transaction per slot of pool, from newest down to
+// oldest, each emitting a swap whose ActualAmountIn is its slot, except the failed one.
+func newFakeHistory(t *testing.T, pool solana.PublicKey, newest, oldest, failed uint64) *fakeHistory {
+	payer := solana.NewWallet().PublicKey()
+	tx, err := solana.NewTransaction([]solana.Instruction{
+		solana.NewInstruction(CpAMMProgramId, solana.AccountMetaSlice{solana.Meta(payer).WRITE().SIGNER()}, []byte{1}),
+	}, solana.Hash{}, solana.TransactionPayer(payer))
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, err := tx.ToBase64()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fake := &fakeHistory{raw: raw}
+	for slot := newest; slot >= oldest; slot-- {
+		evt := borshBytes(t, cp_amm.EvtSwapEventData{Pool: pool, ActualAmountIn: slot})
+		fake.txs = append(fake.txs, fakeHistoryTx{
+			signature: solana.Signature{byte(slot)},
+			slot:      slot,
+			failed:    slot == failed,
+			logs:      []string{"Program data: " + base64.StdEncoding.EncodeToString(evt)},
+		})
+	}
+	return fake
+}
+
+func TestBackfillResumesFromCheckpoint(t *testing.T) {
+	pool := solana.NewWallet().PublicKey()
+	fake := newFakeHistory(t, pool, 14, 10, 12)
+
+	store, err := NewFileCheckpointStore(filepath.Join(t.TempDir(), "checkpoints.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cp := NewCpAMM(rpc.NewWithCustomRPCClient(fake))
+	opts := BackfillOpts{Address: pool, PageSize: 2, Concurrency: 3, Checkpoints: store}
+
+	var slots []uint64
+	stop := errors.New("stop")
+	err = cp.Backfill(context.Background(), opts, func(update EventUpdate) error {
+		if update.Slot == 13 {
+			return stop
+		}
+		if update.Event.Data.(*cp_amm.EvtSwapEventData).ActualAmountIn != update.Slot {
+			t.Fatalf("event of slot %d handed over for slot %d", update.Event.Data.(*cp_amm.EvtSwapEventData).ActualAmountIn, update.Slot)
+		}
+		slots = append(slots, update.Slot)
+		return nil
+	})
+	if !errors.Is(err, stop) {
+		t.Fatalf("err = %v", err)
+	}
+
+	// reopened as a new run would.
+	store, err = NewFileCheckpointStore(store.path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkpoint, err := store.Load(pool)
+	if err != nil || checkpoint == nil || checkpoint.Slot != 11 {
+		t.Fatalf("checkpoint = %+v err = %v", checkpoint, err)
+	}
+
+	opts.Checkpoints = store
+	if err := cp.Backfill(context.Background(), opts, func(update EventUpdate) error {
+		slots = append(slots, update.Slot)
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []uint64{10, 11, 13, 14}
+	if len(slots) != len(want) {
+		t.Fatalf("slots = %v, want %v", slots, want)
+	}
+	for i := range want {
+		if slots[i] != want[i] {
+			t.Fatalf("slots = %v, want %v", slots, want)
+		}
+	}
+}
+
+func TestBackfillSlotRange(t *testing.T) {
+	pool := solana.NewWallet().PublicKey()
+	fake := newFakeHistory(t, pool, 20, 1, 15)
+	fake.finalized = 14
+	cp := NewCpAMM(rpc.NewWithCustomRPCClient(fake))
+
+	var slots, finalized []uint64
+	if err := cp.Backfill(context.Background(), BackfillOpts{
+		Address:  pool,
+		FromSlot: 13,
+		ToSlot:   17,
+		PageSize: 3,
+	}, func(update EventUpdate) error {
+		slots = append(slots, update.Slot)
+		if update.Finalized {
+			finalized = append(finalized, update.Slot)
+		}
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if want := []uint64{13, 14, 16, 17}; !slices.Equal(slots, want) {
+		t.Fatalf("slots = %v, want %v", slots, want)
+	}
+	if want := []uint64{13, 14}; !slices.Equal(finalized, want) {
+		t.Fatalf("finalized slots = %v, want %v", finalized, want)
+	}
+	// the page reaching slot 13 is the last one listed.
+	if fake.lowest != 12 {
+		t.Fatalf("listed down to slot %d, want 12", fake.lowest)
+	}
+}
+
+func TestBackfillPagesOldestFirst(t *testing.T) {
+	pool := solana.NewWallet().PublicKey()
+	for _, pageSize := range []int{1, 2, 4, 5, 10} {
+		fake := newFakeHistory(t, pool, 10, 1, 0)
+		cp := NewCpAMM(rpc.NewWithCustomRPCClient(fake))
+
+		var slots []uint64
+		if err := cp.Backfill(context.Background(), BackfillOpts{Address: pool, PageSize: pageSize}, func(update EventUpdate) error {
+			slots = append(slots, update.Slot)
+			return nil
+		}); err != nil {
+			t.Fatal(err)
+		}
+		if want := []uint64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}; !slices.Equal(slots, want) {
+			t.Fatalf("page size %d: slots = %v, want %v", pageSize, slots, want)
+		}
+	}
+}
@@ -6,6 +6,7 @@ import (
 	cp_amm "dammv2GoSDK/generated/cpAmm"
 	"dammv2GoSDK/helpers"
 	"dammv2GoSDK/maths"
+	"dammv2GoSDK/txn"
 	"dammv2GoSDK/types"
 	"errors"
 	"fmt"
@@ -27,13 +28,106 @@ var (
 type CpAMM struct {
 	poolAuthority solana.PublicKey
 	conn          *rpc.Client
+	cache         *anchor.AccountCache
+	wsEndpoint    string
+	priorityFee   txn.PriorityFeeEstimator
+	// idempotentATAs skips the ATA lookups, see WithIdempotentATAs.
+	idempotentATAs bool
+	// existingATAs are never looked up nor created, see WithExistingATAs.
+	existingATAs map[solana.PublicKey]bool
+	// atas remembers the associated token accounts found to exist, apart from the
+	// accounts of cache. It is set along with cache.
+	atas *anchor.AccountCache
 }
 
-func NewCpAMM(conn *rpc.Client) *CpAMM {
-	return &CpAMM{
+func NewCpAMM(conn *rpc.Client, opts ...CpAMMOption) *CpAMM {
+	cp := &CpAMM{
 		conn:          conn,
 		poolAuthority: DerivePoolAuthority(),
 	}
+	for _, opt := range opts {
+		opt(cp)
+	}
+	return cp
+}
+
+// withPriorityFee prepends the SetComputeUnitPrice of the WithPriorityFee estimator to ixns.
+func (cp *CpAMM) withPriorityFee(ctx context.Context, ixns []solana.Instruction) ([]solana.Instruction, error) {
+	if cp.priorityFee == nil {
+		return ixns, nil
+	}
+	return txn.WithPriorityFee(ctx, cp.priorityFee, ixns)
+}
+
+// poolStateOrFetch returns state, or the state of pool fetched when it is nil.
+func (cp *CpAMM) poolStateOrFetch(
+	ctx context.Context,
+	pool solana.PublicKey,
+	state *cp_amm.PoolAccount,
+) (*cp_amm.PoolAccount, error) {
+	if state != nil {
+		return state, nil
+	}
+	return cp.FetchPoolState(ctx, pool)
+}
+
+// getOrCreateATAInstruction wraps helpers.GetOrCreateATAInstruction, consulting the known ATAs first.
+// WSOL ATAs are never memoized: the SOL builders close them in their post instructions.
+func (cp *CpAMM) getOrCreateATAInstruction(
+	ctx context.Context,
+	tokenMint, owner, payer solana.PublicKey,
+	tokenProgram solana.PublicKey,
+) (solana.PublicKey, *solana.GenericInstruction, error) {
+	memoize := cp.atas != nil && !tokenMint.Equals(solana.WrappedSol)
+	if cp.idempotentATAs || len(cp.existingATAs) > 0 || memoize {
+		ata, err := helpers.GetAssociatedTokenAddressSync(
+			tokenMint,
+			owner,
+			true,
+			tokenProgram,
+			solana.PublicKey{},
+		)
+		if err != nil {
+			return solana.PublicKey{}, nil, err
+		}
+		if cp.existingATAs[ata] {
+			return ata, nil, nil
+		}
+		if cp.idempotentATAs {
+			return ata, helpers.CreateAssociatedTokenAccountIdempotentInstruction(
+				payer,
+				ata,
+				owner,
+				tokenMint,
+				tokenProgram,
+				solana.PublicKey{},
+			), nil
+		}
+		if memoize {
+			if _, _, ok := cp.atas.Get(ata, 0); ok {
+				return ata, nil, nil
+			}
+		}
+	}
+
+	ata, ix, err := helpers.GetOrCreateATAInstruction(
+		ctx,
+		cp.conn,
+		tokenMint,
+		owner,
+		payer,
+		true,
+		tokenProgram,
+	)
+	if err != nil {
+		return solana.PublicKey{}, nil, err
+	}
+
+	if memoize && ix == nil {
+		cp.atas.Set(ata, struct{}{}, 0)
+	}
+
+	return ata, ix, nil
 }
 
 // prepareTokenAccounts prepares token accounts for a transaction by retrieving or creating ix for creating the associated token accounts.
@@ -59,13 +153,11 @@ func (cp *CpAMM) prepareTokenAccounts(
 
 	go func(p *res, wg *sync.WaitGroup) {
 		defer wg.Done()
-		ata, ix, err := helpers.GetOrCreateATAInstruction(
+		ata, ix, err := cp.getOrCreateATAInstruction(
 			ctx,
-			cp.conn,
 			param.TokenAMint,
 			param.TokenAOwner,
 			param.Payer,
-			true,
 			param.TokenAProgram,
 		)
 		if err != nil {
@@ -78,13 +170,11 @@ func (cp *CpAMM) prepareTokenAccounts(
 
 	go func(p *res, wg *sync.WaitGroup) {
 		defer wg.Done()
-		ata, ix, err := helpers.GetOrCreateATAInstruction(
+		ata, ix, err := cp.getOrCreateATAInstruction(
 			ctx,
-			cp.conn,
 			param.TokenBMint,
 			param.TokenBOwner,
 			param.Payer,
-			true,
 			param.TokenBProgram,
 		)
 		if err != nil {
@@ -583,7 +673,7 @@ func (cp *CpAMM) FetchConfigState(ctx context.Context, config solana.PublicKey)
 	configState, err := anchor.NewPgAccounts(
 		cp.conn,
 		func() *cp_amm.ConfigAccount { return &cp_amm.ConfigAccount{} },
-	).Fetch(
+	).WithCache(cp.cache).Fetch(
 		ctx,
 		config,
 		nil,
@@ -604,7 +694,7 @@ func (cp *CpAMM) FetchPoolState(ctx context.Context, pool solana.PublicKey) (*cp
 	poolState, err := anchor.NewPgAccounts(
 		cp.conn,
 		func() *cp_amm.PoolAccount { return &cp_amm.PoolAccount{} },
-	).Fetch(
+	).WithCache(cp.cache).Fetch(
 		ctx,
 		pool,
 		nil,
@@ -625,7 +715,7 @@ func (cp *CpAMM) FetchPositionState(ctx context.Context, position solana.PublicK
 	positionState, err := anchor.NewPgAccounts(
 		cp.conn,
 		func() *cp_amm.PositionAccount { return &cp_amm.PositionAccount{} },
-	).Fetch(
+	).WithCache(cp.cache).Fetch(
 		ctx,
 		position,
 		nil,
@@ -664,24 +754,16 @@ func (cp *CpAMM) GetAllConfigs(ctx context.Context, config solana.PublicKey) ([]
 	return configState, nil
 }
 
-// GetAllPools retrieves all pool accounts.
-func (cp *CpAMM) GetAllPools(ctx context.Context, pool solana.PublicKey) ([]anchor.ProgramAccount[*cp_amm.PoolAccount], error) {
-	poolState, err := anchor.NewPgAccounts(
-		cp.conn,
-		func() *cp_amm.PoolAccount { return &cp_amm.PoolAccount{} },
-	).All(
-		ctx,
-		CpAMMProgramId,
-		cp_amm.PoolAccountDiscriminator,
-		nil,
-		nil,
-	)
+// GetAllPools retrieves every pool account of the program. GetPoolsByMints, GetPoolsByConfig,
+// GetPoolsByCreator and GetPoolsByPartner only read the pools they are after.
+func (cp *CpAMM) GetAllPools(ctx context.Context) ([]anchor.ProgramAccount[*cp_amm.PoolAccount], error) {
+	poolState, err := cp.scanPools(ctx)
 	if err != nil {
 		return nil, err
 	}
 
 	if len(poolState) == 0 {
-		return nil, fmt.Errorf("pool account: %s not found", pool.String())
+		return nil, errors.New("no pool account found")
 	}
 
 	return poolState, nil
@@ -756,25 +838,31 @@ func (cp *CpAMM) GetPositionsByUser(
 	positionStates, err := anchor.NewPgAccounts(
 		cp.conn,
 		func() *cp_amm.PositionAccount { return &cp_amm.PositionAccount{} },
-	).FetchMultiple(
+	).FetchMultipleResults(
 		ctx,
 		positionAddresses,
 		nil,
+		anchor.FetchModeLenient,
 	)
 	if err != nil {
 		return nil, err
 	}
 
-	positionResult := make([]GetPositionsByUserResult, len(userPositionAccounts))
+	positionResult := make([]GetPositionsByUserResult, 0, len(userPositionAccounts))
 	for idx, account := range userPositionAccounts {
 		positionState := positionStates[idx]
-		if positionState != nil {
-			positionResult = append(positionResult, GetPositionsByUserResult{
-				PositionNftAccount: account.PositionNftAccount,
-				Position:           positionAddresses[idx],
-				PositionState:      positionState,
-			})
+		if !positionState.Exists {
+			// the nft outlived its position, e.g closed positions.
+			continue
 		}
+		if positionState.Err != nil {
+			return nil, fmt.Errorf("err decoding position %s: %w", positionState.PublicKey, positionState.Err)
+		}
+		positionResult = append(positionResult, GetPositionsByUserResult{
+			PositionNftAccount: account.PositionNftAccount,
+			Position:           positionAddresses[idx],
+			PositionState:      positionState.Value,
+		})
 	}
 
 	slices.SortFunc(positionResult, func(a, b GetPositionsByUserResult) int {
@@ -800,28 +888,20 @@ func (cp *CpAMM) GetPositionsByUser(
 	return positionResult, nil
 }
 
-func (cp *CpAMM) GetAllVestingsByPosition(ctx context.Context, position solana.PublicKey) ([]anchor.ProgramAccount[*cp_amm.PositionAccount], error) {
-	positionState, err := anchor.NewPgAccounts(
+// GetAllVestingsByPosition retrieves the vesting accounts attached to position.
+func (cp *CpAMM) GetAllVestingsByPosition(ctx context.Context, position solana.PublicKey) ([]anchor.ProgramAccount[*cp_amm.VestingAccount], error) {
+	return anchor.NewPgAccounts(
 		cp.conn,
-		func() *cp_amm.PositionAccount { return &cp_amm.PositionAccount{} },
+		func() *cp_amm.VestingAccount { return &cp_amm.VestingAccount{} },
 	).All(
 		ctx,
 		CpAMMProgramId,
-		cp_amm.PositionAccountDiscriminator,
+		cp_amm.VestingAccountDiscriminator,
 		[]rpc.RPCFilter{
 			helpers.VestingByPositionFilter(position),
 		},
 		nil,
 	)
-	if err != nil {
-		return nil, err
-	}
-
-	if len(positionState) == 0 {
-		return nil, fmt.Errorf("position account: %s not found", position.String())
-	}
-
-	return positionState, nil
 }
 
 func (cp CpAMM) IsLockedPosition(position *cp_amm.PositionAccount) bool {
@@ -862,7 +942,7 @@ func (cp *CpAMM) IsPoolExist(ctx context.Context, pool solana.PublicKey) bool {
 	out, err := anchor.NewPgAccounts(
 		cp.conn,
 		func() *cp_amm.PoolAccount { return &cp_amm.PoolAccount{} },
-	).Fetch(
+	).WithCache(cp.cache).Fetch(
 		ctx,
 		pool,
 		nil,
@@ -927,15 +1007,17 @@ func GetQuote(param types.GetQuoteParams) types.GetQuoteResult {
 		dynamicFeeParams,
 	)
 
+	aToB := param.PoolState.TokenAMint.Equals(param.InputTokenMint)
+	inputTokenInfo, outputTokenInfo := param.SwapTokenInfos(aToB)
+
 	actualAmountIn := param.InAmount
-	if param.InputTokenInfo != nil {
+	if inputTokenInfo != nil {
 		actualAmountIn = helpers.CalculateTransferFeeExcludedAmount(
 			param.InAmount,
-			param.InputTokenInfo.Mint,
-			param.InputTokenInfo.CurrentEpoch,
+			inputTokenInfo.Mint,
+			inputTokenInfo.CurrentEpoch,
 		).Amount
 	}
-	aToB := param.PoolState.TokenAMint.Equals(param.InputTokenMint)
 
 	out := helpers.GetSwapAmount(
 		actualAmountIn,
@@ -947,11 +1029,11 @@ func GetQuote(param types.GetQuoteParams) types.GetQuoteResult {
 	)
 
 	actualAmountOut := param.InAmount
-	if param.OutputTokenInfo != nil {
+	if outputTokenInfo != nil {
 		actualAmountOut = helpers.CalculateTransferFeeExcludedAmount(
 			param.InAmount,
-			param.OutputTokenInfo.Mint,
-			param.OutputTokenInfo.CurrentEpoch,
+			outputTokenInfo.Mint,
+			outputTokenInfo.CurrentEpoch,
 		).Amount
 	}
 
@@ -978,6 +1060,7 @@ func GetQuoteExactOut(param types.GetQuoteExactOutParams) (types.QuoteExactOutRe
 	if bToA {
 		tradeDirection = types.TradeDirectionBtoA
 	}
+	inputTokenInfo, outputTokenInfo := param.SwapTokenInfos(!bToA)
 
 	currentPoint := param.CurrentSlot
 	if param.PoolState.ActivationType != 0 {
@@ -992,7 +1075,7 @@ func GetQuoteExactOut(param types.GetQuoteExactOutParams) (types.QuoteExactOutRe
 	// }
 
 	actualAmountOut := param.OutAmount
-	if h := param.OutputTokenInfo; h != nil {
+	if h := outputTokenInfo; h != nil {
 		actualAmountOut = helpers.CalculateTransferFeeExcludedAmount(
 			param.OutAmount,
 			h.Mint,
@@ -1014,7 +1097,7 @@ func GetQuoteExactOut(param types.GetQuoteExactOutParams) (types.QuoteExactOutRe
 	}
 
 	actualInputAmount := out.InputAmount
-	if h := param.InputTokenInfo; h != nil {
+	if h := inputTokenInfo; h != nil {
 		actualInputAmount = helpers.CalculateTransferFeeExcludedAmount(
 			out.InputAmount,
 			h.Mint,
@@ -1354,7 +1437,7 @@ func (cp *CpAMM) CreatePool(
 	ixns = append(ixns, currentIx)
 	ixns = append(ixns, postInstruction...)
 
-	return ixns, nil
+	return cp.withPriorityFee(ctx, ixns)
 }
 
 // CreateCustomPool builds a transaction to create a customizable pool.
@@ -1488,6 +1571,15 @@ func (cp *CpAMM) CreateCustomPool(
 	ixns = append(ixns, currentIx)
 	ixns = append(ixns, postInstruction...)
 
+	ixns, err = cp.withPriorityFee(ctx, ixns)
+	if err != nil {
+		return struct {
+			Pool     solana.PublicKey
+			Position solana.PublicKey
+			Ixns     []solana.Instruction
+		}{}, err
+	}
+
 	return struct {
 		Pool     solana.PublicKey
 		Position solana.PublicKey
@@ -1610,6 +1702,15 @@ func (cp *CpAMM) CreateCustomPoolWithDynamicConfig(
 	ixns = append(ixns, currentIx)
 	ixns = append(ixns, postInstruction...)
 
+	ixns, err = cp.withPriorityFee(ctx, ixns)
+	if err != nil {
+		return struct {
+			Pool     solana.PublicKey
+			Position solana.PublicKey
+			Ixns     []solana.Instruction
+		}{}, err
+	}
+
 	return struct {
 		Pool     solana.PublicKey
 		Position solana.PublicKey
@@ -1623,13 +1724,33 @@ func (cp *CpAMM) CreateCustomPoolWithDynamicConfig(
 
 // CreatePosition builds a instructions to create a position.
 func (cp *CpAMM) CreatePosition(
+	ctx context.Context,
 	param types.CreatePositionParams,
 ) (struct {
 	Position          solana.PublicKey
 	PositonNftAccount solana.PublicKey
-	Ix                *cp_amm.Instruction
+	Ixns              []solana.Instruction
 }, error) {
-	return cp.buildCreatePositionInstruction(param)
+	var res struct {
+		Position          solana.PublicKey
+		PositonNftAccount solana.PublicKey
+		Ixns              []solana.Instruction
+	}
+
+	createPosition, err := cp.buildCreatePositionInstruction(param)
+	if err != nil {
+		return res, err
+	}
+
+	ixns, err := cp.withPriorityFee(ctx, []solana.Instruction{createPosition.Ix})
+	if err != nil {
+		return res, err
+	}
+
+	res.Position = createPosition.Position
+	res.PositonNftAccount = createPosition.PositonNftAccount
+	res.Ixns = ixns
+	return res, nil
 }
 
 // AddLiquidity builds instruction to add liquidity to an existing position.
@@ -1730,7 +1851,7 @@ func (cp *CpAMM) AddLiquidity(
 	res = append(res, preInstructions...)
 	res = append(res, addLiquidityInstruction)
 	res = append(res, postInstructions...)
-	return res, nil
+	return cp.withPriorityFee(ctx, res)
 }
 
 // CreatePositionAndAddLiquidity creates a new position and add liquidity to position it in a single transaction.
@@ -1846,7 +1967,7 @@ func (cp *CpAMM) CreatePositionAndAddLiquidity(
 	res = append(res, preInstructions...)
 	res = append(res, addLiquidityInstruction)
 	res = append(res, postInstructions...)
-	return res, nil
+	return cp.withPriorityFee(ctx, res)
 }
 
 // RemoveLiquidity builds instruction to remove liquidity from a position.
@@ -1951,7 +2072,7 @@ func (cp *CpAMM) RemoveLiquidity(
 	ixns = append(ixns, currentIx)
 	ixns = append(ixns, postInstructions...)
 
-	return ixns, nil
+	return cp.withPriorityFee(ctx, ixns)
 }
 
 // RemoveaAllLiquidity builds instruction to remove all liquidity from a position.
@@ -2055,7 +2176,7 @@ func (cp *CpAMM) RemoveALLLiquidity(
 	ixns = append(ixns, currentIx)
 	ixns = append(ixns, postInstructions...)
 
-	return ixns, nil
+	return cp.withPriorityFee(ctx, ixns)
 }
 
 // Swap builds instruction to perform a swap in the pool.
@@ -2187,12 +2308,14 @@ func (cp *CpAMM) Swap(
 	ixns = append(ixns, swapIx)
 	ixns = append(ixns, postInstructions...)
 
-	return ixns, nil
+	return cp.withPriorityFee(ctx, ixns)
 }
 
+// LockPosition builds instructions to lock a position under a vesting schedule.
 func (cp *CpAMM) LockPosition(
+	ctx context.Context,
 	param types.LockPositionParams,
-) (*cp_amm.Instruction, error) {
+) ([]solana.Instruction, error) {
 	// if !param.PeriodFrequency.IsUint64() {
 	// 	return nil, fmt.Errorf("cannot fit PeriodFrequency(%s)  into uint64",
 	// 		param.PeriodFrequency)
@@ -2221,14 +2344,19 @@ func (cp *CpAMM) LockPosition(
 		return nil, fmt.Errorf("err deriving eventAuthPDA: %w", err)
 	}
 
-	return lockPositionPtr.SetEventAuthorityAccount(eventAuthPDA).ValidateAndBuild()
+	lockPositionIx, err := lockPositionPtr.SetEventAuthorityAccount(eventAuthPDA).ValidateAndBuild()
+	if err != nil {
+		return nil, err
+	}
 
+	return cp.withPriorityFee(ctx, []solana.Instruction{lockPositionIx})
 }
 
 // PermanentLockPosition builds a transaction to permanently lock a position.
 func (cp *CpAMM) PermanentLockPosition(
+	ctx context.Context,
 	param types.PermanentLockParams,
-) (*cp_amm.Instruction, error) {
+) ([]solana.Instruction, error) {
 	permanentLockPositionPtr := cp_amm.NewPermanentLockPositionInstruction(
 		param.UnlockedLiquidity,
 		param.Pool,
@@ -2243,14 +2371,25 @@ func (cp *CpAMM) PermanentLockPosition(
 		return nil, fmt.Errorf("err deriving eventAuthPDA: %w", err)
 	}
 
-	return permanentLockPositionPtr.SetEventAuthorityAccount(eventAuthPDA).ValidateAndBuild()
+	permanentLockPositionIx, err := permanentLockPositionPtr.SetEventAuthorityAccount(eventAuthPDA).ValidateAndBuild()
+	if err != nil {
+		return nil, err
+	}
+
+	return cp.withPriorityFee(ctx, []solana.Instruction{permanentLockPositionIx})
 }
 
 // RefreshVesting builds a transaction to refresh vesting status of a position.
 func (cp *CpAMM) RefreshVesting(
+	ctx context.Context,
 	param types.RefreshVestingParams,
-) (*cp_amm.Instruction, error) {
-	return cp.buildRefreshVestingInstruction(param)
+) ([]solana.Instruction, error) {
+	refreshVestingIx, err := cp.buildRefreshVestingInstruction(param)
+	if err != nil {
+		return nil, err
+	}
+
+	return cp.withPriorityFee(ctx, []solana.Instruction{refreshVestingIx})
 }
 
 //	RemoveAllLiquidityAndClosePosition builds instructions to remove all liquidity from a position and close it.
@@ -2353,7 +2492,7 @@ func (cp *CpAMM) RemoveAllLiquidityAndClosePosition(
 	ixns = append(ixns, liquidatePositionInstructions)
 	ixns = append(ixns, postInstructions...)
 
-	return ixns, nil
+	return cp.withPriorityFee(ctx, ixns)
 }
 
 // MergePosition builds instructions to merge liquidity from one position into another.
@@ -2535,13 +2674,14 @@ func (cp *CpAMM) MergePosition(
 		ixns = append(ixns, closeWrappedSOLIx)
 	}
 
-	return slices.Clip(ixns), nil
+	return cp.withPriorityFee(ctx, slices.Clip(ixns))
 }
 
 // UpdateRewardDuration builds instruction to update reward duration.
 func (cp *CpAMM) UpdateRewardDuration(
+	ctx context.Context,
 	param types.UpdateRewardDurationParams,
-) (*cp_amm.Instruction, error) {
+) ([]solana.Instruction, error) {
 	// if !param.NewDuration.IsUint64() {
 	// 	return nil, fmt.Errorf("cannot fit NewDuration(%s)  into uint64",
 	// 		param.NewDuration)
@@ -2560,14 +2700,20 @@ func (cp *CpAMM) UpdateRewardDuration(
 		return nil, fmt.Errorf("err deriving eventAuthPDA: %w", err)
 	}
 
-	return updateRewardDurationPtr.SetEventAuthorityAccount(eventAuthPDA).
+	updateRewardDurationIx, err := updateRewardDurationPtr.SetEventAuthorityAccount(eventAuthPDA).
 		ValidateAndBuild()
+	if err != nil {
+		return nil, err
+	}
+
+	return cp.withPriorityFee(ctx, []solana.Instruction{updateRewardDurationIx})
 }
 
 // UpdateRewardDuration builds instruction tto update reward funder address.
 func (cp *CpAMM) UpdateRewardFunder(
+	ctx context.Context,
 	param types.UpdateRewardFunderParams,
-) (*cp_amm.Instruction, error) {
+) ([]solana.Instruction, error) {
 	updateRewardFunderPtr := cp_amm.NewUpdateRewardFunderInstruction(
 		param.RewardIndex,
 		param.NewFunder,
@@ -2581,8 +2727,13 @@ func (cp *CpAMM) UpdateRewardFunder(
 		return nil, fmt.Errorf("err deriving eventAuthPDA: %w", err)
 	}
 
-	return updateRewardFunderPtr.SetEventAuthorityAccount(eventAuthPDA).
+	updateRewardFunderIx, err := updateRewardFunderPtr.SetEventAuthorityAccount(eventAuthPDA).
 		ValidateAndBuild()
+	if err != nil {
+		return nil, err
+	}
+
+	return cp.withPriorityFee(ctx, []solana.Instruction{updateRewardFunderIx})
 }
 
 // fundReward builds instructions to fund rewards in a pool.
@@ -2597,7 +2748,7 @@ func (cp *CpAMM) fundReward(
 	// 		param.Amount)
 	// }
 
-	poolState, err := cp.FetchPoolState(ctx, param.Pool)
+	poolState, err := cp.poolStateOrFetch(ctx, param.Pool, param.PoolState)
 	if err != nil {
 		return nil, err
 	}
@@ -2606,20 +2757,20 @@ func (cp *CpAMM) fundReward(
 
 	rewardInfo := poolState.RewardInfos[param.RewardIndex]
 	tokenProgram := helpers.GetTokenProgram(param.RewardIndex)
-	funderTokenAccount, createFunderTokenAccountIx, err := helpers.GetOrCreateATAInstruction(
+	funderTokenAccount, createFunderTokenAccountIx, err := cp.getOrCreateATAInstruction(
 		ctx,
-		cp.conn,
 		rewardInfo.Mint,
 		param.Funder,
 		param.Funder,
-		true,
 		tokenProgram,
 	)
 	if err != nil {
 		return nil, err
 	}
 
-	preInstructions = append(preInstructions, createFunderTokenAccountIx)
+	if createFunderTokenAccountIx != nil {
+		preInstructions = append(preInstructions, createFunderTokenAccountIx)
+	}
 
 	// TODO: check case reward mint is wSOL && carryForward is true => total amount > amount
 	if rewardInfo.Mint.Equals(solana.WrappedSol) ||
@@ -2667,20 +2818,18 @@ func (cp *CpAMM) WithdrawIneligibleReward(
 	ctx context.Context,
 	param types.WithdrawIneligibleRewardParams,
 ) ([]solana.Instruction, error) {
-	poolState, err := cp.FetchPoolState(ctx, param.Pool)
+	poolState, err := cp.poolStateOrFetch(ctx, param.Pool, param.PoolState)
 	if err != nil {
 		return nil, err
 	}
 
 	rewardInfo := poolState.RewardInfos[param.RewardIndex]
 	tokenProgram := helpers.GetTokenProgram(rewardInfo.RewardTokenFlag)
-	funderTokenAccount, createFunderTokenAccountIx, err := helpers.GetOrCreateATAInstruction(
+	funderTokenAccount, createFunderTokenAccountIx, err := cp.getOrCreateATAInstruction(
 		ctx,
-		cp.conn,
 		rewardInfo.Mint,
 		param.Funder,
 		param.Funder,
-		true,
 		tokenProgram,
 	)
 	if err != nil {
@@ -2723,9 +2872,12 @@ func (cp *CpAMM) WithdrawIneligibleReward(
 	}
 
 	ixns := make([]solana.Instruction, 0, 1+1+len(postInstructions))
-	ixns = append(ixns, createFunderTokenAccountIx, currentIx)
+	if createFunderTokenAccountIx != nil {
+		ixns = append(ixns, createFunderTokenAccountIx)
+	}
+	ixns = append(ixns, currentIx)
 	ixns = append(ixns, postInstructions...)
-	return ixns, nil
+	return cp.withPriorityFee(ctx, ixns)
 }
 
 func (cp *CpAMM) ClaimPartnerFee(
@@ -2739,7 +2891,7 @@ func (cp *CpAMM) ClaimPartnerFee(
 	// 			param.MaxAmountA, param.MaxAmountB)
 	// }
 
-	poolState, err := cp.FetchPoolState(ctx, param.Pool)
+	poolState, err := cp.poolStateOrFetch(ctx, param.Pool, param.PoolState)
 	if err != nil {
 		return nil, err
 	}
@@ -2802,7 +2954,7 @@ func (cp *CpAMM) ClaimPartnerFee(
 	ixns = append(ixns, currentIx)
 	ixns = append(ixns, out.PostInstructions...)
 
-	return ixns, nil
+	return cp.withPriorityFee(ctx, ixns)
 }
 
 // ClaimPositionFee builds instructions to claim position fee rewards.
@@ -2859,8 +3011,7 @@ func (cp *CpAMM) ClaimPositionFee(
 	ixns = append(ixns, claimPositionFeeIx)
 	ixns = append(ixns, out.PostInstructions...)
 
-	return ixns, nil
-
+	return cp.withPriorityFee(ctx, ixns)
 }
 
 // ClaimPositionFee2 builds instructions to claim position fee rewards.
@@ -2939,7 +3090,7 @@ func (cp *CpAMM) ClaimPositionFee2(
 	ixns = append(ixns, claimPositionFeeIx)
 	ixns = append(ixns, postInstructions...)
 
-	return ixns, nil
+	return cp.withPriorityFee(ctx, ixns)
 }
 
 // ClaimReward builds instruction to claim reward from a position.
@@ -2956,13 +3107,11 @@ func (cp *CpAMM) ClaimReward(
 		feePayer = param.FeePayer
 	}
 
-	userTokenAccount, createUserTokenAccountIx, err := helpers.GetOrCreateATAInstruction(
+	userTokenAccount, createUserTokenAccountIx, err := cp.getOrCreateATAInstruction(
 		ctx,
-		cp.conn,
 		rewardInfo.Mint,
 		param.User,
 		feePayer,
-		true,
 		tokenProgram,
 	)
 	if err != nil {
@@ -3007,14 +3156,19 @@ func (cp *CpAMM) ClaimReward(
 	}
 
 	ixns := make([]solana.Instruction, 0, 1+1+1)
-	ixns = append(ixns, createUserTokenAccountIx, currentIx)
+	if createUserTokenAccountIx != nil {
+		ixns = append(ixns, createUserTokenAccountIx)
+	}
+	ixns = append(ixns, currentIx)
 	ixns = append(ixns, postInstructions...)
-	return ixns, nil
+	return cp.withPriorityFee(ctx, ixns)
 }
 
+// SplitPosition builds instructions to split part of a position into a second one.
 func (cp *CpAMM) SplitPosition(
+	ctx context.Context,
 	param types.SplitPositionParams,
-) (*cp_amm.Instruction, error) {
+) ([]solana.Instruction, error) {
 	splitPositionPtr := cp_amm.NewSplitPositionInstruction(
 		cp_amm.SplitPositionParameters{
 			PermanentLockedLiquidityPercentage: uint8(param.PermanentLockedLiquidityPercentage),
@@ -3039,6 +3193,11 @@ func (cp *CpAMM) SplitPosition(
 		return nil, fmt.Errorf("err deriving eventAuthPDA: %w", err)
 	}
 
-	return splitPositionPtr.SetEventAuthorityAccount(eventAuthPDA).
+	splitPositionIx, err := splitPositionPtr.SetEventAuthorityAccount(eventAuthPDA).
 		ValidateAndBuild()
+	if err != nil {
+		return nil, err
+	}
+
+	return cp.withPriorityFee(ctx, []solana.Instruction{splitPositionIx})
 }
@@ -1168,7 +1168,7 @@ func TestCreatePosition(t *testing.T) {
 			PositionNft: userPositionNft.PublicKey(),
 		}
 
-		createPositionIx, err := ammInstance.CreatePosition(createPositionParams)
+		createPositionIx, err := ammInstance.CreatePosition(context.Background(), createPositionParams)
 		if err != nil {
 			t.Fatalf("\nerr from CreatePosition:\n %s", err.Error())
 		}
@@ -1176,7 +1176,7 @@ func TestCreatePosition(t *testing.T) {
 		if _, err = testUtils.ExecuteTransaction(
 			conn,
 			wsClient,
-			[]solana.Instruction{createPositionIx.Ix},
+			createPositionIx.Ixns,
 			actors.User,
 			userPositionNft,
 		); err != nil {
@@ -1486,7 +1486,7 @@ func TestLockPosition(t *testing.T) {
 			NumberOfPeriod:       numberOfPeriod,
 		}
 
-		lockPositionIx, err := ammInstance.LockPosition(lockPositionParams)
+		lockPositionIx, err := ammInstance.LockPosition(context.Background(), lockPositionParams)
 		if err != nil {
 			t.Fatalf("\nerr from LockPosition:\n %s", err.Error())
 		}
@@ -1494,7 +1494,7 @@ func TestLockPosition(t *testing.T) {
 		if _, err = testUtils.ExecuteTransaction(
 			conn,
 			wsClient,
-			[]solana.Instruction{lockPositionIx},
+			lockPositionIx,
 			actors.PoolCreator,
 			vestingAccount,
 		); err != nil {
@@ -1657,7 +1657,7 @@ func TestMergePosition(t *testing.T) {
 
 	// create position 2
 	secondPositionNft := solana.NewWallet().PrivateKey
-	createPosition2Result, err := ammInstance.CreatePosition(types.CreatePositionParams{
+	createPosition2Result, err := ammInstance.CreatePosition(context.Background(), types.CreatePositionParams{
 		Owner:       actors.PoolCreator.PublicKey(),
 		Payer:       actors.PoolCreator.PublicKey(),
 		Pool:        createCustomPoolResult.Pool,
@@ -1670,7 +1670,7 @@ func TestMergePosition(t *testing.T) {
 	if _, err = testUtils.ExecuteTransaction(
 		conn,
 		wsClient,
-		[]solana.Instruction{createPosition2Result.Ix},
+		createPosition2Result.Ixns,
 		actors.PoolCreator,
 		secondPositionNft,
 	); err != nil {
@@ -1907,6 +1907,7 @@ func TestPermanentLockPosition(t *testing.T) {
 
 		// permanant lock position
 		lockPositionIx, err := ammInstance.PermanentLockPosition(
+			context.Background(),
 			types.PermanentLockParams{
 				Owner:              actors.PoolCreator.PublicKey(),
 				Position:           createCustomPoolResult.Position,
@@ -1922,7 +1923,7 @@ func TestPermanentLockPosition(t *testing.T) {
 		if _, err = testUtils.ExecuteTransaction(
 			conn,
 			wsClient,
-			[]solana.Instruction{lockPositionIx},
+			lockPositionIx,
 			actors.PoolCreator,
 		); err != nil {
 			testUtils.PrettyPrintTxnErrorLog(t, err)
@@ -2230,7 +2231,7 @@ func TestSplitPosition(t *testing.T) {
 			PositionNft: secondPositionNft.PublicKey(),
 		}
 
-		createPositionIx, err := ammInstance.CreatePosition(createPositionParams)
+		createPositionIx, err := ammInstance.CreatePosition(context.Background(), createPositionParams)
 		if err != nil {
 			t.Fatalf("err from CreatePosition:\n %s", err.Error())
 		}
@@ -2238,7 +2239,7 @@ func TestSplitPosition(t *testing.T) {
 		if _, err = testUtils.ExecuteTransaction(
 			conn,
 			wsClient,
-			[]solana.Instruction{createPositionIx.Ix},
+			createPositionIx.Ixns,
 			actors.User,
 			secondPositionNft,
 		); err != nil {
@@ -2269,7 +2270,7 @@ func TestSplitPosition(t *testing.T) {
 			Reward1Percentage:           50,
 		}
 
-		splitPositionIx, err := ammInstance.SplitPosition(splitPositionParams)
+		splitPositionIx, err := ammInstance.SplitPosition(context.Background(), splitPositionParams)
 		if err != nil {
 			t.Fatalf("err from CreatePosition:\n %s", err.Error())
 		}
@@ -2277,7 +2278,7 @@ func TestSplitPosition(t *testing.T) {
 		if _, err = testUtils.ExecuteTransaction(
 			conn,
 			wsClient,
-			[]solana.Instruction{splitPositionIx},
+			splitPositionIx,
 			actors.PoolCreator,
 			actors.User,
 		); err != nil {
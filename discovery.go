@@ -0,0 +1,249 @@
+package dammv2gosdk
+
+import (
+	"context"
+	"dammv2GoSDK/anchor"
+	cp_amm "dammv2GoSDK/generated/cpAmm"
+	"dammv2GoSDK/helpers"
+	"dammv2GoSDK/types"
+	"fmt"
+	"slices"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+	"golang.org/x/sync/errgroup"
+)
+
+// GetPoolsByMints returns every pool trading tokenAMint against tokenBMint, in either order.
+// The customizable pool address and the pool address of every opts.Configs entry are
+// checked first, the getProgramAccounts scan then picks up pools created from other configs.
+func (cp *CpAMM) GetPoolsByMints(
+	ctx context.Context,
+	tokenAMint, tokenBMint solana.PublicKey,
+	opts types.GetPoolsOpts,
+) ([]anchor.ProgramAccount[*cp_amm.PoolAccount], error) {
+	derived := make([]solana.PublicKey, 0, 1+len(opts.Configs))
+	derived = append(derived, DeriveCustomizablePoolAddress(tokenAMint, tokenBMint))
+	for _, config := range opts.Configs {
+		derived = append(derived, DerivePoolAddress(config, tokenAMint, tokenBMint))
+	}
+
+	pools, err := cp.fetchDerivedPools(ctx, derived)
+	if err != nil {
+		return nil, err
+	}
+
+	if !opts.DerivedOnly {
+		var (
+			g, gCtx = errgroup.WithContext(ctx)
+			scanned [2][]anchor.ProgramAccount[*cp_amm.PoolAccount]
+		)
+		for i, mints := range [2][2]solana.PublicKey{
+			{tokenAMint, tokenBMint},
+			{tokenBMint, tokenAMint},
+		} {
+			g.Go(func() error {
+				out, err := cp.scanPools(
+					gCtx,
+					helpers.PoolByTokenAMintFilter(mints[0]),
+					helpers.PoolByTokenBMintFilter(mints[1]),
+				)
+				scanned[i] = out
+				return err
+			})
+		}
+		if err := g.Wait(); err != nil {
+			return nil, err
+		}
+
+		pools = mergePools(pools, scanned[0], scanned[1])
+	}
+
+	SortPools(pools, opts.SortBy, opts.Descending)
+	return pools, nil
+}
+
+// GetPoolsByConfig returns the pools created from config.
+//
+// The pool account does not store its config, so a pool belongs to config when its
+// address is the one DerivePoolAddress gives for config and its mints. With
+// opts.TokenPairs set only those derived addresses are fetched, otherwise the mints
+// of every pool are scanned and the matching pools are fetched in full.
+func (cp *CpAMM) GetPoolsByConfig(
+	ctx context.Context,
+	config solana.PublicKey,
+	opts types.GetPoolsByConfigOpts,
+) ([]anchor.ProgramAccount[*cp_amm.PoolAccount], error) {
+	var candidates []solana.PublicKey
+
+	if len(opts.TokenPairs) > 0 || opts.DerivedOnly {
+		candidates = make([]solana.PublicKey, 0, len(opts.TokenPairs))
+		for _, pair := range opts.TokenPairs {
+			candidates = append(candidates, DerivePoolAddress(config, pair[0], pair[1]))
+		}
+	} else {
+		// only the two mints are needed to derive the pool address.
+		mints, err := anchor.NewPgAccounts(
+			cp.conn,
+			func() *cp_amm.PoolAccount { return &cp_amm.PoolAccount{} },
+		).AllSlices(
+			ctx,
+			CpAMMProgramId,
+			cp_amm.PoolAccountDiscriminator,
+			nil,
+			poolTokenAMintRange,
+			poolTokenBMintRange,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, v := range mints {
+			tokenAMint, _ := v.Field(poolTokenAMintRange)
+			tokenBMint, _ := v.Field(poolTokenBMintRange)
+			if DerivePoolAddress(
+				config,
+				solana.PublicKeyFromBytes(tokenAMint),
+				solana.PublicKeyFromBytes(tokenBMint),
+			).Equals(v.PublicKey) {
+				candidates = append(candidates, v.PublicKey)
+			}
+		}
+	}
+
+	pools, err := cp.fetchDerivedPools(ctx, candidates)
+	if err != nil {
+		return nil, err
+	}
+	// a pair derives the same address in either order.
+	pools = mergePools(pools)
+
+	SortPools(pools, opts.SortBy, opts.Descending)
+	return pools, nil
+}
+
+// GetPoolsByCreator returns the pools created by creator.
+func (cp *CpAMM) GetPoolsByCreator(
+	ctx context.Context,
+	creator solana.PublicKey,
+	opts types.GetPoolsOpts,
+) ([]anchor.ProgramAccount[*cp_amm.PoolAccount], error) {
+	pools, err := cp.scanPools(ctx, helpers.PoolByCreatorFilter(creator))
+	if err != nil {
+		return nil, err
+	}
+
+	SortPools(pools, opts.SortBy, opts.Descending)
+	return pools, nil
+}
+
+// GetPoolsByPartner returns the pools whose partner is partner.
+func (cp *CpAMM) GetPoolsByPartner(
+	ctx context.Context,
+	partner solana.PublicKey,
+	opts types.GetPoolsOpts,
+) ([]anchor.ProgramAccount[*cp_amm.PoolAccount], error) {
+	pools, err := cp.scanPools(ctx, helpers.PoolByPartnerFilter(partner))
+	if err != nil {
+		return nil, err
+	}
+
+	SortPools(pools, opts.SortBy, opts.Descending)
+	return pools, nil
+}
+
+// SortPools orders pools in place by liquidity or sqrt price, ascending unless descending is set.
+// Pools with equal keys keep their relative order.
+func SortPools(
+	pools []anchor.ProgramAccount[*cp_amm.PoolAccount],
+	sortBy types.PoolSortBy,
+	descending bool,
+) {
+	var key func(*cp_amm.PoolAccount) [2]uint64
+	switch sortBy {
+	case types.PoolSortByLiquidity:
+		key = func(p *cp_amm.PoolAccount) [2]uint64 { return [2]uint64{p.Liquidity.Hi, p.Liquidity.Lo} }
+	case types.PoolSortBySqrtPrice:
+		key = func(p *cp_amm.PoolAccount) [2]uint64 { return [2]uint64{p.SqrtPrice.Hi, p.SqrtPrice.Lo} }
+	default:
+		return
+	}
+
+	slices.SortStableFunc(pools, func(a, b anchor.ProgramAccount[*cp_amm.PoolAccount]) int {
+		ka, kb := key(a.Account), key(b.Account)
+		c := slices.Compare(ka[:], kb[:])
+		if descending {
+			return -c
+		}
+		return c
+	})
+}
+
+func (cp *CpAMM) scanPools(
+	ctx context.Context,
+	filters ...rpc.RPCFilter,
+) ([]anchor.ProgramAccount[*cp_amm.PoolAccount], error) {
+	return anchor.NewPgAccounts(
+		cp.conn,
+		func() *cp_amm.PoolAccount { return &cp_amm.PoolAccount{} },
+	).All(
+		ctx,
+		CpAMMProgramId,
+		cp_amm.PoolAccountDiscriminator,
+		filters,
+		nil,
+	)
+}
+
+// fetchDerivedPools fetches addresses, skipping the ones with no pool account behind them.
+func (cp *CpAMM) fetchDerivedPools(
+	ctx context.Context,
+	addresses []solana.PublicKey,
+) ([]anchor.ProgramAccount[*cp_amm.PoolAccount], error) {
+	if len(addresses) == 0 {
+		return nil, nil
+	}
+
+	states, err := anchor.NewPgAccounts(
+		cp.conn,
+		func() *cp_amm.PoolAccount { return &cp_amm.PoolAccount{} },
+	).WithDiscriminator(cp_amm.PoolAccountDiscriminator).
+		FetchMultipleResults(ctx, addresses, nil, anchor.FetchModeLenient)
+	if err != nil {
+		return nil, fmt.Errorf("err fetching derived pools: %w", err)
+	}
+
+	pools := make([]anchor.ProgramAccount[*cp_amm.PoolAccount], 0, len(addresses))
+	for _, state := range states {
+		if !state.Exists {
+			continue
+		}
+		if state.Err != nil {
+			return nil, fmt.Errorf("err decoding pool %s: %w", state.PublicKey, state.Err)
+		}
+		pools = append(pools, anchor.ProgramAccount[*cp_amm.PoolAccount]{
+			PublicKey: state.PublicKey,
+			Account:   state.Value,
+		})
+	}
+
+	return pools, nil
+}
+
+// mergePools concatenates lists, keeping the first occurrence of every pool.
+func mergePools(lists ...[]anchor.ProgramAccount[*cp_amm.PoolAccount]) []anchor.ProgramAccount[*cp_amm.PoolAccount] {
+	var (
+		seen = make(map[solana.PublicKey]struct{})
+		res  []anchor.ProgramAccount[*cp_amm.PoolAccount]
+	)
+	for _, list := range lists {
+		for _, pool := range list {
+			if _, ok := seen[pool.PublicKey]; ok {
+				continue
+			}
+			seen[pool.PublicKey] = struct{}{}
+			res = append(res, pool)
+		}
+	}
+	return res
+}
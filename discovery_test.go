@@ -0,0 +1,198 @@
+package dammv2gosdk
+
+import (
+	"context"
+	"dammv2GoSDK/anchor"
+	cp_amm "dammv2GoSDK/generated/cpAmm"
+	"dammv2GoSDK/internal/test/rpctest"
+	"dammv2GoSDK/types"
+	"slices"
+	"testing"
+
+	ag_binary "github.com/gagliardetto/binary"
+	"github.com/gagliardetto/solana-go"
+)
+
+func poolKeys(pools []anchor.ProgramAccount[*cp_amm.PoolAccount]) []solana.PublicKey {
+	keys := make([]solana.PublicKey, len(pools))
+	for i, pool := range pools {
+		keys[i] = pool.PublicKey
+	}
+	return keys
+}
+
+func TestPoolDiscovery(t *testing.T) {
+	ctx := context.Background()
+	var (
+		mintA, mintB = solana.NewWallet().PublicKey(), solana.NewWallet().PublicKey()
+		config       = solana.NewWallet().PublicKey()
+		creator      = solana.NewWallet().PublicKey()
+		partner      = solana.NewWallet().PublicKey()
+
+		customizable = DeriveCustomizablePoolAddress(mintA, mintB)
+		fromConfig   = DerivePoolAddress(config, mintA, mintB)
+		// pools the derived addresses can't find: the mints swapped, and another pair.
+		swapped = solana.NewWallet().PublicKey()
+		other   = solana.NewWallet().PublicKey()
+	)
+
+	accounts := &rpctest.Accounts{
+		Slot:  1,
+		Owner: CpAMMProgramId,
+		Data: map[solana.PublicKey][]byte{
+			customizable: borshBytes(t, &cp_amm.PoolAccount{
+				TokenAMint: mintA, TokenBMint: mintB, Liquidity: ag_binary.Uint128{Lo: 5},
+			}),
+			fromConfig: borshBytes(t, &cp_amm.PoolAccount{
+				TokenAMint: mintA, TokenBMint: mintB, Liquidity: ag_binary.Uint128{Lo: 1},
+			}),
+			swapped: borshBytes(t, &cp_amm.PoolAccount{
+				TokenAMint: mintB, TokenBMint: mintA, Liquidity: ag_binary.Uint128{Lo: 3},
+				Creator: creator, Partner: partner,
+			}),
+			other: borshBytes(t, &cp_amm.PoolAccount{
+				TokenAMint: solana.NewWallet().PublicKey(), TokenBMint: mintB, Liquidity: ag_binary.Uint128{Hi: 1},
+				Creator: creator,
+			}),
+			// a position of the pool, which the discriminator filter leaves out.
+			solana.NewWallet().PublicKey(): borshBytes(t, &cp_amm.PositionAccount{Pool: customizable}),
+		},
+	}
+	cp := NewCpAMM(rpctest.NewClient(accounts.Handle))
+
+	byLiquidity := types.GetPoolsOpts{SortBy: types.PoolSortByLiquidity, Descending: true}
+	derivedOnly := byLiquidity
+	derivedOnly.Configs = []solana.PublicKey{config}
+	derivedOnly.DerivedOnly = true
+
+	for _, tt := range []struct {
+		name string
+		get  func() ([]anchor.ProgramAccount[*cp_amm.PoolAccount], error)
+		want []solana.PublicKey
+	}{
+		{
+			name: "by mints",
+			get: func() ([]anchor.ProgramAccount[*cp_amm.PoolAccount], error) {
+				return cp.GetPoolsByMints(ctx, mintA, mintB, byLiquidity)
+			},
+			want: []solana.PublicKey{customizable, swapped, fromConfig},
+		},
+		{
+			name: "by mints in the other order",
+			get: func() ([]anchor.ProgramAccount[*cp_amm.PoolAccount], error) {
+				return cp.GetPoolsByMints(ctx, mintB, mintA, byLiquidity)
+			},
+			want: []solana.PublicKey{customizable, swapped, fromConfig},
+		},
+		{
+			name: "by mints, derived only",
+			get: func() ([]anchor.ProgramAccount[*cp_amm.PoolAccount], error) {
+				return cp.GetPoolsByMints(ctx, mintA, mintB, derivedOnly)
+			},
+			want: []solana.PublicKey{customizable, fromConfig},
+		},
+		{
+			name: "by config",
+			get: func() ([]anchor.ProgramAccount[*cp_amm.PoolAccount], error) {
+				return cp.GetPoolsByConfig(ctx, config, types.GetPoolsByConfigOpts{})
+			},
+			want: []solana.PublicKey{fromConfig},
+		},
+		{
+			name: "by config, derived from token pairs",
+			get: func() ([]anchor.ProgramAccount[*cp_amm.PoolAccount], error) {
+				return cp.GetPoolsByConfig(ctx, config, types.GetPoolsByConfigOpts{
+					TokenPairs: [][2]solana.PublicKey{{mintA, mintB}, {mintB, mintA}},
+				})
+			},
+			want: []solana.PublicKey{fromConfig},
+		},
+		{
+			name: "by creator",
+			get: func() ([]anchor.ProgramAccount[*cp_amm.PoolAccount], error) {
+				return cp.GetPoolsByCreator(ctx, creator, byLiquidity)
+			},
+			want: []solana.PublicKey{other, swapped},
+		},
+		{
+			name: "by partner",
+			get: func() ([]anchor.ProgramAccount[*cp_amm.PoolAccount], error) {
+				return cp.GetPoolsByPartner(ctx, partner, byLiquidity)
+			},
+			want: []solana.PublicKey{swapped},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			pools, err := tt.get()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := poolKeys(pools); !slices.Equal(got, tt.want) {
+				t.Fatalf("pools = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSortPools(t *testing.T) {
+	keys := make([]solana.PublicKey, 4)
+	for i := range keys {
+		keys[i] = solana.NewWallet().PublicKey()
+	}
+	pool := func(i int, liquidity, sqrtPrice ag_binary.Uint128) anchor.ProgramAccount[*cp_amm.PoolAccount] {
+		return anchor.ProgramAccount[*cp_amm.PoolAccount]{
+			PublicKey: keys[i],
+			Account:   &cp_amm.PoolAccount{Liquidity: liquidity, SqrtPrice: sqrtPrice},
+		}
+	}
+	pools := []anchor.ProgramAccount[*cp_amm.PoolAccount]{
+		pool(0, ag_binary.Uint128{Hi: 1}, ag_binary.Uint128{Lo: 2}),
+		pool(1, ag_binary.Uint128{Lo: 9}, ag_binary.Uint128{Lo: 1}),
+		pool(2, ag_binary.Uint128{Hi: 1}, ag_binary.Uint128{Lo: 3}),
+		pool(3, ag_binary.Uint128{Lo: 1}, ag_binary.Uint128{Hi: 1}),
+	}
+
+	for _, tt := range []struct {
+		name       string
+		sortBy     types.PoolSortBy
+		descending bool
+		want       []solana.PublicKey
+	}{
+		{"none", types.PoolSortByNone, true, keys},
+		{"liquidity", types.PoolSortByLiquidity, false, []solana.PublicKey{keys[3], keys[1], keys[0], keys[2]}},
+		{"liquidity descending, ties kept in order", types.PoolSortByLiquidity, true, []solana.PublicKey{keys[0], keys[2], keys[1], keys[3]}},
+		{"sqrt price", types.PoolSortBySqrtPrice, false, []solana.PublicKey{keys[1], keys[0], keys[2], keys[3]}},
+		{"sqrt price descending", types.PoolSortBySqrtPrice, true, []solana.PublicKey{keys[3], keys[2], keys[0], keys[1]}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			sorted := slices.Clone(pools)
+			SortPools(sorted, tt.sortBy, tt.descending)
+			if got := poolKeys(sorted); !slices.Equal(got, tt.want) {
+				t.Fatalf("pools = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMergePools(t *testing.T) {
+	a, b, c := solana.NewWallet().PublicKey(), solana.NewWallet().PublicKey(), solana.NewWallet().PublicKey()
+	pool := func(key solana.PublicKey, liquidity uint64) anchor.ProgramAccount[*cp_amm.PoolAccount] {
+		return anchor.ProgramAccount[*cp_amm.PoolAccount]{
+			PublicKey: key,
+			Account:   &cp_amm.PoolAccount{Liquidity: ag_binary.Uint128{Lo: liquidity}},
+		}
+	}
+
+	merged := mergePools(
+		[]anchor.ProgramAccount[*cp_amm.PoolAccount]{pool(a, 1), pool(b, 1)},
+		nil,
+		[]anchor.ProgramAccount[*cp_amm.PoolAccount]{pool(b, 2), pool(c, 2), pool(a, 2)},
+	)
+	if got := poolKeys(merged); !slices.Equal(got, []solana.PublicKey{a, b, c}) {
+		t.Fatalf("pools = %v", got)
+	}
+	// the first occurrence is kept.
+	if merged[1].Account.Liquidity.Lo != 1 {
+		t.Fatalf("pool %s taken from the later list", b)
+	}
+}
@@ -0,0 +1,201 @@
+package dammv2gosdk
+
+import (
+	"context"
+	cp_amm "dammv2GoSDK/generated/cpAmm"
+	"dammv2GoSDK/helpers"
+	"encoding/base64"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+// EventFilter selects the events SubscribeEvents delivers. Every non-empty field
+// must match, an empty filter matches every event.
+type EventFilter struct {
+	// Pools the event refers to.
+	Pools []solana.PublicKey
+	// Positions the event refers to, events about no position never match.
+	Positions []solana.PublicKey
+	// Names of the events, as cp_amm.Event.Name e.g "EvtSwap".
+	Names []string
+}
+
+// Match reports whether evt passes the filter.
+func (f EventFilter) Match(evt *cp_amm.Event) bool {
+	if len(f.Names) > 0 && !slices.Contains(f.Names, evt.Name) {
+		return false
+	}
+	if len(f.Pools) > 0 {
+		pool, ok := helpers.EventPool(evt.Data)
+		if !ok || !slices.Contains(f.Pools, pool) {
+			return false
+		}
+	}
+	if len(f.Positions) > 0 {
+		if !slices.ContainsFunc(eventPositions(evt.Data), func(position solana.PublicKey) bool {
+			return slices.Contains(f.Positions, position)
+		}) {
+			return false
+		}
+	}
+	return true
+}
+
+// mentions is the account logs are subscribed for: the only pool or position
+// the filter selects, the program otherwise.
+func (f EventFilter) mentions() solana.PublicKey {
+	switch {
+	case len(f.Pools) == 1:
+		return f.Pools[0]
+	case len(f.Positions) == 1:
+		return f.Positions[0]
+	}
+	return CpAMMProgramId
+}
+
+// EventUpdate is a pool program event, or a gap notice.
+type EventUpdate struct {
+	Signature solana.Signature
+	Slot      uint64
+	Event     *cp_amm.Event
+	// Index of Event among the events of its transaction, filtered out ones included.
+	Index int
+	// Finalized reports the transaction was finalized when read. Backfill sets it out of
+	// the confirmation status of the signature.
+	Finalized bool
+	// Gap, set without an Event, reports the subscription reconnected: events of slots
+	// Gap.FromSlot to Gap.ToSlot, both included, may have been missed and should be
+	// backfilled, deduplicating by signature.
+	Gap *SlotGap
+}
+
+// SlotGap is a range of slots a subscription may have missed events in.
+type SlotGap struct {
+	FromSlot uint64
+	ToSlot   uint64
+}
+
+// decodeLogEvents decodes the "Program data:" lines the pool program logged.
+//
+// Events the program emits through a self invocation (anchor's emit_cpi) are not
+// logged, and truncated logs may lose some: complete is false when logs alone can't
+// be trusted to hold every event and the transaction has to be read instead.
+func decodeLogEvents(logs []string) (events []*cp_amm.Event, complete bool, err error) {
+	const (
+		invokeSuffix = " invoke ["
+		dataPrefix   = "Program data: "
+	)
+	programPrefix := "Program " + CpAMMProgramId.String()
+
+	complete = true
+	var stack []bool // whether each invoked program is the pool program
+	inPool := func() bool { return len(stack) > 0 && stack[len(stack)-1] }
+
+	for _, line := range logs {
+		switch {
+		case strings.HasPrefix(line, dataPrefix):
+			if !inPool() {
+				continue
+			}
+			data, err := base64.StdEncoding.DecodeString(line[len(dataPrefix):])
+			if err != nil {
+				return nil, false, fmt.Errorf("err decoding event data: %w", err)
+			}
+			evt, err := cp_amm.DecodeEvent(data)
+			if err != nil {
+				return nil, false, fmt.Errorf("err decoding event: %w", err)
+			}
+			if evt != nil {
+				events = append(events, evt)
+			}
+		case strings.HasPrefix(line, "Program ") && strings.Contains(line, invokeSuffix):
+			isPool := strings.HasPrefix(line, programPrefix+invokeSuffix)
+			if isPool && inPool() {
+				complete = false
+			}
+			stack = append(stack, isPool)
+		case strings.HasPrefix(line, "Program ") &&
+			(strings.HasSuffix(line, " success") || strings.Contains(line, " failed: ")):
+			if len(stack) > 0 {
+				stack = stack[:len(stack)-1]
+			}
+		case line == "Log truncated":
+			complete = false
+		}
+	}
+	return events, complete, nil
+}
+
+// transactionEvents reads the transaction of signature and decodes every event it emitted.
+// Lookup tables are read through tables when set, taken from the addresses the transaction
+// loaded otherwise.
+func (cp *CpAMM) transactionEvents(
+	ctx context.Context,
+	signature solana.Signature,
+	commitment rpc.CommitmentType,
+	tables *lookupTableCache,
+) ([]*cp_amm.Event, error) {
+	// transactions aren't served at processed commitment.
+	if commitment == rpc.CommitmentProcessed {
+		commitment = rpc.CommitmentConfirmed
+	}
+
+	var maxVersion uint64 = 0
+	txResult, err := cp.conn.GetTransaction(ctx, signature, &rpc.GetTransactionOpts{
+		Encoding:                       solana.EncodingBase64,
+		Commitment:                     commitment,
+		MaxSupportedTransactionVersion: &maxVersion,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("err fetching transaction %s: %w", signature, err)
+	}
+	if txResult.Meta == nil {
+		return nil, fmt.Errorf("transaction %s has no meta", signature)
+	}
+
+	tx, err := txResult.Transaction.GetTransaction()
+	if err != nil {
+		return nil, fmt.Errorf("err decoding transaction %s: %w", signature, err)
+	}
+	getAddressTables := func([]solana.PublicKey) (map[solana.PublicKey]solana.PublicKeySlice, error) {
+		return loadedTables(tx.Message, txResult.Meta.LoadedAddresses), nil
+	}
+	if tables != nil {
+		getAddressTables = tables.getter(ctx, tx.Message, txResult.Meta.LoadedAddresses)
+	}
+
+	events, err := cp_amm.DecodeEvents(txResult, CpAMMProgramId, getAddressTables)
+	if err != nil {
+		return nil, fmt.Errorf("err decoding events of %s: %w", signature, err)
+	}
+	return events, nil
+}
+
+// eventPositions returns the positions evt refers to.
+func eventPositions(data cp_amm.EventData) []solana.PublicKey {
+	switch data := data.(type) {
+	case *cp_amm.EvtAddLiquidityEventData:
+		return []solana.PublicKey{data.Position}
+	case *cp_amm.EvtRemoveLiquidityEventData:
+		return []solana.PublicKey{data.Position}
+	case *cp_amm.EvtClaimPositionFeeEventData:
+		return []solana.PublicKey{data.Position}
+	case *cp_amm.EvtClaimRewardEventData:
+		return []solana.PublicKey{data.Position}
+	case *cp_amm.EvtCreatePositionEventData:
+		return []solana.PublicKey{data.Position}
+	case *cp_amm.EvtClosePositionEventData:
+		return []solana.PublicKey{data.Position}
+	case *cp_amm.EvtLockPositionEventData:
+		return []solana.PublicKey{data.Position}
+	case *cp_amm.EvtPermanentLockPositionEventData:
+		return []solana.PublicKey{data.Position}
+	case *cp_amm.EvtSplitPositionEventData:
+		return []solana.PublicKey{data.FirstPosition, data.SecondPosition}
+	}
+	return nil
+}
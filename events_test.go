@@ -0,0 +1,76 @@
+package dammv2gosdk
+
+import (
+	cp_amm "dammv2GoSDK/generated/cpAmm"
+	"encoding/base64"
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+)
+
+func TestDecodeLogEvents(t *testing.T) {
+	var (
+		pool     = solana.NewWallet().PublicKey()
+		position = solana.NewWallet().PublicKey()
+		other    = solana.NewWallet().PublicKey()
+		program  = "Program " + CpAMMProgramId.String()
+	)
+	swap := "Program data: " + base64.StdEncoding.EncodeToString(borshBytes(t, cp_amm.EvtSwapEventData{Pool: pool}))
+	add := "Program data: " + base64.StdEncoding.EncodeToString(borshBytes(t, cp_amm.EvtAddLiquidityEventData{Pool: pool, Position: position}))
+
+	logs := []string{
+		program + " invoke [1]",
+		"Program log: Instruction: Swap",
+		swap,
+		"Program " + other.String() + " invoke [2]",
+		// logged by another program, ignored.
+		add,
+		"Program " + other.String() + " success",
+		program + " success",
+		program + " invoke [1]",
+		add,
+		program + " success",
+	}
+
+	events, complete, err := decodeLogEvents(logs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !complete || len(events) != 2 || events[0].Name != "EvtSwap" || events[1].Name != "EvtAddLiquidity" {
+		t.Fatalf("complete = %v events = %+v", complete, events)
+	}
+
+	tests := []struct {
+		name   string
+		filter EventFilter
+		want   []bool
+	}{
+		{name: "empty", want: []bool{true, true}},
+		{name: "pool", filter: EventFilter{Pools: []solana.PublicKey{pool}}, want: []bool{true, true}},
+		{name: "other pool", filter: EventFilter{Pools: []solana.PublicKey{other}}, want: []bool{false, false}},
+		{name: "position", filter: EventFilter{Positions: []solana.PublicKey{position}}, want: []bool{false, true}},
+		{name: "name", filter: EventFilter{Pools: []solana.PublicKey{pool}, Names: []string{"EvtSwap"}}, want: []bool{true, false}},
+	}
+	for _, tt := range tests {
+		for i, evt := range events {
+			if got := tt.filter.Match(evt); got != tt.want[i] {
+				t.Errorf("%s: Match(%s) = %v", tt.name, evt.Name, got)
+			}
+		}
+	}
+
+	// events emitted through a self invocation aren't logged.
+	_, complete, err = decodeLogEvents([]string{
+		program + " invoke [1]",
+		program + " invoke [2]",
+		program + " success",
+		program + " success",
+	})
+	if err != nil || complete {
+		t.Fatalf("complete = %v err = %v", complete, err)
+	}
+	_, complete, _ = decodeLogEvents([]string{program + " invoke [1]", "Log truncated"})
+	if complete {
+		t.Fatal("truncated logs are not complete")
+	}
+}
@@ -0,0 +1,768 @@
+package dammv2gosdk
+
+import (
+	"bytes"
+	"context"
+	"dammv2GoSDK/anchor"
+	cp_amm "dammv2GoSDK/generated/cpAmm"
+	"dammv2GoSDK/helpers"
+	"dammv2GoSDK/types"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"slices"
+	"strconv"
+	"strings"
+
+	ag_binary "github.com/gagliardetto/binary"
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/programs/token"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+// eventIxTag prefixes the self-invoked instructions anchor emits events through.
+var eventIxTag = []byte{228, 69, 165, 46, 81, 203, 154, 29}
+
+// ExplainSignature fetches the transaction of signature and explains it, see ExplainTransactionResult.
+func (cp *CpAMM) ExplainSignature(
+	ctx context.Context,
+	signature solana.Signature,
+	opts types.ExplainOpts,
+) (*types.TransactionExplanation, error) {
+	if opts.Commitment == "" {
+		opts.Commitment = rpc.CommitmentConfirmed
+	}
+
+	var maxVersion uint64 = 0
+	txResult, err := cp.conn.GetTransaction(ctx, signature, &rpc.GetTransactionOpts{
+		Encoding:                       solana.EncodingBase64,
+		Commitment:                     opts.Commitment,
+		MaxSupportedTransactionVersion: &maxVersion,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("err fetching transaction %s: %w", signature, err)
+	}
+
+	return cp.ExplainTransactionResult(ctx, txResult, opts)
+}
+
+// ExplainTransactionResult explains a confirmed transaction: its pool program instructions,
+// inner ones included, and one action per event it emitted, e.g
+// "Swapped 10.5 USDC → 0.03 SOL on pool X, fees: ...". The pools, positions and mints
+// the events refer to are read to resolve the tokens and their decimals.
+//
+// Lookup table accounts are resolved from the addresses the transaction loaded, so
+// tables closed or extended since are no issue.
+func (cp *CpAMM) ExplainTransactionResult(
+	ctx context.Context,
+	txResult *rpc.GetTransactionResult,
+	opts types.ExplainOpts,
+) (*types.TransactionExplanation, error) {
+	if txResult.Meta == nil {
+		return nil, fmt.Errorf("transaction has no meta, see ExplainTransaction")
+	}
+
+	tx, err := txResult.Transaction.GetTransaction()
+	if err != nil {
+		return nil, fmt.Errorf("err decoding transaction: %w", err)
+	}
+
+	tables := loadedTables(tx.Message, txResult.Meta.LoadedAddresses)
+	e := newExplainer(cp, opts)
+	res, err := e.explainInstructions(tx, tables, txResult.Meta.InnerInstructions)
+	if err != nil {
+		return nil, err
+	}
+
+	events, err := cp_amm.DecodeEvents(
+		txResult,
+		CpAMMProgramId,
+		func([]solana.PublicKey) (map[solana.PublicKey]solana.PublicKeySlice, error) {
+			return tables, nil
+		},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("err decoding events: %w", err)
+	}
+
+	res.Slot = txResult.Slot
+	if txResult.BlockTime != nil {
+		res.BlockTime = int64(*txResult.BlockTime)
+	}
+	res.Executed = true
+	if txResult.Meta.Err != nil {
+		res.Error = anchor.NewTransactionError(txResult.Meta.Err, CpAMMProgramId, &tx.Message, txResult.Meta.LogMessages).Error()
+	}
+
+	if err := e.load(ctx, eventAccounts(events)); err != nil {
+		return nil, err
+	}
+	for _, evt := range events {
+		res.Actions = append(res.Actions, e.eventAction(evt))
+	}
+
+	return res, nil
+}
+
+// ExplainTransaction explains a transaction that may not have been sent, e.g one handed
+// over for signing, from its instructions alone: actions tell what it asks for, like
+// "Swap 10.5 USDC for at least 0.03 SOL on pool X", rather than what it did.
+func (cp *CpAMM) ExplainTransaction(
+	ctx context.Context,
+	tx *solana.Transaction,
+	opts types.ExplainOpts,
+) (*types.TransactionExplanation, error) {
+	if opts.Commitment == "" {
+		opts.Commitment = rpc.CommitmentConfirmed
+	}
+
+	var tables map[solana.PublicKey]solana.PublicKeySlice
+	if lookups := tx.Message.AddressTableLookups; len(lookups) > 0 {
+		var err error
+		tables, err = anchor.AddressTablesFetcher(ctx, cp.conn)(lookups.GetTableIDs())
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	e := newExplainer(cp, opts)
+	res, err := e.explainInstructions(tx, tables, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var accounts explainAccounts
+	for _, ix := range e.decoded {
+		accounts.add(instructionAccounts(ix))
+	}
+	if err := e.load(ctx, accounts); err != nil {
+		return nil, err
+	}
+	for _, ix := range e.decoded {
+		if ix.inner {
+			continue
+		}
+		if action, ok := e.instructionAction(ix.Instruction); ok {
+			res.Actions = append(res.Actions, action)
+		}
+	}
+
+	return res, nil
+}
+
+// WriteExplanationText renders explanation for humans: the actions, one per line,
+// then the instructions with their accounts.
+func WriteExplanationText(w io.Writer, explanation *types.TransactionExplanation) error {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "Transaction %s", explanation.Signature)
+	if explanation.Slot != 0 {
+		fmt.Fprintf(&b, " (slot %d)", explanation.Slot)
+	}
+	if !explanation.Executed {
+		b.WriteString(" (not executed)")
+	}
+	fmt.Fprintf(&b, "\nFee payer: %s\n", explanation.FeePayer)
+	if explanation.Error != "" {
+		fmt.Fprintf(&b, "Failed: %s\n", explanation.Error)
+	}
+
+	b.WriteString("Actions:\n")
+	for _, action := range explanation.Actions {
+		fmt.Fprintf(&b, "  - %s\n", action.Summary)
+	}
+
+	b.WriteString("Instructions:\n")
+	for _, ix := range explanation.Instructions {
+		indent := "  "
+		if ix.Inner {
+			indent = "      "
+		}
+		fmt.Fprintf(&b, "%s#%d %s\n", indent, ix.Index, ix.Name)
+		for _, account := range ix.Accounts {
+			var flags []string
+			if account.Writable {
+				flags = append(flags, "writable")
+			}
+			if account.Signer {
+				flags = append(flags, "signer")
+			}
+			if account.LookupTable {
+				flags = append(flags, "lookup table")
+			}
+			fmt.Fprintf(&b, "%s    %s", indent, account.Address)
+			if len(flags) > 0 {
+				fmt.Fprintf(&b, " (%s)", strings.Join(flags, ", "))
+			}
+			b.WriteString("\n")
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// WriteExplanationJSON renders explanation as indented JSON.
+func WriteExplanationJSON(w io.Writer, explanation *types.TransactionExplanation) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(explanation)
+}
+
+// FormatTokenAmount renders amount in whole tokens followed by its symbol, e.g "10.5 USDC".
+func FormatTokenAmount(amount types.TokenAmount) string {
+	raw := strconv.FormatUint(amount.Amount, 10)
+	if amount.Decimals > 0 {
+		if pad := int(amount.Decimals) + 1 - len(raw); pad > 0 {
+			raw = strings.Repeat("0", pad) + raw
+		}
+		whole, frac := raw[:len(raw)-int(amount.Decimals)], strings.TrimRight(raw[len(raw)-int(amount.Decimals):], "0")
+		raw = whole
+		if frac != "" {
+			raw += "." + frac
+		}
+	}
+	return raw + " " + amount.Symbol
+}
+
+// decodedInstruction is a pool program instruction of the explained transaction.
+type decodedInstruction struct {
+	*cp_amm.Instruction
+	inner bool
+}
+
+// explainer resolves the accounts the instructions and events of a transaction refer to.
+type explainer struct {
+	cp   *CpAMM
+	opts types.ExplainOpts
+
+	decoded   []decodedInstruction
+	pools     map[solana.PublicKey]*cp_amm.PoolAccount
+	positions map[solana.PublicKey]*cp_amm.PositionAccount
+	decimals  map[solana.PublicKey]uint8
+}
+
+func newExplainer(cp *CpAMM, opts types.ExplainOpts) *explainer {
+	if opts.Commitment == "" {
+		opts.Commitment = rpc.CommitmentConfirmed
+	}
+	return &explainer{
+		cp:        cp,
+		opts:      opts,
+		pools:     make(map[solana.PublicKey]*cp_amm.PoolAccount),
+		positions: make(map[solana.PublicKey]*cp_amm.PositionAccount),
+		decimals:  make(map[solana.PublicKey]uint8),
+	}
+}
+
+// explainInstructions lists the pool program instructions of tx, each top-level one
+// followed by the pool program instructions it invoked.
+func (e *explainer) explainInstructions(
+	tx *solana.Transaction,
+	tables map[solana.PublicKey]solana.PublicKeySlice,
+	inner []rpc.InnerInstruction,
+) (*types.TransactionExplanation, error) {
+	// a copy, resolving the lookups appends to the account keys.
+	message := tx.Message
+	message.AccountKeys = slices.Clone(tx.Message.AccountKeys)
+	static := len(message.AccountKeys)
+	if len(message.AddressTableLookups) > 0 {
+		if err := message.SetAddressTables(tables); err != nil {
+			return nil, err
+		}
+		if err := message.ResolveLookups(); err != nil {
+			return nil, fmt.Errorf("err resolving address lookup tables: %w", err)
+		}
+	}
+
+	res := &types.TransactionExplanation{
+		Instructions: []types.ExplainedInstruction{},
+		Actions:      []types.ExplainedAction{},
+	}
+	if len(tx.Signatures) > 0 {
+		res.Signature = tx.Signatures[0]
+	}
+	if len(message.AccountKeys) > 0 {
+		res.FeePayer = message.AccountKeys[0]
+	}
+
+	innerByIndex := make(map[int][]solana.CompiledInstruction, len(inner))
+	for _, group := range inner {
+		innerByIndex[int(group.Index)] = append(innerByIndex[int(group.Index)], group.Instructions...)
+	}
+
+	explain := func(index int, isInner bool, ix solana.CompiledInstruction) error {
+		program, err := message.Program(ix.ProgramIDIndex)
+		if err != nil {
+			return err
+		}
+		if !program.Equals(CpAMMProgramId) || bytes.HasPrefix(ix.Data, eventIxTag) {
+			return nil
+		}
+
+		metas, err := ix.ResolveInstructionAccounts(&message)
+		if err != nil {
+			return err
+		}
+		explained := types.ExplainedInstruction{
+			Index:    index,
+			Inner:    isInner,
+			Name:     "Unknown",
+			Accounts: make([]types.ExplainedAccount, len(metas)),
+		}
+		for i, meta := range metas {
+			explained.Accounts[i] = types.ExplainedAccount{
+				Address:     meta.PublicKey,
+				Writable:    meta.IsWritable,
+				Signer:      meta.IsSigner,
+				LookupTable: int(ix.Accounts[i]) >= static,
+			}
+		}
+
+		// instructions of other versions of the program are listed, but left undecoded.
+		if decoded, err := decodePoolInstruction(metas, ix.Data); err == nil {
+			explained.Name = cp_amm.InstructionIDToName(decoded.TypeID)
+			e.decoded = append(e.decoded, decodedInstruction{Instruction: decoded, inner: isInner})
+		}
+		res.Instructions = append(res.Instructions, explained)
+		return nil
+	}
+
+	for i, ix := range message.Instructions {
+		if err := explain(i, false, ix); err != nil {
+			return nil, err
+		}
+		for _, innerIx := range innerByIndex[i] {
+			if err := explain(i, true, innerIx); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return res, nil
+}
+
+func decodePoolInstruction(accounts []*solana.AccountMeta, data []byte) (*cp_amm.Instruction, error) {
+	inst := new(cp_amm.Instruction)
+	if err := ag_binary.NewBorshDecoder(data).Decode(inst); err != nil {
+		return nil, err
+	}
+	if settable, ok := inst.Impl.(solana.AccountsSettable); ok {
+		if err := settable.SetAccounts(accounts); err != nil {
+			return nil, err
+		}
+	}
+	return inst, nil
+}
+
+// loadedTables rebuilds the part of the lookup tables msg uses from the addresses the
+// transaction loaded, as reported in its meta.
+func loadedTables(msg solana.Message, loaded rpc.LoadedAddresses) map[solana.PublicKey]solana.PublicKeySlice {
+	tables := make(map[solana.PublicKey]solana.PublicKeySlice, len(msg.AddressTableLookups))
+	var writable, readonly int
+	for _, lookup := range msg.AddressTableLookups {
+		size := 0
+		for _, idx := range append(slices.Clone(lookup.WritableIndexes), lookup.ReadonlyIndexes...) {
+			size = max(size, int(idx)+1)
+		}
+
+		table := make(solana.PublicKeySlice, size)
+		for _, idx := range lookup.WritableIndexes {
+			if writable < len(loaded.Writable) {
+				table[idx] = loaded.Writable[writable]
+				writable++
+			}
+		}
+		for _, idx := range lookup.ReadonlyIndexes {
+			if readonly < len(loaded.ReadOnly) {
+				table[idx] = loaded.ReadOnly[readonly]
+				readonly++
+			}
+		}
+		tables[lookup.AccountKey] = table
+	}
+	return tables
+}
+
+// explainAccounts are the accounts to read to explain a transaction.
+type explainAccounts struct {
+	pools, positions, mints []solana.PublicKey
+}
+
+func (a *explainAccounts) add(other explainAccounts) {
+	a.pools = append(a.pools, other.pools...)
+	a.positions = append(a.positions, other.positions...)
+	a.mints = append(a.mints, other.mints...)
+}
+
+// load reads the pools, positions and mints of accounts, along with the mints of the pools.
+// Accounts that no longer exist are left out, the explanation then lacks what they tell.
+func (e *explainer) load(ctx context.Context, accounts explainAccounts) error {
+	opts := &rpc.GetMultipleAccountsOpts{Commitment: e.opts.Commitment}
+
+	pools, err := anchor.NewPgAccounts(
+		e.cp.conn,
+		func() *cp_amm.PoolAccount { return &cp_amm.PoolAccount{} },
+	).WithDiscriminator(cp_amm.PoolAccountDiscriminator).
+		FetchMultipleResults(ctx, uniqueKeys(accounts.pools), opts, anchor.FetchModeLenient)
+	if err != nil {
+		return fmt.Errorf("err fetching pools: %w", err)
+	}
+	mints := slices.Clone(accounts.mints)
+	for _, pool := range pools {
+		if pool.Ok() {
+			e.pools[pool.PublicKey] = pool.Value
+			mints = append(mints, pool.Value.TokenAMint, pool.Value.TokenBMint)
+		}
+	}
+
+	positions, err := anchor.NewPgAccounts(
+		e.cp.conn,
+		func() *cp_amm.PositionAccount { return &cp_amm.PositionAccount{} },
+	).WithDiscriminator(cp_amm.PositionAccountDiscriminator).
+		FetchMultipleResults(ctx, uniqueKeys(accounts.positions), opts, anchor.FetchModeLenient)
+	if err != nil {
+		return fmt.Errorf("err fetching positions: %w", err)
+	}
+	for _, position := range positions {
+		if position.Ok() {
+			e.positions[position.PublicKey] = position.Value
+		}
+	}
+
+	mintResults, err := anchor.NewPgAccounts(
+		e.cp.conn,
+		func() *token.Mint { return &token.Mint{} },
+	).FetchMultipleResults(ctx, uniqueKeys(mints), opts, anchor.FetchModeLenient)
+	if err != nil {
+		return fmt.Errorf("err fetching mints: %w", err)
+	}
+	for _, mint := range mintResults {
+		if mint.Ok() {
+			e.decimals[mint.PublicKey] = mint.Value.Decimals
+		}
+	}
+
+	return nil
+}
+
+func uniqueKeys(keys []solana.PublicKey) []solana.PublicKey {
+	res := make([]solana.PublicKey, 0, len(keys))
+	for _, key := range keys {
+		if !key.IsZero() && !slices.Contains(res, key) {
+			res = append(res, key)
+		}
+	}
+	return res
+}
+
+func (e *explainer) amount(mint solana.PublicKey, amount uint64) types.TokenAmount {
+	return types.TokenAmount{
+		Mint:     mint,
+		Amount:   amount,
+		Decimals: e.decimals[mint],
+		Symbol:   e.symbol(mint),
+	}
+}
+
+func (e *explainer) symbol(mint solana.PublicKey) string {
+	if symbol, ok := e.opts.Symbols[mint]; ok {
+		return symbol
+	}
+	if mint.Equals(solana.WrappedSol) {
+		return "SOL"
+	}
+	address := mint.String()
+	return address[:4] + "…" + address[len(address)-4:]
+}
+
+// pair formats the token a and b amounts of pool, e.g "10 USDC + 0.5 SOL".
+func (e *explainer) pair(pool solana.PublicKey, amountA, amountB uint64) ([]types.TokenAmount, string) {
+	state, ok := e.pools[pool]
+	if !ok {
+		return nil, fmt.Sprintf("%d token a + %d token b units", amountA, amountB)
+	}
+	amounts := []types.TokenAmount{
+		e.amount(state.TokenAMint, amountA),
+		e.amount(state.TokenBMint, amountB),
+	}
+	return amounts, FormatTokenAmount(amounts[0]) + " + " + FormatTokenAmount(amounts[1])
+}
+
+// lockedPercentage is the share of the position liquidity liquidity stands for, zero when unknown.
+func (e *explainer) lockedPercentage(position solana.PublicKey, liquidity *big.Int) float64 {
+	state, ok := e.positions[position]
+	if !ok {
+		return 0
+	}
+	total := new(big.Int).Add(state.UnlockedLiquidity.BigInt(), state.VestedLiquidity.BigInt())
+	total.Add(total, state.PermanentLockedLiquidity.BigInt())
+	if total.Sign() == 0 {
+		return 0
+	}
+	pct, _ := new(big.Rat).SetFrac(new(big.Int).Mul(liquidity, big.NewInt(100)), total).Float64()
+	return pct
+}
+
+func vestingLiquidity(cliffUnlock, perPeriod ag_binary.Uint128, periods uint16) *big.Int {
+	res := new(big.Int).Mul(perPeriod.BigInt(), big.NewInt(int64(periods)))
+	return res.Add(res, cliffUnlock.BigInt())
+}
+
+func formatLocked(pct float64, liquidity *big.Int) string {
+	if pct == 0 {
+		return liquidity.String() + " liquidity"
+	}
+	return strconv.FormatFloat(pct, 'f', -1, 64) + "% liquidity"
+}
+
+// eventAccounts lists the pools and positions events refer to.
+func eventAccounts(events []*cp_amm.Event) explainAccounts {
+	var res explainAccounts
+	for _, evt := range events {
+		switch data := evt.Data.(type) {
+		case *cp_amm.EvtLockPositionEventData:
+			res.pools = append(res.pools, data.Pool)
+			res.positions = append(res.positions, data.Position)
+		case *cp_amm.EvtPermanentLockPositionEventData:
+			res.pools = append(res.pools, data.Pool)
+			res.positions = append(res.positions, data.Position)
+		case *cp_amm.EvtClaimRewardEventData:
+			res.pools = append(res.pools, data.Pool)
+			res.mints = append(res.mints, data.MintReward)
+		case *cp_amm.EvtFundRewardEventData:
+			res.pools = append(res.pools, data.Pool)
+			res.mints = append(res.mints, data.MintReward)
+		default:
+			if pool, ok := helpers.EventPool(evt.Data); ok {
+				res.pools = append(res.pools, pool)
+			}
+		}
+	}
+	return res
+}
+
+// eventAction explains what evt tells about the transaction.
+func (e *explainer) eventAction(evt *cp_amm.Event) types.ExplainedAction {
+	action := types.ExplainedAction{Kind: strings.TrimPrefix(evt.Name, "Evt")}
+	if pool, ok := helpers.EventPool(evt.Data); ok {
+		action.Pool = pool
+	}
+
+	switch data := evt.Data.(type) {
+	case *cp_amm.EvtSwapEventData:
+		state, ok := e.pools[data.Pool]
+		if !ok {
+			action.Summary = fmt.Sprintf("Swapped %d units for %d units on pool %s",
+				data.ActualAmountIn, data.SwapResult.OutputAmount, data.Pool)
+			break
+		}
+		inMint, outMint := state.TokenAMint, state.TokenBMint
+		bToA := types.TradeDirection(data.TradeDirection) == types.TradeDirectionBtoA
+		if bToA {
+			inMint, outMint = outMint, inMint
+		}
+		feeMint := state.TokenBMint
+		if helpers.GetFeeMode(types.CollectFeeMode(state.CollectFeeMode), bToA).FeesOnTokenA {
+			feeMint = state.TokenAMint
+		}
+
+		action.Amounts = []types.TokenAmount{
+			e.amount(inMint, data.ActualAmountIn),
+			e.amount(outMint, data.SwapResult.OutputAmount),
+		}
+		action.Fees = &types.SwapFees{
+			Lp:       e.amount(feeMint, data.SwapResult.LpFee),
+			Protocol: e.amount(feeMint, data.SwapResult.ProtocolFee),
+			Partner:  e.amount(feeMint, data.SwapResult.PartnerFee),
+			Referral: e.amount(feeMint, data.SwapResult.ReferralFee),
+		}
+		action.Summary = fmt.Sprintf("Swapped %s → %s on pool %s, fees: lp %s / protocol %s / partner %s / referral %s",
+			FormatTokenAmount(action.Amounts[0]), FormatTokenAmount(action.Amounts[1]), data.Pool,
+			FormatTokenAmount(action.Fees.Lp), FormatTokenAmount(action.Fees.Protocol),
+			FormatTokenAmount(action.Fees.Partner), FormatTokenAmount(action.Fees.Referral))
+
+	case *cp_amm.EvtAddLiquidityEventData:
+		action.Position = &data.Position
+		var pair string
+		action.Amounts, pair = e.pair(data.Pool, data.TokenAAmount, data.TokenBAmount)
+		action.Summary = fmt.Sprintf("Added %s to position %s on pool %s", pair, data.Position, data.Pool)
+
+	case *cp_amm.EvtRemoveLiquidityEventData:
+		action.Position = &data.Position
+		var pair string
+		action.Amounts, pair = e.pair(data.Pool, data.TokenAAmount, data.TokenBAmount)
+		action.Summary = fmt.Sprintf("Removed %s from position %s on pool %s", pair, data.Position, data.Pool)
+
+	case *cp_amm.EvtClaimPositionFeeEventData:
+		action.Position = &data.Position
+		var pair string
+		action.Amounts, pair = e.pair(data.Pool, data.FeeAClaimed, data.FeeBClaimed)
+		action.Summary = fmt.Sprintf("Claimed fees %s from position %s on pool %s", pair, data.Position, data.Pool)
+
+	case *cp_amm.EvtClaimPartnerFeeEventData:
+		var pair string
+		action.Amounts, pair = e.pair(data.Pool, data.TokenAAmount, data.TokenBAmount)
+		action.Summary = fmt.Sprintf("Claimed partner fees %s on pool %s", pair, data.Pool)
+
+	case *cp_amm.EvtClaimProtocolFeeEventData:
+		var pair string
+		action.Amounts, pair = e.pair(data.Pool, data.TokenAAmount, data.TokenBAmount)
+		action.Summary = fmt.Sprintf("Claimed protocol fees %s on pool %s", pair, data.Pool)
+
+	case *cp_amm.EvtClaimRewardEventData:
+		action.Pool, action.Position = data.Pool, &data.Position
+		action.Amounts = []types.TokenAmount{e.amount(data.MintReward, data.TotalReward)}
+		action.Summary = fmt.Sprintf("Claimed %s of reward %d from position %s on pool %s",
+			FormatTokenAmount(action.Amounts[0]), data.RewardIndex, data.Position, data.Pool)
+
+	case *cp_amm.EvtFundRewardEventData:
+		action.Pool = data.Pool
+		action.Amounts = []types.TokenAmount{e.amount(data.MintReward, data.Amount)}
+		action.Summary = fmt.Sprintf("Funded reward %d of pool %s with %s",
+			data.RewardIndex, data.Pool, FormatTokenAmount(action.Amounts[0]))
+
+	case *cp_amm.EvtLockPositionEventData:
+		action.Pool, action.Position = data.Pool, &data.Position
+		liquidity := vestingLiquidity(data.CliffUnlockLiquidity, data.LiquidityPerPeriod, data.NumberOfPeriod)
+		action.LockedPercentage = e.lockedPercentage(data.Position, liquidity)
+		action.Summary = fmt.Sprintf("Locked %s of position %s with vesting: cliff at %d, then %d periods every %d",
+			formatLocked(action.LockedPercentage, liquidity), data.Position,
+			data.CliffPoint, data.NumberOfPeriod, data.PeriodFrequency)
+
+	case *cp_amm.EvtPermanentLockPositionEventData:
+		action.Pool, action.Position = data.Pool, &data.Position
+		liquidity := data.LockLiquidityAmount.BigInt()
+		action.LockedPercentage = e.lockedPercentage(data.Position, liquidity)
+		action.Summary = fmt.Sprintf("Permanently locked %s of position %s",
+			formatLocked(action.LockedPercentage, liquidity), data.Position)
+
+	case *cp_amm.EvtCreatePositionEventData:
+		action.Position = &data.Position
+		action.Summary = fmt.Sprintf("Created position %s on pool %s for %s", data.Position, data.Pool, data.Owner)
+
+	case *cp_amm.EvtClosePositionEventData:
+		action.Position = &data.Position
+		action.Summary = fmt.Sprintf("Closed position %s on pool %s", data.Position, data.Pool)
+
+	case *cp_amm.EvtInitializePoolEventData:
+		a, b := e.amount(data.TokenAMint, data.TokenAAmount), e.amount(data.TokenBMint, data.TokenBAmount)
+		action.Amounts = []types.TokenAmount{a, b}
+		action.Summary = fmt.Sprintf("Created pool %s with %s + %s", data.Pool, FormatTokenAmount(a), FormatTokenAmount(b))
+
+	case *cp_amm.EvtSplitPositionEventData:
+		action.Position = &data.FirstPosition
+		action.Summary = fmt.Sprintf("Split position %s into %s on pool %s", data.FirstPosition, data.SecondPosition, data.Pool)
+
+	default:
+		action.Summary = action.Kind
+		if !action.Pool.IsZero() {
+			action.Summary += " on pool " + action.Pool.String()
+		}
+	}
+
+	return action
+}
+
+// instructionAccounts lists the pools, positions and mints of ix.
+func instructionAccounts(ix decodedInstruction) explainAccounts {
+	var res explainAccounts
+	if getter, ok := ix.Impl.(interface{ GetPoolAccount() *solana.AccountMeta }); ok && getter.GetPoolAccount() != nil {
+		res.pools = append(res.pools, getter.GetPoolAccount().PublicKey)
+	}
+	if getter, ok := ix.Impl.(interface{ GetPositionAccount() *solana.AccountMeta }); ok && getter.GetPositionAccount() != nil {
+		res.positions = append(res.positions, getter.GetPositionAccount().PublicKey)
+	}
+	return res
+}
+
+// instructionAction explains what ix asks for, false for instructions on no pool.
+func (e *explainer) instructionAction(ix *cp_amm.Instruction) (types.ExplainedAction, bool) {
+	action := types.ExplainedAction{Kind: cp_amm.InstructionIDToName(ix.TypeID)}
+	accounts := instructionAccounts(decodedInstruction{Instruction: ix})
+	if len(accounts.pools) == 0 {
+		return action, false
+	}
+	action.Pool = accounts.pools[0]
+	if len(accounts.positions) > 0 {
+		action.Position = &accounts.positions[0]
+	}
+
+	switch inst := ix.Impl.(type) {
+	case *cp_amm.SwapInstruction:
+		e.swapAction(&action, inst)
+
+	case *cp_amm.AddLiquidityInstruction:
+		var pair string
+		action.Amounts, pair = e.pair(action.Pool, inst.Params.TokenAAmountThreshold, inst.Params.TokenBAmountThreshold)
+		action.Summary = fmt.Sprintf("Add liquidity to position %s on pool %s, at most %s", action.Position, action.Pool, pair)
+
+	case *cp_amm.RemoveLiquidityInstruction:
+		var pair string
+		action.Amounts, pair = e.pair(action.Pool, inst.Params.TokenAAmountThreshold, inst.Params.TokenBAmountThreshold)
+		action.Summary = fmt.Sprintf("Remove liquidity from position %s on pool %s, at least %s", action.Position, action.Pool, pair)
+
+	case *cp_amm.RemoveAllLiquidityInstruction:
+		var pair string
+		action.Amounts, pair = e.pair(action.Pool, *inst.TokenAAmountThreshold, *inst.TokenBAmountThreshold)
+		action.Summary = fmt.Sprintf("Remove all liquidity from position %s on pool %s, at least %s", action.Position, action.Pool, pair)
+
+	case *cp_amm.LockPositionInstruction:
+		liquidity := vestingLiquidity(inst.Params.CliffUnlockLiquidity, inst.Params.LiquidityPerPeriod, inst.Params.NumberOfPeriod)
+		action.LockedPercentage = e.lockedPercentage(*action.Position, liquidity)
+		cliff := "now"
+		if inst.Params.CliffPoint != nil {
+			cliff = strconv.FormatUint(*inst.Params.CliffPoint, 10)
+		}
+		action.Summary = fmt.Sprintf("Lock %s of position %s with vesting: cliff at %s, then %d periods every %d",
+			formatLocked(action.LockedPercentage, liquidity), action.Position,
+			cliff, inst.Params.NumberOfPeriod, inst.Params.PeriodFrequency)
+
+	case *cp_amm.PermanentLockPositionInstruction:
+		liquidity := inst.PermanentLockLiquidity.BigInt()
+		action.LockedPercentage = e.lockedPercentage(*action.Position, liquidity)
+		action.Summary = fmt.Sprintf("Permanently lock %s of position %s",
+			formatLocked(action.LockedPercentage, liquidity), action.Position)
+
+	default:
+		action.Summary = action.Kind + " on pool " + action.Pool.String()
+		if action.Position != nil {
+			action.Summary += ", position " + action.Position.String()
+		}
+	}
+
+	return action, true
+}
+
+// swapAction tells the swap direction apart from the input token account, when it is
+// the associated token account of the payer for either token.
+func (e *explainer) swapAction(action *types.ExplainedAction, inst *cp_amm.SwapInstruction) {
+	var (
+		payer   = inst.GetPayerAccount().PublicKey
+		input   = inst.GetInputTokenAccountAccount().PublicKey
+		mintA   = inst.GetTokenAMintAccount().PublicKey
+		mintB   = inst.GetTokenBMintAccount().PublicKey
+		ataA, _ = helpers.GetAssociatedTokenAddressSync(mintA, payer, true, inst.GetTokenAProgramAccount().PublicKey, solana.PublicKey{})
+		ataB, _ = helpers.GetAssociatedTokenAddressSync(mintB, payer, true, inst.GetTokenBProgramAccount().PublicKey, solana.PublicKey{})
+	)
+
+	var inMint, outMint solana.PublicKey
+	switch {
+	case input.Equals(ataA):
+		inMint, outMint = mintA, mintB
+	case input.Equals(ataB):
+		inMint, outMint = mintB, mintA
+	default:
+		action.Summary = fmt.Sprintf("Swap %d units for at least %d units on pool %s",
+			inst.Params.AmountIn, inst.Params.MinimumAmountOut, action.Pool)
+		return
+	}
+
+	action.Amounts = []types.TokenAmount{
+		e.amount(inMint, inst.Params.AmountIn),
+		e.amount(outMint, inst.Params.MinimumAmountOut),
+	}
+	action.Summary = fmt.Sprintf("Swap %s for at least %s on pool %s",
+		FormatTokenAmount(action.Amounts[0]), FormatTokenAmount(action.Amounts[1]), action.Pool)
+}
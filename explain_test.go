@@ -0,0 +1,225 @@
+package dammv2gosdk
+
+import (
+	"bytes"
+	"context"
+	cp_amm "dammv2GoSDK/generated/cpAmm"
+	"dammv2GoSDK/types"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	ag_binary "github.com/gagliardetto/binary"
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/programs/token"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/gagliardetto/solana-go/rpc/jsonrpc"
+)
+
+// fakeAccounts answers getMultipleAccounts out of accounts.
+type fakeAccounts struct {
+	accounts map[solana.PublicKey][]byte
+}
+
+func (f *fakeAccounts) CallForInto(_ context.Context, out any, method string, params []any) error {
+	if method != "getMultipleAccounts" {
+		return errors.New("unexpected method " + method)
+	}
+
+	keys := params[0].([]solana.PublicKey)
+	values := make([]any, len(keys))
+	for i, key := range keys {
+		data, ok := f.accounts[key]
+		if !ok {
+			continue
+		}
+		values[i] = map[string]any{
+			"data":       []string{base64.StdEncoding.EncodeToString(data), "base64"},
+			"owner":      solana.SystemProgramID.String(),
+			"lamports":   1,
+			"executable": false,
+			"rentEpoch":  0,
+		}
+	}
+
+	raw, err := json.Marshal(map[string]any{
+		"context": map[string]any{"slot": 42},
+		"value":   values,
+	})
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(raw, out)
+}
+
+func (f *fakeAccounts) CallWithCallback(context.Context, string, []any, func(*http.Request, *http.Response) error) error {
+	return errors.New("not implemented")
+}
+
+func (f *fakeAccounts) CallBatch(context.Context, jsonrpc.RPCRequests) (jsonrpc.RPCResponses, error) {
+	return nil, errors.New("not implemented")
+}
+
+func borshBytes(t *testing.T, v ag_binary.BinaryMarshaler) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := v.MarshalWithEncoder(ag_binary.NewBorshEncoder(&buf)); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestExplainTransactionResultSwap(t *testing.T) {
+	var (
+		payer = solana.NewWallet().PublicKey()
+		usdc  = solana.NewWallet().PublicKey()
+		pool  = solana.NewWallet().PublicKey()
+		state = &cp_amm.PoolAccount{
+			TokenAMint:  usdc,
+			TokenBMint:  solana.WrappedSol,
+			TokenAVault: solana.NewWallet().PublicKey(),
+			TokenBVault: solana.NewWallet().PublicKey(),
+		}
+		table = solana.NewWallet().PublicKey()
+	)
+
+	ix, err := cp_amm.NewSwapInstruction(
+		cp_amm.SwapParameters{AmountIn: 10_500_000, MinimumAmountOut: 1},
+		DerivePoolAuthority(),
+		pool,
+		solana.NewWallet().PublicKey(),
+		solana.NewWallet().PublicKey(),
+		state.TokenAVault,
+		state.TokenBVault,
+		usdc,
+		solana.WrappedSol,
+		payer,
+		solana.TokenProgramID,
+		solana.TokenProgramID,
+		CpAMMProgramId,
+		DeriveEventAuthority(),
+		CpAMMProgramId,
+	).ValidateAndBuild()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tableAddresses := solana.PublicKeySlice{pool, state.TokenAVault, state.TokenBVault}
+	tx, err := solana.NewTransaction([]solana.Instruction{ix}, solana.Hash{},
+		solana.TransactionPayer(payer),
+		solana.TransactionAddressTables(map[solana.PublicKey]solana.PublicKeySlice{table: tableAddresses}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var loaded rpc.LoadedAddresses
+	for _, lookup := range tx.Message.AddressTableLookups {
+		for _, idx := range lookup.WritableIndexes {
+			loaded.Writable = append(loaded.Writable, tableAddresses[idx])
+		}
+		for _, idx := range lookup.ReadonlyIndexes {
+			loaded.ReadOnly = append(loaded.ReadOnly, tableAddresses[idx])
+		}
+	}
+	if len(loaded.Writable) == 0 {
+		t.Fatal("expected the pool accounts to be looked up")
+	}
+
+	txBase64, err := tx.ToBase64()
+	if err != nil {
+		t.Fatal(err)
+	}
+	envelope := &rpc.TransactionResultEnvelope{}
+	if err := envelope.UnmarshalJSON([]byte(`["` + txBase64 + `","base64"]`)); err != nil {
+		t.Fatal(err)
+	}
+
+	evt := borshBytes(t, cp_amm.EvtSwapEventData{
+		Pool:           pool,
+		TradeDirection: uint8(types.TradeDirectionAtoB),
+		Params:         cp_amm.SwapParameters{AmountIn: 10_500_000, MinimumAmountOut: 1},
+		SwapResult: cp_amm.SwapResult{
+			OutputAmount: 30_000_000,
+			LpFee:        80_000,
+			ProtocolFee:  15_000,
+			PartnerFee:   5_000,
+		},
+		ActualAmountIn: 10_500_000,
+	})
+
+	fake := &fakeAccounts{accounts: map[solana.PublicKey][]byte{
+		pool:              borshBytes(t, state),
+		usdc:              borshBytes(t, &token.Mint{Decimals: 6, IsInitialized: true}),
+		solana.WrappedSol: borshBytes(t, &token.Mint{Decimals: 9, IsInitialized: true}),
+	}}
+	cp := NewCpAMM(rpc.NewWithCustomRPCClient(fake))
+
+	res, err := cp.ExplainTransactionResult(context.Background(), &rpc.GetTransactionResult{
+		Slot:        7,
+		Transaction: envelope,
+		Meta: &rpc.TransactionMeta{
+			LogMessages: []string{
+				"Program " + CpAMMProgramId.String() + " invoke [1]",
+				"Program data: " + base64.StdEncoding.EncodeToString(evt),
+				"Program " + CpAMMProgramId.String() + " success",
+			},
+			LoadedAddresses: loaded,
+		},
+	}, types.ExplainOpts{Symbols: map[solana.PublicKey]string{usdc: "USDC"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(res.Instructions) != 1 || res.Instructions[0].Name != "Swap" {
+		t.Fatalf("instructions = %+v", res.Instructions)
+	}
+	var lookedUp bool
+	for _, account := range res.Instructions[0].Accounts {
+		if account.Address.Equals(pool) {
+			lookedUp = account.LookupTable && account.Writable
+		}
+	}
+	if !lookedUp {
+		t.Fatal("pool account not resolved from the lookup table")
+	}
+
+	if len(res.Actions) != 1 {
+		t.Fatalf("actions = %+v", res.Actions)
+	}
+	want := "Swapped 10.5 USDC → 0.03 SOL on pool " + pool.String() +
+		", fees: lp 0.00008 SOL / protocol 0.000015 SOL / partner 0.000005 SOL / referral 0 SOL"
+	if res.Actions[0].Summary != want {
+		t.Fatalf("summary = %q\nwant      %q", res.Actions[0].Summary, want)
+	}
+
+	var text, js bytes.Buffer
+	if err := WriteExplanationText(&text, res); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(text.String(), want) || !strings.Contains(text.String(), "(writable, lookup table)") {
+		t.Fatalf("text = %s", text.String())
+	}
+	if err := WriteExplanationJSON(&js, res); err != nil {
+		t.Fatal(err)
+	}
+	var decoded types.TransactionExplanation
+	if err := json.Unmarshal(js.Bytes(), &decoded); err != nil || decoded.Actions[0].Summary != want {
+		t.Fatalf("json = %s, err = %v", js.String(), err)
+	}
+}
+
+func TestFormatTokenAmount(t *testing.T) {
+	for amount, want := range map[types.TokenAmount]string{
+		{Amount: 10_500_000, Decimals: 6, Symbol: "USDC"}: "10.5 USDC",
+		{Amount: 5, Decimals: 9, Symbol: "SOL"}:           "0.000000005 SOL",
+		{Amount: 42, Symbol: "RAW"}:                       "42 RAW",
+		{Amount: 0, Decimals: 6, Symbol: "USDC"}:          "0 USDC",
+	} {
+		if got := FormatTokenAmount(amount); got != want {
+			t.Errorf("FormatTokenAmount(%+v) = %q, want %q", amount, got, want)
+		}
+	}
+}
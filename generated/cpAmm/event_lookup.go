@@ -0,0 +1,13 @@
+package cp_amm
+
+// DecodeEvent decodes a borsh encoded event, discriminator first, through the event tables
+// of the generated events. Unknown discriminators yield nil.
+//
+// Written by hand, next to the generated code it relies on.
+func DecodeEvent(data []byte) (*Event, error) {
+	evts, err := parseEvents([][]byte{data})
+	if err != nil || len(evts) == 0 {
+		return nil, err
+	}
+	return evts[0], nil
+}
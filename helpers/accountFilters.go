@@ -1,26 +1,34 @@
 package helpers
 
 import (
+	"dammv2GoSDK/helpers/filters"
+
 	"github.com/gagliardetto/solana-go"
 	"github.com/gagliardetto/solana-go/rpc"
 )
 
 var (
 	PositionByPoolFilter = func(pool solana.PublicKey) rpc.RPCFilter {
-		return rpc.RPCFilter{
-			Memcmp: &rpc.RPCFilterMemcmp{
-				Bytes:  pool.Bytes(),
-				Offset: 8,
-			},
-		}
+		return filters.Position.Pool(pool)
 	}
 
 	VestingByPositionFilter = func(position solana.PublicKey) rpc.RPCFilter {
-		return rpc.RPCFilter{
-			Memcmp: &rpc.RPCFilterMemcmp{
-				Bytes:  position.Bytes(),
-				Offset: 8,
-			},
-		}
+		return filters.Vesting.Position(position)
+	}
+
+	PoolByTokenAMintFilter = func(mint solana.PublicKey) rpc.RPCFilter {
+		return filters.Pool.TokenAMint(mint)
+	}
+
+	PoolByTokenBMintFilter = func(mint solana.PublicKey) rpc.RPCFilter {
+		return filters.Pool.TokenBMint(mint)
+	}
+
+	PoolByPartnerFilter = func(partner solana.PublicKey) rpc.RPCFilter {
+		return filters.Pool.Partner(partner)
+	}
+
+	PoolByCreatorFilter = func(creator solana.PublicKey) rpc.RPCFilter {
+		return filters.Pool.Creator(creator)
 	}
 )
@@ -0,0 +1,52 @@
+package helpers
+
+import (
+	cp_amm "dammv2GoSDK/generated/cpAmm"
+
+	"github.com/gagliardetto/solana-go"
+)
+
+// EventPool returns the Pool field most events carry.
+func EventPool(data cp_amm.EventData) (solana.PublicKey, bool) {
+	switch data := data.(type) {
+	case *cp_amm.EvtSwapEventData:
+		return data.Pool, true
+	case *cp_amm.EvtAddLiquidityEventData:
+		return data.Pool, true
+	case *cp_amm.EvtRemoveLiquidityEventData:
+		return data.Pool, true
+	case *cp_amm.EvtClaimPositionFeeEventData:
+		return data.Pool, true
+	case *cp_amm.EvtClaimPartnerFeeEventData:
+		return data.Pool, true
+	case *cp_amm.EvtClaimProtocolFeeEventData:
+		return data.Pool, true
+	case *cp_amm.EvtCreatePositionEventData:
+		return data.Pool, true
+	case *cp_amm.EvtClosePositionEventData:
+		return data.Pool, true
+	case *cp_amm.EvtInitializePoolEventData:
+		return data.Pool, true
+	case *cp_amm.EvtSplitPositionEventData:
+		return data.Pool, true
+	case *cp_amm.EvtInitializeRewardEventData:
+		return data.Pool, true
+	case *cp_amm.EvtClaimRewardEventData:
+		return data.Pool, true
+	case *cp_amm.EvtFundRewardEventData:
+		return data.Pool, true
+	case *cp_amm.EvtLockPositionEventData:
+		return data.Pool, true
+	case *cp_amm.EvtPermanentLockPositionEventData:
+		return data.Pool, true
+	case *cp_amm.EvtSetPoolStatusEventData:
+		return data.Pool, true
+	case *cp_amm.EvtUpdateRewardDurationEventData:
+		return data.Pool, true
+	case *cp_amm.EvtUpdateRewardFunderEventData:
+		return data.Pool, true
+	case *cp_amm.EvtWithdrawIneligibleRewardEventData:
+		return data.Pool, true
+	}
+	return solana.PublicKey{}, false
+}
@@ -0,0 +1,47 @@
+// Package filters builds getProgramAccounts filters for the cp_amm accounts.
+//
+// The builders and offsets in filters_gen.go are generated from the Borsh layout
+// of the IDL, every offset includes the 8 byte account discriminator:
+//
+//	filters.Pool.TokenAMint(mint)
+//	filters.Position.Pool(pool)
+//	filters.Vesting.DataSize()
+package filters
+
+//go:generate go run ../../internal/cmd/filtergen -idl ../../cp_amm.json -out filters_gen.go
+
+import (
+	"encoding/binary"
+
+	ag_binary "github.com/gagliardetto/binary"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+// Memcmp matches accounts whose data holds b at offset.
+func Memcmp(offset uint64, b []byte) rpc.RPCFilter {
+	return rpc.RPCFilter{
+		Memcmp: &rpc.RPCFilterMemcmp{
+			Offset: offset,
+			Bytes:  b,
+		},
+	}
+}
+
+// DataSize matches accounts whose data is exactly size bytes long.
+func DataSize(size uint64) rpc.RPCFilter {
+	return rpc.RPCFilter{
+		DataSize: size,
+	}
+}
+
+func boolBytes(v bool) []byte {
+	if v {
+		return []byte{1}
+	}
+	return []byte{0}
+}
+
+func uint128Bytes(v ag_binary.Uint128) []byte {
+	b := binary.LittleEndian.AppendUint64(make([]byte, 0, 16), v.Lo)
+	return binary.LittleEndian.AppendUint64(b, v.Hi)
+}
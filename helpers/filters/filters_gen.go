@@ -0,0 +1,485 @@
+// Code generated by filtergen from cp_amm.json. DO NOT EDIT.
+
+package filters
+
+import (
+	"encoding/binary"
+
+	ag_binary "github.com/gagliardetto/binary"
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+// Pool account layout, offsets include the 8 byte discriminator.
+const (
+	PoolSize                         = 1112
+	PoolPoolFeesOffset               = 8
+	PoolTokenAMintOffset             = 168
+	PoolTokenBMintOffset             = 200
+	PoolTokenAVaultOffset            = 232
+	PoolTokenBVaultOffset            = 264
+	PoolWhitelistedVaultOffset       = 296
+	PoolPartnerOffset                = 328
+	PoolLiquidityOffset              = 360
+	PoolProtocolAFeeOffset           = 392
+	PoolProtocolBFeeOffset           = 400
+	PoolPartnerAFeeOffset            = 408
+	PoolPartnerBFeeOffset            = 416
+	PoolSqrtMinPriceOffset           = 424
+	PoolSqrtMaxPriceOffset           = 440
+	PoolSqrtPriceOffset              = 456
+	PoolActivationPointOffset        = 472
+	PoolActivationTypeOffset         = 480
+	PoolPoolStatusOffset             = 481
+	PoolTokenAFlagOffset             = 482
+	PoolTokenBFlagOffset             = 483
+	PoolCollectFeeModeOffset         = 484
+	PoolPoolTypeOffset               = 485
+	PoolFeeAPerLiquidityOffset       = 488
+	PoolFeeBPerLiquidityOffset       = 520
+	PoolPermanentLockLiquidityOffset = 552
+	PoolMetricsOffset                = 568
+	PoolCreatorOffset                = 648
+	PoolRewardInfosOffset            = 728
+)
+
+// PoolFilters builds getProgramAccounts filters matching Pool accounts.
+type PoolFilters struct{}
+
+// Pool is the filter builder of Pool accounts.
+var Pool PoolFilters
+
+// Discriminator matches the Pool account discriminator.
+func (PoolFilters) Discriminator() rpc.RPCFilter {
+	return Memcmp(0, []byte{0xf1, 0x9a, 0x6d, 0x4, 0x11, 0xb1, 0x6d, 0xbc})
+}
+
+// DataSize matches accounts of the Pool account size.
+func (PoolFilters) DataSize() rpc.RPCFilter {
+	return DataSize(PoolSize)
+}
+
+// PoolFees matches the pool_fees field (160 bytes at offset 8).
+// v is compared against the start of the field, it may be shorter than the field.
+func (PoolFilters) PoolFees(v []byte) rpc.RPCFilter {
+	return Memcmp(PoolPoolFeesOffset, v)
+}
+
+// TokenAMint matches the token_a_mint field (32 bytes at offset 168).
+func (PoolFilters) TokenAMint(v solana.PublicKey) rpc.RPCFilter {
+	return Memcmp(PoolTokenAMintOffset, v.Bytes())
+}
+
+// TokenBMint matches the token_b_mint field (32 bytes at offset 200).
+func (PoolFilters) TokenBMint(v solana.PublicKey) rpc.RPCFilter {
+	return Memcmp(PoolTokenBMintOffset, v.Bytes())
+}
+
+// TokenAVault matches the token_a_vault field (32 bytes at offset 232).
+func (PoolFilters) TokenAVault(v solana.PublicKey) rpc.RPCFilter {
+	return Memcmp(PoolTokenAVaultOffset, v.Bytes())
+}
+
+// TokenBVault matches the token_b_vault field (32 bytes at offset 264).
+func (PoolFilters) TokenBVault(v solana.PublicKey) rpc.RPCFilter {
+	return Memcmp(PoolTokenBVaultOffset, v.Bytes())
+}
+
+// WhitelistedVault matches the whitelisted_vault field (32 bytes at offset 296).
+func (PoolFilters) WhitelistedVault(v solana.PublicKey) rpc.RPCFilter {
+	return Memcmp(PoolWhitelistedVaultOffset, v.Bytes())
+}
+
+// Partner matches the partner field (32 bytes at offset 328).
+func (PoolFilters) Partner(v solana.PublicKey) rpc.RPCFilter {
+	return Memcmp(PoolPartnerOffset, v.Bytes())
+}
+
+// Liquidity matches the liquidity field (16 bytes at offset 360).
+func (PoolFilters) Liquidity(v ag_binary.Uint128) rpc.RPCFilter {
+	return Memcmp(PoolLiquidityOffset, uint128Bytes(v))
+}
+
+// ProtocolAFee matches the protocol_a_fee field (8 bytes at offset 392).
+func (PoolFilters) ProtocolAFee(v uint64) rpc.RPCFilter {
+	return Memcmp(PoolProtocolAFeeOffset, binary.LittleEndian.AppendUint64(nil, v))
+}
+
+// ProtocolBFee matches the protocol_b_fee field (8 bytes at offset 400).
+func (PoolFilters) ProtocolBFee(v uint64) rpc.RPCFilter {
+	return Memcmp(PoolProtocolBFeeOffset, binary.LittleEndian.AppendUint64(nil, v))
+}
+
+// PartnerAFee matches the partner_a_fee field (8 bytes at offset 408).
+func (PoolFilters) PartnerAFee(v uint64) rpc.RPCFilter {
+	return Memcmp(PoolPartnerAFeeOffset, binary.LittleEndian.AppendUint64(nil, v))
+}
+
+// PartnerBFee matches the partner_b_fee field (8 bytes at offset 416).
+func (PoolFilters) PartnerBFee(v uint64) rpc.RPCFilter {
+	return Memcmp(PoolPartnerBFeeOffset, binary.LittleEndian.AppendUint64(nil, v))
+}
+
+// SqrtMinPrice matches the sqrt_min_price field (16 bytes at offset 424).
+func (PoolFilters) SqrtMinPrice(v ag_binary.Uint128) rpc.RPCFilter {
+	return Memcmp(PoolSqrtMinPriceOffset, uint128Bytes(v))
+}
+
+// SqrtMaxPrice matches the sqrt_max_price field (16 bytes at offset 440).
+func (PoolFilters) SqrtMaxPrice(v ag_binary.Uint128) rpc.RPCFilter {
+	return Memcmp(PoolSqrtMaxPriceOffset, uint128Bytes(v))
+}
+
+// SqrtPrice matches the sqrt_price field (16 bytes at offset 456).
+func (PoolFilters) SqrtPrice(v ag_binary.Uint128) rpc.RPCFilter {
+	return Memcmp(PoolSqrtPriceOffset, uint128Bytes(v))
+}
+
+// ActivationPoint matches the activation_point field (8 bytes at offset 472).
+func (PoolFilters) ActivationPoint(v uint64) rpc.RPCFilter {
+	return Memcmp(PoolActivationPointOffset, binary.LittleEndian.AppendUint64(nil, v))
+}
+
+// ActivationType matches the activation_type field (1 bytes at offset 480).
+func (PoolFilters) ActivationType(v uint8) rpc.RPCFilter {
+	return Memcmp(PoolActivationTypeOffset, []byte{v})
+}
+
+// PoolStatus matches the pool_status field (1 bytes at offset 481).
+func (PoolFilters) PoolStatus(v uint8) rpc.RPCFilter {
+	return Memcmp(PoolPoolStatusOffset, []byte{v})
+}
+
+// TokenAFlag matches the token_a_flag field (1 bytes at offset 482).
+func (PoolFilters) TokenAFlag(v uint8) rpc.RPCFilter {
+	return Memcmp(PoolTokenAFlagOffset, []byte{v})
+}
+
+// TokenBFlag matches the token_b_flag field (1 bytes at offset 483).
+func (PoolFilters) TokenBFlag(v uint8) rpc.RPCFilter {
+	return Memcmp(PoolTokenBFlagOffset, []byte{v})
+}
+
+// CollectFeeMode matches the collect_fee_mode field (1 bytes at offset 484).
+func (PoolFilters) CollectFeeMode(v uint8) rpc.RPCFilter {
+	return Memcmp(PoolCollectFeeModeOffset, []byte{v})
+}
+
+// PoolType matches the pool_type field (1 bytes at offset 485).
+func (PoolFilters) PoolType(v uint8) rpc.RPCFilter {
+	return Memcmp(PoolPoolTypeOffset, []byte{v})
+}
+
+// FeeAPerLiquidity matches the fee_a_per_liquidity field (32 bytes at offset 488).
+func (PoolFilters) FeeAPerLiquidity(v [32]byte) rpc.RPCFilter {
+	return Memcmp(PoolFeeAPerLiquidityOffset, v[:])
+}
+
+// FeeBPerLiquidity matches the fee_b_per_liquidity field (32 bytes at offset 520).
+func (PoolFilters) FeeBPerLiquidity(v [32]byte) rpc.RPCFilter {
+	return Memcmp(PoolFeeBPerLiquidityOffset, v[:])
+}
+
+// PermanentLockLiquidity matches the permanent_lock_liquidity field (16 bytes at offset 552).
+func (PoolFilters) PermanentLockLiquidity(v ag_binary.Uint128) rpc.RPCFilter {
+	return Memcmp(PoolPermanentLockLiquidityOffset, uint128Bytes(v))
+}
+
+// Metrics matches the metrics field (80 bytes at offset 568).
+// v is compared against the start of the field, it may be shorter than the field.
+func (PoolFilters) Metrics(v []byte) rpc.RPCFilter {
+	return Memcmp(PoolMetricsOffset, v)
+}
+
+// Creator matches the creator field (32 bytes at offset 648).
+func (PoolFilters) Creator(v solana.PublicKey) rpc.RPCFilter {
+	return Memcmp(PoolCreatorOffset, v.Bytes())
+}
+
+// RewardInfos matches the reward_infos field (384 bytes at offset 728).
+// v is compared against the start of the field, it may be shorter than the field.
+func (PoolFilters) RewardInfos(v []byte) rpc.RPCFilter {
+	return Memcmp(PoolRewardInfosOffset, v)
+}
+
+// Position account layout, offsets include the 8 byte discriminator.
+const (
+	PositionSize                           = 408
+	PositionPoolOffset                     = 8
+	PositionNftMintOffset                  = 40
+	PositionFeeAPerTokenCheckpointOffset   = 72
+	PositionFeeBPerTokenCheckpointOffset   = 104
+	PositionFeeAPendingOffset              = 136
+	PositionFeeBPendingOffset              = 144
+	PositionUnlockedLiquidityOffset        = 152
+	PositionVestedLiquidityOffset          = 168
+	PositionPermanentLockedLiquidityOffset = 184
+	PositionMetricsOffset                  = 200
+	PositionRewardInfosOffset              = 216
+)
+
+// PositionFilters builds getProgramAccounts filters matching Position accounts.
+type PositionFilters struct{}
+
+// Position is the filter builder of Position accounts.
+var Position PositionFilters
+
+// Discriminator matches the Position account discriminator.
+func (PositionFilters) Discriminator() rpc.RPCFilter {
+	return Memcmp(0, []byte{0xaa, 0xbc, 0x8f, 0xe4, 0x7a, 0x40, 0xf7, 0xd0})
+}
+
+// DataSize matches accounts of the Position account size.
+func (PositionFilters) DataSize() rpc.RPCFilter {
+	return DataSize(PositionSize)
+}
+
+// Pool matches the pool field (32 bytes at offset 8).
+func (PositionFilters) Pool(v solana.PublicKey) rpc.RPCFilter {
+	return Memcmp(PositionPoolOffset, v.Bytes())
+}
+
+// NftMint matches the nft_mint field (32 bytes at offset 40).
+func (PositionFilters) NftMint(v solana.PublicKey) rpc.RPCFilter {
+	return Memcmp(PositionNftMintOffset, v.Bytes())
+}
+
+// FeeAPerTokenCheckpoint matches the fee_a_per_token_checkpoint field (32 bytes at offset 72).
+func (PositionFilters) FeeAPerTokenCheckpoint(v [32]byte) rpc.RPCFilter {
+	return Memcmp(PositionFeeAPerTokenCheckpointOffset, v[:])
+}
+
+// FeeBPerTokenCheckpoint matches the fee_b_per_token_checkpoint field (32 bytes at offset 104).
+func (PositionFilters) FeeBPerTokenCheckpoint(v [32]byte) rpc.RPCFilter {
+	return Memcmp(PositionFeeBPerTokenCheckpointOffset, v[:])
+}
+
+// FeeAPending matches the fee_a_pending field (8 bytes at offset 136).
+func (PositionFilters) FeeAPending(v uint64) rpc.RPCFilter {
+	return Memcmp(PositionFeeAPendingOffset, binary.LittleEndian.AppendUint64(nil, v))
+}
+
+// FeeBPending matches the fee_b_pending field (8 bytes at offset 144).
+func (PositionFilters) FeeBPending(v uint64) rpc.RPCFilter {
+	return Memcmp(PositionFeeBPendingOffset, binary.LittleEndian.AppendUint64(nil, v))
+}
+
+// UnlockedLiquidity matches the unlocked_liquidity field (16 bytes at offset 152).
+func (PositionFilters) UnlockedLiquidity(v ag_binary.Uint128) rpc.RPCFilter {
+	return Memcmp(PositionUnlockedLiquidityOffset, uint128Bytes(v))
+}
+
+// VestedLiquidity matches the vested_liquidity field (16 bytes at offset 168).
+func (PositionFilters) VestedLiquidity(v ag_binary.Uint128) rpc.RPCFilter {
+	return Memcmp(PositionVestedLiquidityOffset, uint128Bytes(v))
+}
+
+// PermanentLockedLiquidity matches the permanent_locked_liquidity field (16 bytes at offset 184).
+func (PositionFilters) PermanentLockedLiquidity(v ag_binary.Uint128) rpc.RPCFilter {
+	return Memcmp(PositionPermanentLockedLiquidityOffset, uint128Bytes(v))
+}
+
+// Metrics matches the metrics field (16 bytes at offset 200).
+// v is compared against the start of the field, it may be shorter than the field.
+func (PositionFilters) Metrics(v []byte) rpc.RPCFilter {
+	return Memcmp(PositionMetricsOffset, v)
+}
+
+// RewardInfos matches the reward_infos field (96 bytes at offset 216).
+// v is compared against the start of the field, it may be shorter than the field.
+func (PositionFilters) RewardInfos(v []byte) rpc.RPCFilter {
+	return Memcmp(PositionRewardInfosOffset, v)
+}
+
+// Config account layout, offsets include the 8 byte discriminator.
+const (
+	ConfigSize                       = 328
+	ConfigVaultConfigKeyOffset       = 8
+	ConfigPoolCreatorAuthorityOffset = 40
+	ConfigPoolFeesOffset             = 72
+	ConfigActivationTypeOffset       = 200
+	ConfigCollectFeeModeOffset       = 201
+	ConfigConfigTypeOffset           = 202
+	ConfigIndexOffset                = 208
+	ConfigSqrtMinPriceOffset         = 216
+	ConfigSqrtMaxPriceOffset         = 232
+)
+
+// ConfigFilters builds getProgramAccounts filters matching Config accounts.
+type ConfigFilters struct{}
+
+// Config is the filter builder of Config accounts.
+var Config ConfigFilters
+
+// Discriminator matches the Config account discriminator.
+func (ConfigFilters) Discriminator() rpc.RPCFilter {
+	return Memcmp(0, []byte{0x9b, 0xc, 0xaa, 0xe0, 0x1e, 0xfa, 0xcc, 0x82})
+}
+
+// DataSize matches accounts of the Config account size.
+func (ConfigFilters) DataSize() rpc.RPCFilter {
+	return DataSize(ConfigSize)
+}
+
+// VaultConfigKey matches the vault_config_key field (32 bytes at offset 8).
+func (ConfigFilters) VaultConfigKey(v solana.PublicKey) rpc.RPCFilter {
+	return Memcmp(ConfigVaultConfigKeyOffset, v.Bytes())
+}
+
+// PoolCreatorAuthority matches the pool_creator_authority field (32 bytes at offset 40).
+func (ConfigFilters) PoolCreatorAuthority(v solana.PublicKey) rpc.RPCFilter {
+	return Memcmp(ConfigPoolCreatorAuthorityOffset, v.Bytes())
+}
+
+// PoolFees matches the pool_fees field (128 bytes at offset 72).
+// v is compared against the start of the field, it may be shorter than the field.
+func (ConfigFilters) PoolFees(v []byte) rpc.RPCFilter {
+	return Memcmp(ConfigPoolFeesOffset, v)
+}
+
+// ActivationType matches the activation_type field (1 bytes at offset 200).
+func (ConfigFilters) ActivationType(v uint8) rpc.RPCFilter {
+	return Memcmp(ConfigActivationTypeOffset, []byte{v})
+}
+
+// CollectFeeMode matches the collect_fee_mode field (1 bytes at offset 201).
+func (ConfigFilters) CollectFeeMode(v uint8) rpc.RPCFilter {
+	return Memcmp(ConfigCollectFeeModeOffset, []byte{v})
+}
+
+// ConfigType matches the config_type field (1 bytes at offset 202).
+func (ConfigFilters) ConfigType(v uint8) rpc.RPCFilter {
+	return Memcmp(ConfigConfigTypeOffset, []byte{v})
+}
+
+// Index matches the index field (8 bytes at offset 208).
+func (ConfigFilters) Index(v uint64) rpc.RPCFilter {
+	return Memcmp(ConfigIndexOffset, binary.LittleEndian.AppendUint64(nil, v))
+}
+
+// SqrtMinPrice matches the sqrt_min_price field (16 bytes at offset 216).
+func (ConfigFilters) SqrtMinPrice(v ag_binary.Uint128) rpc.RPCFilter {
+	return Memcmp(ConfigSqrtMinPriceOffset, uint128Bytes(v))
+}
+
+// SqrtMaxPrice matches the sqrt_max_price field (16 bytes at offset 232).
+func (ConfigFilters) SqrtMaxPrice(v ag_binary.Uint128) rpc.RPCFilter {
+	return Memcmp(ConfigSqrtMaxPriceOffset, uint128Bytes(v))
+}
+
+// Vesting account layout, offsets include the 8 byte discriminator.
+const (
+	VestingSize                         = 184
+	VestingPositionOffset               = 8
+	VestingCliffPointOffset             = 40
+	VestingPeriodFrequencyOffset        = 48
+	VestingCliffUnlockLiquidityOffset   = 56
+	VestingLiquidityPerPeriodOffset     = 72
+	VestingTotalReleasedLiquidityOffset = 88
+	VestingNumberOfPeriodOffset         = 104
+)
+
+// VestingFilters builds getProgramAccounts filters matching Vesting accounts.
+type VestingFilters struct{}
+
+// Vesting is the filter builder of Vesting accounts.
+var Vesting VestingFilters
+
+// Discriminator matches the Vesting account discriminator.
+func (VestingFilters) Discriminator() rpc.RPCFilter {
+	return Memcmp(0, []byte{0x64, 0x95, 0x42, 0x8a, 0x5f, 0xc8, 0x80, 0xf1})
+}
+
+// DataSize matches accounts of the Vesting account size.
+func (VestingFilters) DataSize() rpc.RPCFilter {
+	return DataSize(VestingSize)
+}
+
+// Position matches the position field (32 bytes at offset 8).
+func (VestingFilters) Position(v solana.PublicKey) rpc.RPCFilter {
+	return Memcmp(VestingPositionOffset, v.Bytes())
+}
+
+// CliffPoint matches the cliff_point field (8 bytes at offset 40).
+func (VestingFilters) CliffPoint(v uint64) rpc.RPCFilter {
+	return Memcmp(VestingCliffPointOffset, binary.LittleEndian.AppendUint64(nil, v))
+}
+
+// PeriodFrequency matches the period_frequency field (8 bytes at offset 48).
+func (VestingFilters) PeriodFrequency(v uint64) rpc.RPCFilter {
+	return Memcmp(VestingPeriodFrequencyOffset, binary.LittleEndian.AppendUint64(nil, v))
+}
+
+// CliffUnlockLiquidity matches the cliff_unlock_liquidity field (16 bytes at offset 56).
+func (VestingFilters) CliffUnlockLiquidity(v ag_binary.Uint128) rpc.RPCFilter {
+	return Memcmp(VestingCliffUnlockLiquidityOffset, uint128Bytes(v))
+}
+
+// LiquidityPerPeriod matches the liquidity_per_period field (16 bytes at offset 72).
+func (VestingFilters) LiquidityPerPeriod(v ag_binary.Uint128) rpc.RPCFilter {
+	return Memcmp(VestingLiquidityPerPeriodOffset, uint128Bytes(v))
+}
+
+// TotalReleasedLiquidity matches the total_released_liquidity field (16 bytes at offset 88).
+func (VestingFilters) TotalReleasedLiquidity(v ag_binary.Uint128) rpc.RPCFilter {
+	return Memcmp(VestingTotalReleasedLiquidityOffset, uint128Bytes(v))
+}
+
+// NumberOfPeriod matches the number_of_period field (2 bytes at offset 104).
+func (VestingFilters) NumberOfPeriod(v uint16) rpc.RPCFilter {
+	return Memcmp(VestingNumberOfPeriodOffset, binary.LittleEndian.AppendUint16(nil, v))
+}
+
+// TokenBadge account layout, offsets include the 8 byte discriminator.
+const (
+	TokenBadgeSize            = 168
+	TokenBadgeTokenMintOffset = 8
+)
+
+// TokenBadgeFilters builds getProgramAccounts filters matching TokenBadge accounts.
+type TokenBadgeFilters struct{}
+
+// TokenBadge is the filter builder of TokenBadge accounts.
+var TokenBadge TokenBadgeFilters
+
+// Discriminator matches the TokenBadge account discriminator.
+func (TokenBadgeFilters) Discriminator() rpc.RPCFilter {
+	return Memcmp(0, []byte{0x74, 0xdb, 0xcc, 0xe5, 0xf9, 0x74, 0xff, 0x96})
+}
+
+// DataSize matches accounts of the TokenBadge account size.
+func (TokenBadgeFilters) DataSize() rpc.RPCFilter {
+	return DataSize(TokenBadgeSize)
+}
+
+// TokenMint matches the token_mint field (32 bytes at offset 8).
+func (TokenBadgeFilters) TokenMint(v solana.PublicKey) rpc.RPCFilter {
+	return Memcmp(TokenBadgeTokenMintOffset, v.Bytes())
+}
+
+// ClaimFeeOperator account layout, offsets include the 8 byte discriminator.
+const (
+	ClaimFeeOperatorSize           = 168
+	ClaimFeeOperatorOperatorOffset = 8
+)
+
+// ClaimFeeOperatorFilters builds getProgramAccounts filters matching ClaimFeeOperator accounts.
+type ClaimFeeOperatorFilters struct{}
+
+// ClaimFeeOperator is the filter builder of ClaimFeeOperator accounts.
+var ClaimFeeOperator ClaimFeeOperatorFilters
+
+// Discriminator matches the ClaimFeeOperator account discriminator.
+func (ClaimFeeOperatorFilters) Discriminator() rpc.RPCFilter {
+	return Memcmp(0, []byte{0xa6, 0x30, 0x86, 0x56, 0x22, 0xc8, 0xbc, 0x96})
+}
+
+// DataSize matches accounts of the ClaimFeeOperator account size.
+func (ClaimFeeOperatorFilters) DataSize() rpc.RPCFilter {
+	return DataSize(ClaimFeeOperatorSize)
+}
+
+// Operator matches the operator field (32 bytes at offset 8).
+func (ClaimFeeOperatorFilters) Operator(v solana.PublicKey) rpc.RPCFilter {
+	return Memcmp(ClaimFeeOperatorOperatorOffset, v.Bytes())
+}
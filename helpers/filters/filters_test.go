@@ -0,0 +1,107 @@
+package filters
+
+import (
+	"bytes"
+	"testing"
+
+	cp_amm "dammv2GoSDK/generated/cpAmm"
+
+	ag_binary "github.com/gagliardetto/binary"
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+func encode(t *testing.T, account interface {
+	MarshalWithEncoder(*ag_binary.Encoder) error
+}) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := account.MarshalWithEncoder(ag_binary.NewBorshEncoder(&buf)); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// assertMatches applies filter the way the rpc node does.
+func assertMatches(t *testing.T, name string, data []byte, filter rpc.RPCFilter) {
+	t.Helper()
+	m := filter.Memcmp
+	end := m.Offset + uint64(len(m.Bytes))
+	if end > uint64(len(data)) || !bytes.Equal(data[m.Offset:end], m.Bytes) {
+		t.Errorf("%s filter does not match the encoded account", name)
+	}
+}
+
+func TestPoolLayout(t *testing.T) {
+	var (
+		tokenAMint = solana.NewWallet().PublicKey()
+		tokenBMint = solana.NewWallet().PublicKey()
+		partner    = solana.NewWallet().PublicKey()
+		creator    = solana.NewWallet().PublicKey()
+		sqrtPrice  = ag_binary.Uint128{Lo: 7, Hi: 3}
+	)
+
+	data := encode(t, &cp_amm.PoolAccount{
+		TokenAMint: tokenAMint,
+		TokenBMint: tokenBMint,
+		Partner:    partner,
+		Creator:    creator,
+		SqrtPrice:  sqrtPrice,
+		PoolStatus: 1,
+	})
+
+	if len(data) != PoolSize {
+		t.Fatalf("PoolSize = %d, encoded account is %d bytes", PoolSize, len(data))
+	}
+
+	for name, filter := range map[string]rpc.RPCFilter{
+		"Discriminator": Pool.Discriminator(),
+		"TokenAMint":    Pool.TokenAMint(tokenAMint),
+		"TokenBMint":    Pool.TokenBMint(tokenBMint),
+		"Partner":       Pool.Partner(partner),
+		"Creator":       Pool.Creator(creator),
+		"SqrtPrice":     Pool.SqrtPrice(sqrtPrice),
+		"PoolStatus":    Pool.PoolStatus(1),
+	} {
+		assertMatches(t, name, data, filter)
+	}
+}
+
+func TestPositionAndVestingLayout(t *testing.T) {
+	var (
+		pool     = solana.NewWallet().PublicKey()
+		nftMint  = solana.NewWallet().PublicKey()
+		position = solana.NewWallet().PublicKey()
+	)
+
+	positionData := encode(t, &cp_amm.PositionAccount{Pool: pool, NftMint: nftMint})
+	if len(positionData) != PositionSize {
+		t.Fatalf("PositionSize = %d, encoded account is %d bytes", PositionSize, len(positionData))
+	}
+	assertMatches(t, "Position.Pool", positionData, Position.Pool(pool))
+	assertMatches(t, "Position.NftMint", positionData, Position.NftMint(nftMint))
+
+	vestingData := encode(t, &cp_amm.VestingAccount{Position: position, NumberOfPeriod: 12})
+	if len(vestingData) != VestingSize {
+		t.Fatalf("VestingSize = %d, encoded account is %d bytes", VestingSize, len(vestingData))
+	}
+	assertMatches(t, "Vesting.Position", vestingData, Vesting.Position(position))
+	assertMatches(t, "Vesting.NumberOfPeriod", vestingData, Vesting.NumberOfPeriod(12))
+}
+
+func TestAccountSizes(t *testing.T) {
+	for name, tc := range map[string]struct {
+		size    int
+		account interface {
+			MarshalWithEncoder(*ag_binary.Encoder) error
+		}
+	}{
+		"Config":           {ConfigSize, &cp_amm.ConfigAccount{}},
+		"TokenBadge":       {TokenBadgeSize, &cp_amm.TokenBadgeAccount{}},
+		"ClaimFeeOperator": {ClaimFeeOperatorSize, &cp_amm.ClaimFeeOperatorAccount{}},
+	} {
+		if got := len(encode(t, tc.account)); got != tc.size {
+			t.Errorf("%sSize = %d, encoded account is %d bytes", name, tc.size, got)
+		}
+	}
+}
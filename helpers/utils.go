@@ -55,6 +55,13 @@ func MustBigIntToUint128(b *big.Int) ag_binary.Uint128 {
 	return v
 }
 
+// U256ToBigInt decodes the little endian u256 b, e.g. the fee per liquidity of a pool.
+func U256ToBigInt(b [32]uint8) *big.Int {
+	be := b
+	ag_binary.ReverseBytes(be[:])
+	return new(big.Int).SetBytes(be[:])
+}
+
 // GetPriceImpact calculates the percentage difference between the current and next sqrt prices.
 // TODO: take a another look.
 func GetPriceImpact(nextSqrtPrice, currentSqrtPrice *big.Int) float64 {
@@ -0,0 +1,217 @@
+// Package indexer writes the events of the pool program into a Store: the history
+// through a backfill, then live events as they come.
+package indexer
+
+import (
+	"context"
+	dammv2gosdk "dammv2GoSDK"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+// Store persists records. Records of slots not finalized yet may belong to a fork the
+// cluster abandons: the indexer finalizes or drops them once their slot is finalized.
+type Store interface {
+	// Put writes records, skipping the ones whose key was written before.
+	Put(ctx context.Context, records ...Record) error
+	// Pending returns the transactions of the records not finalized yet, of slots up to
+	// slot, with the slot they were written with.
+	Pending(ctx context.Context, slot uint64) (map[solana.Signature]uint64, error)
+	// Finalize marks the records of the transactions final, along with the slot they were
+	// finalized in.
+	Finalize(ctx context.Context, slots map[solana.Signature]uint64) error
+	// Drop deletes the records of the transactions, e.g ones of an abandoned fork.
+	Drop(ctx context.Context, signatures ...solana.Signature) error
+	Close() error
+}
+
+// Opts configures an Indexer.
+type Opts struct {
+	// Filter the events indexed go through.
+	Filter dammv2gosdk.EventFilter
+	// Backfill configures the backfills run on start and after every subscription gap,
+	// its Filter is replaced by Filter and, after a gap, its slots by the ones of the gap.
+	// Set Backfill.Checkpoints so that they resume.
+	Backfill dammv2gosdk.BackfillOpts
+	// Subscription configures the live subscription, its backpressure is always blocking.
+	Subscription dammv2gosdk.SubscriptionOpts
+	// FinalizeInterval is the time between finalization rounds, defaults to 15s.
+	FinalizeInterval time.Duration
+	// OnError, when set, is called with every finalization or subscription error.
+	// The indexer keeps running after reporting it.
+	OnError func(error)
+}
+
+// statusWindow is the number of slots behind the finalized one nodes keep the statuses of
+// transactions for, whether they have the transaction history or not.
+const statusWindow = 300
+
+// dropAfter is the number of finalization rounds a transaction of the status window must
+// be unknown in to be dropped: a lagging node may not have seen it yet.
+const dropAfter = 3
+
+// Indexer writes the events of the pool program into a Store.
+type Indexer struct {
+	cp    *dammv2gosdk.CpAMM
+	conn  *rpc.Client
+	store Store
+	opts  Opts
+
+	// mu serializes finalization rounds. unknown counts the rounds pending transactions
+	// had no status in.
+	mu      sync.Mutex
+	unknown map[solana.Signature]int
+}
+
+// New returns an indexer reading through cp, and conn, the client cp was built with.
+func New(cp *dammv2gosdk.CpAMM, conn *rpc.Client, store Store, opts Opts) *Indexer {
+	opts.Backfill.Filter = opts.Filter
+	opts.Subscription.Backpressure = dammv2gosdk.BackpressureBlock
+	if opts.FinalizeInterval <= 0 {
+		opts.FinalizeInterval = 15 * time.Second
+	}
+	return &Indexer{cp: cp, conn: conn, store: store, opts: opts, unknown: make(map[solana.Signature]int)}
+}
+
+// Run indexes live events until ctx is done. The subscription is opened first and the
+// history backfilled, so that no event falls in between, and the slots of every
+// subscription gap are backfilled again. Inserts are idempotent, events seen by both are
+// only written once.
+//
+// Run returns the first backfill or store error.
+func (ix *Indexer) Run(ctx context.Context) error {
+	subOpts := ix.opts.Subscription
+	if ix.opts.OnError != nil {
+		subOpts.OnError = ix.opts.OnError
+	}
+	events, err := ix.cp.SubscribeEvents(ctx, ix.opts.Filter, subOpts)
+	if err != nil {
+		return err
+	}
+
+	if err := ix.Backfill(ctx); err != nil {
+		return err
+	}
+
+	ticker := time.NewTicker(ix.opts.FinalizeInterval)
+	defer ticker.Stop()
+
+	liveFinalized := subOpts.Commitment == rpc.CommitmentFinalized
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			if err := ix.Finalize(ctx); err != nil && ix.opts.OnError != nil && ctx.Err() == nil {
+				ix.opts.OnError(err)
+			}
+		case update, ok := <-events:
+			if !ok {
+				return ctx.Err()
+			}
+			if update.Gap != nil {
+				opts := ix.opts.Backfill
+				opts.FromSlot, opts.ToSlot = update.Gap.FromSlot, update.Gap.ToSlot
+				if err := ix.backfill(ctx, opts); err != nil {
+					return fmt.Errorf("err backfilling slots %d to %d: %w", update.Gap.FromSlot, update.Gap.ToSlot, err)
+				}
+				continue
+			}
+			if err := ix.put(ctx, update, liveFinalized); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// Backfill writes the events of the history of Opts.Backfill.Address not indexed yet.
+func (ix *Indexer) Backfill(ctx context.Context) error {
+	return ix.backfill(ctx, ix.opts.Backfill)
+}
+
+func (ix *Indexer) backfill(ctx context.Context, opts dammv2gosdk.BackfillOpts) error {
+	finalized := opts.Commitment == rpc.CommitmentFinalized
+	return ix.cp.Backfill(ctx, opts, func(update dammv2gosdk.EventUpdate) error {
+		return ix.put(ctx, update, finalized || update.Finalized)
+	})
+}
+
+// Finalize settles the records of finalized slots: the ones of finalized transactions are
+// marked final, the failed ones dropped. Transactions without status are unknown to the
+// node: the ones of the last slots, which every node knows of, are dropped as part of an
+// abandoned fork once unknown for a few rounds, the older ones, e.g of a node without
+// the transaction history, kept pending.
+func (ix *Indexer) Finalize(ctx context.Context) error {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+
+	slot, err := ix.conn.GetSlot(ctx, rpc.CommitmentFinalized)
+	if err != nil {
+		return fmt.Errorf("err fetching the finalized slot: %w", err)
+	}
+	pending, err := ix.store.Pending(ctx, slot)
+	if err != nil {
+		return fmt.Errorf("err listing pending records: %w", err)
+	}
+
+	signatures := make([]solana.Signature, 0, len(pending))
+	for sig := range pending {
+		signatures = append(signatures, sig)
+	}
+
+	// getSignatureStatuses takes up to 256 signatures.
+	const chunk = 256
+	var (
+		finalized = make(map[solana.Signature]uint64)
+		dropped   []solana.Signature
+		unknown   = make(map[solana.Signature]int)
+	)
+	for start := 0; start < len(signatures); start += chunk {
+		batch := signatures[start:min(start+chunk, len(signatures))]
+		statuses, err := ix.conn.GetSignatureStatuses(ctx, true, batch...)
+		if err != nil {
+			return fmt.Errorf("err fetching signature statuses: %w", err)
+		}
+		for i, status := range statuses.Value {
+			sig := batch[i]
+			switch {
+			case status == nil:
+				if pending[sig]+statusWindow < slot {
+					continue
+				}
+				if unknown[sig] = ix.unknown[sig] + 1; unknown[sig] >= dropAfter {
+					delete(unknown, sig)
+					dropped = append(dropped, sig)
+				}
+			case status.Err != nil:
+				dropped = append(dropped, sig)
+			case status.ConfirmationStatus == rpc.ConfirmationStatusFinalized:
+				finalized[sig] = status.Slot
+			}
+		}
+	}
+
+	if err := ix.store.Drop(ctx, dropped...); err != nil {
+		return fmt.Errorf("err dropping records: %w", err)
+	}
+	if err := ix.store.Finalize(ctx, finalized); err != nil {
+		return fmt.Errorf("err finalizing records: %w", err)
+	}
+	ix.unknown = unknown
+	return nil
+}
+
+func (ix *Indexer) put(ctx context.Context, update dammv2gosdk.EventUpdate, finalized bool) error {
+	rec, err := NewRecord(update, finalized)
+	if err != nil {
+		return err
+	}
+	if err := ix.store.Put(ctx, rec); err != nil {
+		return fmt.Errorf("err storing %s: %w", update.Signature, err)
+	}
+	return nil
+}
@@ -0,0 +1,234 @@
+package indexer
+
+import (
+	"context"
+	dammv2gosdk "dammv2GoSDK"
+	cp_amm "dammv2GoSDK/generated/cpAmm"
+	"encoding/json"
+	"errors"
+	"math"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	ag_binary "github.com/gagliardetto/binary"
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/gagliardetto/solana-go/rpc/jsonrpc"
+)
+
+func swapRecord(t *testing.T, sig solana.Signature, slot uint64) Record {
+	t.Helper()
+	rec, err := NewRecord(dammv2gosdk.EventUpdate{
+		Signature: sig,
+		Slot:      slot,
+		Event: &cp_amm.Event{Name: "EvtSwap", Data: &cp_amm.EvtSwapEventData{
+			Pool:           solana.NewWallet().PublicKey(),
+			ActualAmountIn: 1_000,
+			SwapResult:     cp_amm.SwapResult{OutputAmount: 990, LpFee: 10},
+		}},
+	}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return rec
+}
+
+func TestNewRecord(t *testing.T) {
+	rec, err := NewRecord(dammv2gosdk.EventUpdate{
+		Signature: solana.Signature{1},
+		Index:     2,
+		Event: &cp_amm.Event{Name: "EvtLockPosition", Data: &cp_amm.EvtLockPositionEventData{
+			CliffUnlockLiquidity: ag_binary.Uint128{Lo: 100},
+			LiquidityPerPeriod:   ag_binary.Uint128{Lo: 10},
+			NumberOfPeriod:       5,
+		}},
+	}, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rec.Index != 2 || !rec.Finalized || rec.Lock == nil || rec.Lock.Action != LockVesting || rec.Lock.Liquidity.Lo != 150 {
+		t.Fatalf("record = %+v lock = %+v", rec, rec.Lock)
+	}
+
+	if _, err := NewRecord(dammv2gosdk.EventUpdate{Gap: &dammv2gosdk.SlotGap{}}, false); err == nil {
+		t.Fatal("gap notices carry no event")
+	}
+}
+
+func TestStores(t *testing.T) {
+	open := map[string]func(path string) (Store, error){
+		"sqlite": func(path string) (Store, error) { return OpenSQLite(path) },
+		"jsonl":  func(path string) (Store, error) { return OpenJSONL(path) },
+	}
+
+	for name, open := range open {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			path := filepath.Join(t.TempDir(), "events."+name)
+			store, err := open(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			final, forked := swapRecord(t, solana.Signature{1}, 10), swapRecord(t, solana.Signature{2}, 11)
+			for range 2 {
+				if err := store.Put(ctx, final, forked); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			pending, err := store.Pending(ctx, 10)
+			if err != nil || len(pending) != 1 || pending[final.Signature] != 10 {
+				t.Fatalf("pending = %v err = %v", pending, err)
+			}
+			if err := store.Finalize(ctx, map[solana.Signature]uint64{final.Signature: 12}); err != nil {
+				t.Fatal(err)
+			}
+			if err := store.Drop(ctx, forked.Signature); err != nil {
+				t.Fatal(err)
+			}
+			if err := store.Close(); err != nil {
+				t.Fatal(err)
+			}
+
+			// the state survives reopening, dropped records can be written again.
+			store, err = open(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer store.Close()
+			if pending, err := store.Pending(ctx, 100); err != nil || len(pending) != 0 {
+				t.Fatalf("pending = %v err = %v", pending, err)
+			}
+			if err := store.Put(ctx, final, forked); err != nil {
+				t.Fatal(err)
+			}
+			if pending, err := store.Pending(ctx, 100); err != nil || len(pending) != 1 || pending[forked.Signature] != 11 {
+				t.Fatalf("pending = %v err = %v", pending, err)
+			}
+
+			if sqlite, ok := store.(*SQLiteStore); ok {
+				var swaps, slot int
+				err := sqlite.DB().QueryRow(
+					`SELECT COUNT(*), MIN(slot) FROM swaps WHERE amount_in = '1000' AND amount_out = '990'`,
+				).Scan(&swaps, &slot)
+				if err != nil || swaps != 2 || slot != 11 {
+					t.Fatalf("swaps = %d slot = %d err = %v", swaps, slot, err)
+				}
+			}
+		})
+	}
+}
+
+// fakeStatuses answers getSlot and getSignatureStatuses.
+type fakeStatuses struct {
+	slot     uint64
+	statuses map[solana.Signature]any
+}
+
+func (f *fakeStatuses) CallForInto(_ context.Context, out any, method string, params []any) error {
+	var res any
+	switch method {
+	case "getSlot":
+		res = f.slot
+	case "getSignatureStatuses":
+		var values []any
+		for _, sig := range params[0].([]solana.Signature) {
+			values = append(values, f.statuses[sig])
+		}
+		res = map[string]any{"context": map[string]any{"slot": f.slot}, "value": values}
+	default:
+		return errors.New("unexpected method " + method)
+	}
+
+	raw, err := json.Marshal(res)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(raw, out)
+}
+
+func (f *fakeStatuses) CallWithCallback(context.Context, string, []any, func(*http.Request, *http.Response) error) error {
+	return errors.New("not implemented")
+}
+
+func (f *fakeStatuses) CallBatch(context.Context, jsonrpc.RPCRequests) (jsonrpc.RPCResponses, error) {
+	return nil, errors.New("not implemented")
+}
+
+func TestIndexerFinalize(t *testing.T) {
+	ctx := context.Background()
+	store, err := OpenJSONL(filepath.Join(t.TempDir(), "events.jsonl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+
+	// history is a finalized record the node, without the transaction history, has no
+	// status of. forked, unknown to the node as well, is recent.
+	var (
+		history = swapRecord(t, solana.Signature{5}, 10)
+		final   = swapRecord(t, solana.Signature{1}, 1_000)
+		forked  = swapRecord(t, solana.Signature{2}, 1_001)
+		lagging = swapRecord(t, solana.Signature{3}, 1_002)
+		recent  = swapRecord(t, solana.Signature{4}, 1_030)
+	)
+	if err := store.Put(ctx, history, final, forked, lagging, recent); err != nil {
+		t.Fatal(err)
+	}
+
+	conn := rpc.NewWithCustomRPCClient(&fakeStatuses{
+		slot: 1_020,
+		statuses: map[solana.Signature]any{
+			final.Signature:   map[string]any{"slot": 1_003, "confirmationStatus": "finalized"},
+			lagging.Signature: map[string]any{"slot": 1_002, "confirmationStatus": "confirmed"},
+		},
+	})
+	ix := New(dammv2gosdk.NewCpAMM(conn), conn, store, Opts{})
+	for round := 1; round <= dropAfter; round++ {
+		if err := ix.Finalize(ctx); err != nil {
+			t.Fatal(err)
+		}
+		// a lagging node may not have seen the forked transaction yet.
+		if dropped := !store.written[forked.Key]; dropped != (round == dropAfter) {
+			t.Fatalf("round %d: forked record dropped %v", round, dropped)
+		}
+	}
+
+	pending, err := store.Pending(ctx, 2_000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pending) != 3 || pending[history.Signature] != 10 || pending[lagging.Signature] != 1_002 ||
+		pending[recent.Signature] != 1_030 {
+		t.Fatalf("pending = %v", pending)
+	}
+	if !store.written[final.Key] || !store.written[history.Key] {
+		t.Fatal("the finalized and history records are kept")
+	}
+}
+
+func TestSQLiteAmountsPastInt64(t *testing.T) {
+	ctx := context.Background()
+	store, err := OpenSQLite(filepath.Join(t.TempDir(), "events.sqlite"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+
+	rec := swapRecord(t, solana.Signature{1}, 10)
+	rec.Swap.AmountIn = math.MaxUint64
+	if err := store.Put(ctx, rec); err != nil {
+		t.Fatal(err)
+	}
+
+	var amountIn string
+	if err := store.DB().QueryRow(`SELECT amount_in FROM swaps`).Scan(&amountIn); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := strconv.ParseUint(amountIn, 10, 64); err != nil || got != math.MaxUint64 {
+		t.Fatalf("amount_in = %s, want %d", amountIn, uint64(math.MaxUint64))
+	}
+}
@@ -0,0 +1,188 @@
+package indexer
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/gagliardetto/solana-go"
+)
+
+// jsonlLine is a line of a JSONL store: a record put, or the finalization or drop of
+// the records of a transaction.
+type jsonlLine struct {
+	Op        string            `json:"op"`
+	Record    *Record           `json:"record,omitempty"`
+	Signature *solana.Signature `json:"signature,omitempty"`
+	Slot      uint64            `json:"slot,omitempty"`
+}
+
+const (
+	jsonlPut      = "put"
+	jsonlFinalize = "finalize"
+	jsonlDrop     = "drop"
+)
+
+// JSONLStore is a Store appending to a file one JSON line per operation:
+//
+//	{"op":"put","record":{...}}
+//	{"op":"finalize","signature":"...","slot":42}
+//	{"op":"drop","signature":"..."}
+//
+// Readers replay the lines in order, a finalize or drop applies to every record of the
+// transaction put before it. The file is replayed on open to keep inserts idempotent.
+//
+// It is safe for concurrent use by multiple goroutines.
+type JSONLStore struct {
+	mu      sync.Mutex
+	file    *os.File
+	w       *bufio.Writer
+	written map[Key]bool
+	// pending are the transactions with records not finalized yet, and their slot.
+	pending map[solana.Signature]uint64
+}
+
+// OpenJSONL opens, creating it when needed, the file at path.
+func OpenJSONL(path string) (*JSONLStore, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("err opening %s: %w", path, err)
+	}
+
+	s := &JSONLStore{
+		file:    file,
+		w:       bufio.NewWriter(file),
+		written: make(map[Key]bool),
+		pending: make(map[solana.Signature]uint64),
+	}
+	if err := s.replay(file); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("err replaying %s: %w", path, err)
+	}
+	return s, nil
+}
+
+func (s *JSONLStore) replay(r io.Reader) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 16<<20)
+	for n := 1; scanner.Scan(); n++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var line jsonlLine
+		if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+			return fmt.Errorf("line %d: %w", n, err)
+		}
+		s.apply(line)
+	}
+	return scanner.Err()
+}
+
+func (s *JSONLStore) apply(line jsonlLine) {
+	switch {
+	case line.Op == jsonlPut && line.Record != nil:
+		s.written[line.Record.Key] = true
+		if !line.Record.Finalized {
+			s.pending[line.Record.Signature] = line.Record.Slot
+		}
+	case line.Op == jsonlFinalize && line.Signature != nil:
+		delete(s.pending, *line.Signature)
+	case line.Op == jsonlDrop && line.Signature != nil:
+		delete(s.pending, *line.Signature)
+		for key := range s.written {
+			if key.Signature == *line.Signature {
+				delete(s.written, key)
+			}
+		}
+	}
+}
+
+// write appends lines and applies them once they are flushed.
+func (s *JSONLStore) write(lines ...jsonlLine) error {
+	if s.file == nil {
+		return errors.New("store is closed")
+	}
+	for _, line := range lines {
+		raw, err := json.Marshal(line)
+		if err != nil {
+			return err
+		}
+		s.w.Write(raw)
+		s.w.WriteByte('\n')
+	}
+	if err := s.w.Flush(); err != nil {
+		return fmt.Errorf("err writing: %w", err)
+	}
+	for _, line := range lines {
+		s.apply(line)
+	}
+	return nil
+}
+
+func (s *JSONLStore) Put(_ context.Context, records ...Record) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	lines := make([]jsonlLine, 0, len(records))
+	seen := make(map[Key]bool, len(records))
+	for i := range records {
+		if s.written[records[i].Key] || seen[records[i].Key] {
+			continue
+		}
+		seen[records[i].Key] = true
+		lines = append(lines, jsonlLine{Op: jsonlPut, Record: &records[i]})
+	}
+	return s.write(lines...)
+}
+
+func (s *JSONLStore) Pending(_ context.Context, slot uint64) (map[solana.Signature]uint64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	res := make(map[solana.Signature]uint64)
+	for sig, pendingSlot := range s.pending {
+		if pendingSlot <= slot {
+			res[sig] = pendingSlot
+		}
+	}
+	return res, nil
+}
+
+func (s *JSONLStore) Finalize(_ context.Context, slots map[solana.Signature]uint64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	lines := make([]jsonlLine, 0, len(slots))
+	for sig, slot := range slots {
+		lines = append(lines, jsonlLine{Op: jsonlFinalize, Signature: &sig, Slot: slot})
+	}
+	return s.write(lines...)
+}
+
+func (s *JSONLStore) Drop(_ context.Context, signatures ...solana.Signature) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	lines := make([]jsonlLine, 0, len(signatures))
+	for _, sig := range signatures {
+		lines = append(lines, jsonlLine{Op: jsonlDrop, Signature: &sig})
+	}
+	return s.write(lines...)
+}
+
+func (s *JSONLStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.file == nil {
+		return nil
+	}
+	err := errors.Join(s.w.Flush(), s.file.Close())
+	s.file = nil
+	return err
+}
@@ -0,0 +1,56 @@
+package indexer
+
+import (
+	"context"
+	"errors"
+
+	"github.com/gagliardetto/solana-go"
+)
+
+// multiStore writes into every store, pending records are read from the first one.
+type multiStore []Store
+
+// Multi returns a Store writing into primary and others, e.g a database along with an
+// in-memory aggregate. Pending records are read from primary.
+func Multi(primary Store, others ...Store) Store {
+	return append(multiStore{primary}, others...)
+}
+
+func (m multiStore) Put(ctx context.Context, records ...Record) error {
+	for _, store := range m {
+		if err := store.Put(ctx, records...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m multiStore) Pending(ctx context.Context, slot uint64) (map[solana.Signature]uint64, error) {
+	return m[0].Pending(ctx, slot)
+}
+
+func (m multiStore) Finalize(ctx context.Context, slots map[solana.Signature]uint64) error {
+	for _, store := range m {
+		if err := store.Finalize(ctx, slots); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m multiStore) Drop(ctx context.Context, signatures ...solana.Signature) error {
+	for _, store := range m {
+		if err := store.Drop(ctx, signatures...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m multiStore) Close() error {
+	var errs []error
+	for _, store := range m {
+		errs = append(errs, store.Close())
+	}
+	return errors.Join(errs...)
+}
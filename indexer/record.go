@@ -0,0 +1,320 @@
+package indexer
+
+import (
+	dammv2gosdk "dammv2GoSDK"
+	cp_amm "dammv2GoSDK/generated/cpAmm"
+	"dammv2GoSDK/helpers"
+	"dammv2GoSDK/types"
+	"encoding/json"
+	"fmt"
+	"math/big"
+
+	ag_binary "github.com/gagliardetto/binary"
+	"github.com/gagliardetto/solana-go"
+)
+
+// Key identifies an event: the transaction it was emitted in and its index among the
+// events of that transaction.
+type Key struct {
+	Signature solana.Signature `json:"signature"`
+	Index     int              `json:"index"`
+}
+
+// Record is an event normalized for storage. Events with no table of their own, e.g
+// EvtSetPoolStatus or EvtCreatePosition, only carry Data.
+type Record struct {
+	Key
+	Slot      uint64           `json:"slot"`
+	Finalized bool             `json:"finalized"`
+	Name      string           `json:"name"`
+	Pool      solana.PublicKey `json:"pool"`
+	// Data is the JSON encoded event data.
+	Data json.RawMessage `json:"data"`
+
+	Swap         *Swap            `json:"swap,omitempty"`
+	Liquidity    *LiquidityChange `json:"liquidity,omitempty"`
+	FeeClaim     *FeeClaim        `json:"feeClaim,omitempty"`
+	Lock         *Lock            `json:"lock,omitempty"`
+	Split        *Split           `json:"split,omitempty"`
+	Reward       *Reward          `json:"reward,omitempty"`
+	PoolCreation *PoolCreation    `json:"poolCreation,omitempty"`
+}
+
+type Swap struct {
+	Direction     types.TradeDirection `json:"direction"`
+	AmountIn      uint64               `json:"amountIn"`
+	AmountOut     uint64               `json:"amountOut"`
+	LpFee         uint64               `json:"lpFee"`
+	ProtocolFee   uint64               `json:"protocolFee"`
+	PartnerFee    uint64               `json:"partnerFee"`
+	ReferralFee   uint64               `json:"referralFee"`
+	HasReferral   bool                 `json:"hasReferral"`
+	NextSqrtPrice ag_binary.Uint128    `json:"nextSqrtPrice"`
+	Timestamp     uint64               `json:"timestamp"`
+}
+
+// Liquidity change actions.
+const (
+	LiquidityAdd    = "add"
+	LiquidityRemove = "remove"
+)
+
+type LiquidityChange struct {
+	Action         string            `json:"action"`
+	Position       solana.PublicKey  `json:"position"`
+	Owner          solana.PublicKey  `json:"owner"`
+	LiquidityDelta ag_binary.Uint128 `json:"liquidityDelta"`
+	AmountA        uint64            `json:"amountA"`
+	AmountB        uint64            `json:"amountB"`
+}
+
+// Fee claim actions.
+const (
+	FeeClaimPosition = "position"
+	FeeClaimPartner  = "partner"
+	FeeClaimProtocol = "protocol"
+)
+
+type FeeClaim struct {
+	Action string `json:"action"`
+	// Position and Owner are only set for position fee claims.
+	Position solana.PublicKey `json:"position"`
+	Owner    solana.PublicKey `json:"owner"`
+	AmountA  uint64           `json:"amountA"`
+	AmountB  uint64           `json:"amountB"`
+}
+
+// Lock actions.
+const (
+	LockVesting   = "vesting"
+	LockPermanent = "permanent"
+)
+
+type Lock struct {
+	Action   string           `json:"action"`
+	Position solana.PublicKey `json:"position"`
+	// Owner and Vesting are only set for vesting locks.
+	Owner   solana.PublicKey `json:"owner"`
+	Vesting solana.PublicKey `json:"vesting"`
+	// Liquidity is the liquidity locked, in total over every period for vesting locks.
+	Liquidity       ag_binary.Uint128 `json:"liquidity"`
+	CliffPoint      uint64            `json:"cliffPoint"`
+	PeriodFrequency uint64            `json:"periodFrequency"`
+	NumberOfPeriod  uint16            `json:"numberOfPeriod"`
+}
+
+type Split struct {
+	FirstPosition            solana.PublicKey  `json:"firstPosition"`
+	SecondPosition           solana.PublicKey  `json:"secondPosition"`
+	FirstOwner               solana.PublicKey  `json:"firstOwner"`
+	SecondOwner              solana.PublicKey  `json:"secondOwner"`
+	UnlockedLiquidity        ag_binary.Uint128 `json:"unlockedLiquidity"`
+	PermanentLockedLiquidity ag_binary.Uint128 `json:"permanentLockedLiquidity"`
+	FeeA                     uint64            `json:"feeA"`
+	FeeB                     uint64            `json:"feeB"`
+	Reward0                  uint64            `json:"reward0"`
+	Reward1                  uint64            `json:"reward1"`
+}
+
+// Reward actions.
+const (
+	RewardInitialize         = "initialize"
+	RewardFund               = "fund"
+	RewardClaim              = "claim"
+	RewardWithdrawIneligible = "withdraw_ineligible"
+	RewardUpdateDuration     = "update_duration"
+	RewardUpdateFunder       = "update_funder"
+)
+
+type Reward struct {
+	Action string `json:"action"`
+	// RewardIndex is unknown, and left 0, for ineligible reward withdrawals.
+	RewardIndex uint8            `json:"rewardIndex"`
+	Mint        solana.PublicKey `json:"mint"`
+	// Account is the funder, or the owner of Position for claims.
+	Account  solana.PublicKey `json:"account"`
+	Position solana.PublicKey `json:"position"`
+	Amount   uint64           `json:"amount"`
+	// Duration is the reward duration of initializations and duration updates.
+	Duration uint64 `json:"duration"`
+}
+
+type PoolCreation struct {
+	TokenAMint      solana.PublicKey  `json:"tokenAMint"`
+	TokenBMint      solana.PublicKey  `json:"tokenBMint"`
+	Creator         solana.PublicKey  `json:"creator"`
+	Payer           solana.PublicKey  `json:"payer"`
+	Liquidity       ag_binary.Uint128 `json:"liquidity"`
+	SqrtPrice       ag_binary.Uint128 `json:"sqrtPrice"`
+	ActivationPoint uint64            `json:"activationPoint"`
+	ActivationType  uint8             `json:"activationType"`
+	CollectFeeMode  uint8             `json:"collectFeeMode"`
+	PoolType        uint8             `json:"poolType"`
+	AmountA         uint64            `json:"amountA"`
+	AmountB         uint64            `json:"amountB"`
+}
+
+// NewRecord normalizes update, which must carry an event.
+func NewRecord(update dammv2gosdk.EventUpdate, finalized bool) (Record, error) {
+	if update.Event == nil {
+		return Record{}, fmt.Errorf("update of %s carries no event", update.Signature)
+	}
+
+	data, err := json.Marshal(update.Event.Data)
+	if err != nil {
+		return Record{}, fmt.Errorf("err encoding %s: %w", update.Event.Name, err)
+	}
+	rec := Record{
+		Key:       Key{Signature: update.Signature, Index: update.Index},
+		Slot:      update.Slot,
+		Finalized: finalized,
+		Name:      update.Event.Name,
+		Data:      data,
+	}
+
+	switch evt := update.Event.Data.(type) {
+	case *cp_amm.EvtSwapEventData:
+		rec.Pool = evt.Pool
+		rec.Swap = &Swap{
+			Direction:     types.TradeDirection(evt.TradeDirection),
+			AmountIn:      evt.ActualAmountIn,
+			AmountOut:     evt.SwapResult.OutputAmount,
+			LpFee:         evt.SwapResult.LpFee,
+			ProtocolFee:   evt.SwapResult.ProtocolFee,
+			PartnerFee:    evt.SwapResult.PartnerFee,
+			ReferralFee:   evt.SwapResult.ReferralFee,
+			HasReferral:   evt.HasReferral,
+			NextSqrtPrice: evt.SwapResult.NextSqrtPrice,
+			Timestamp:     evt.CurrentTimestamp,
+		}
+	case *cp_amm.EvtAddLiquidityEventData:
+		rec.Pool = evt.Pool
+		rec.Liquidity = &LiquidityChange{
+			Action:         LiquidityAdd,
+			Position:       evt.Position,
+			Owner:          evt.Owner,
+			LiquidityDelta: evt.Params.LiquidityDelta,
+			AmountA:        evt.TokenAAmount,
+			AmountB:        evt.TokenBAmount,
+		}
+	case *cp_amm.EvtRemoveLiquidityEventData:
+		rec.Pool = evt.Pool
+		rec.Liquidity = &LiquidityChange{
+			Action:         LiquidityRemove,
+			Position:       evt.Position,
+			Owner:          evt.Owner,
+			LiquidityDelta: evt.Params.LiquidityDelta,
+			AmountA:        evt.TokenAAmount,
+			AmountB:        evt.TokenBAmount,
+		}
+	case *cp_amm.EvtClaimPositionFeeEventData:
+		rec.Pool = evt.Pool
+		rec.FeeClaim = &FeeClaim{
+			Action:   FeeClaimPosition,
+			Position: evt.Position,
+			Owner:    evt.Owner,
+			AmountA:  evt.FeeAClaimed,
+			AmountB:  evt.FeeBClaimed,
+		}
+	case *cp_amm.EvtClaimPartnerFeeEventData:
+		rec.Pool = evt.Pool
+		rec.FeeClaim = &FeeClaim{Action: FeeClaimPartner, AmountA: evt.TokenAAmount, AmountB: evt.TokenBAmount}
+	case *cp_amm.EvtClaimProtocolFeeEventData:
+		rec.Pool = evt.Pool
+		rec.FeeClaim = &FeeClaim{Action: FeeClaimProtocol, AmountA: evt.TokenAAmount, AmountB: evt.TokenBAmount}
+	case *cp_amm.EvtLockPositionEventData:
+		rec.Pool = evt.Pool
+		locked := new(big.Int).Mul(evt.LiquidityPerPeriod.BigInt(), big.NewInt(int64(evt.NumberOfPeriod)))
+		lockedLiquidity, err := helpers.BigIntToUint128(locked.Add(locked, evt.CliffUnlockLiquidity.BigInt()))
+		if err != nil {
+			return Record{}, fmt.Errorf("err computing the liquidity locked by %s: %w", update.Signature, err)
+		}
+		rec.Lock = &Lock{
+			Action:          LockVesting,
+			Position:        evt.Position,
+			Owner:           evt.Owner,
+			Vesting:         evt.Vesting,
+			Liquidity:       lockedLiquidity,
+			CliffPoint:      evt.CliffPoint,
+			PeriodFrequency: evt.PeriodFrequency,
+			NumberOfPeriod:  evt.NumberOfPeriod,
+		}
+	case *cp_amm.EvtPermanentLockPositionEventData:
+		rec.Pool = evt.Pool
+		rec.Lock = &Lock{Action: LockPermanent, Position: evt.Position, Liquidity: evt.LockLiquidityAmount}
+	case *cp_amm.EvtSplitPositionEventData:
+		rec.Pool = evt.Pool
+		rec.Split = &Split{
+			FirstPosition:            evt.FirstPosition,
+			SecondPosition:           evt.SecondPosition,
+			FirstOwner:               evt.FirstOwner,
+			SecondOwner:              evt.SecondOwner,
+			UnlockedLiquidity:        evt.AmountSplits.UnlockedLiquidity,
+			PermanentLockedLiquidity: evt.AmountSplits.PermanentLockedLiquidity,
+			FeeA:                     evt.AmountSplits.FeeA,
+			FeeB:                     evt.AmountSplits.FeeB,
+			Reward0:                  evt.AmountSplits.Reward0,
+			Reward1:                  evt.AmountSplits.Reward1,
+		}
+	case *cp_amm.EvtInitializeRewardEventData:
+		rec.Pool = evt.Pool
+		rec.Reward = &Reward{
+			Action:      RewardInitialize,
+			RewardIndex: evt.RewardIndex,
+			Mint:        evt.RewardMint,
+			Account:     evt.Funder,
+			Duration:    evt.RewardDuration,
+		}
+	case *cp_amm.EvtFundRewardEventData:
+		rec.Pool = evt.Pool
+		rec.Reward = &Reward{
+			Action:      RewardFund,
+			RewardIndex: evt.RewardIndex,
+			Mint:        evt.MintReward,
+			Account:     evt.Funder,
+			Amount:      evt.TransferFeeExcludedAmountIn,
+		}
+	case *cp_amm.EvtClaimRewardEventData:
+		rec.Pool = evt.Pool
+		rec.Reward = &Reward{
+			Action:      RewardClaim,
+			RewardIndex: evt.RewardIndex,
+			Mint:        evt.MintReward,
+			Account:     evt.Owner,
+			Position:    evt.Position,
+			Amount:      evt.TotalReward,
+		}
+	case *cp_amm.EvtWithdrawIneligibleRewardEventData:
+		rec.Pool = evt.Pool
+		rec.Reward = &Reward{Action: RewardWithdrawIneligible, Mint: evt.RewardMint, Amount: evt.Amount}
+	case *cp_amm.EvtUpdateRewardDurationEventData:
+		rec.Pool = evt.Pool
+		rec.Reward = &Reward{Action: RewardUpdateDuration, RewardIndex: evt.RewardIndex, Duration: evt.NewRewardDuration}
+	case *cp_amm.EvtUpdateRewardFunderEventData:
+		rec.Pool = evt.Pool
+		rec.Reward = &Reward{Action: RewardUpdateFunder, RewardIndex: evt.RewardIndex, Account: evt.NewFunder}
+	case *cp_amm.EvtInitializePoolEventData:
+		rec.Pool = evt.Pool
+		rec.PoolCreation = &PoolCreation{
+			TokenAMint:      evt.TokenAMint,
+			TokenBMint:      evt.TokenBMint,
+			Creator:         evt.Creator,
+			Payer:           evt.Payer,
+			Liquidity:       evt.Liquidity,
+			SqrtPrice:       evt.SqrtPrice,
+			ActivationPoint: evt.ActivationPoint,
+			ActivationType:  evt.ActivationType,
+			CollectFeeMode:  evt.CollectFeeMode,
+			PoolType:        evt.PoolType,
+			AmountA:         evt.TokenAAmount,
+			AmountB:         evt.TokenBAmount,
+		}
+	case *cp_amm.EvtCreatePositionEventData:
+		rec.Pool = evt.Pool
+	case *cp_amm.EvtClosePositionEventData:
+		rec.Pool = evt.Pool
+	case *cp_amm.EvtSetPoolStatusEventData:
+		rec.Pool = evt.Pool
+	}
+	return rec, nil
+}
@@ -0,0 +1,348 @@
+package indexer
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+
+	"github.com/gagliardetto/solana-go"
+	_ "modernc.org/sqlite"
+)
+
+// sqliteSchema normalizes records into a table per kind of event, keyed like events,
+// which holds every record. Token amounts and u128 values are stored as decimal text,
+// they don't all fit the signed 64-bit integers of SQLite.
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS events (
+	signature   TEXT    NOT NULL,
+	event_index INTEGER NOT NULL,
+	slot        INTEGER NOT NULL,
+	finalized   INTEGER NOT NULL,
+	name        TEXT    NOT NULL,
+	pool        TEXT    NOT NULL,
+	data        TEXT    NOT NULL,
+	PRIMARY KEY (signature, event_index)
+);
+CREATE INDEX IF NOT EXISTS events_pool_slot ON events (pool, slot);
+CREATE INDEX IF NOT EXISTS events_pending ON events (finalized, slot);
+
+CREATE TABLE IF NOT EXISTS swaps (
+	signature       TEXT    NOT NULL,
+	event_index     INTEGER NOT NULL,
+	slot            INTEGER NOT NULL,
+	pool            TEXT    NOT NULL,
+	direction       INTEGER NOT NULL,
+	amount_in       TEXT    NOT NULL,
+	amount_out      TEXT    NOT NULL,
+	lp_fee          TEXT    NOT NULL,
+	protocol_fee    TEXT    NOT NULL,
+	partner_fee     TEXT    NOT NULL,
+	referral_fee    TEXT    NOT NULL,
+	has_referral    INTEGER NOT NULL,
+	next_sqrt_price TEXT    NOT NULL,
+	timestamp       INTEGER NOT NULL,
+	PRIMARY KEY (signature, event_index)
+);
+CREATE INDEX IF NOT EXISTS swaps_pool_slot ON swaps (pool, slot);
+
+CREATE TABLE IF NOT EXISTS liquidity_changes (
+	signature       TEXT    NOT NULL,
+	event_index     INTEGER NOT NULL,
+	slot            INTEGER NOT NULL,
+	pool            TEXT    NOT NULL,
+	action          TEXT    NOT NULL,
+	position        TEXT    NOT NULL,
+	owner           TEXT    NOT NULL,
+	liquidity_delta TEXT    NOT NULL,
+	amount_a        TEXT    NOT NULL,
+	amount_b        TEXT    NOT NULL,
+	PRIMARY KEY (signature, event_index)
+);
+CREATE INDEX IF NOT EXISTS liquidity_changes_position ON liquidity_changes (position, slot);
+
+CREATE TABLE IF NOT EXISTS fee_claims (
+	signature   TEXT    NOT NULL,
+	event_index INTEGER NOT NULL,
+	slot        INTEGER NOT NULL,
+	pool        TEXT    NOT NULL,
+	action      TEXT    NOT NULL,
+	position    TEXT    NOT NULL,
+	owner       TEXT    NOT NULL,
+	amount_a    TEXT    NOT NULL,
+	amount_b    TEXT    NOT NULL,
+	PRIMARY KEY (signature, event_index)
+);
+
+CREATE TABLE IF NOT EXISTS locks (
+	signature        TEXT    NOT NULL,
+	event_index      INTEGER NOT NULL,
+	slot             INTEGER NOT NULL,
+	pool             TEXT    NOT NULL,
+	action           TEXT    NOT NULL,
+	position         TEXT    NOT NULL,
+	owner            TEXT    NOT NULL,
+	vesting          TEXT    NOT NULL,
+	liquidity        TEXT    NOT NULL,
+	cliff_point      INTEGER NOT NULL,
+	period_frequency INTEGER NOT NULL,
+	number_of_period INTEGER NOT NULL,
+	PRIMARY KEY (signature, event_index)
+);
+
+CREATE TABLE IF NOT EXISTS splits (
+	signature                  TEXT    NOT NULL,
+	event_index                INTEGER NOT NULL,
+	slot                       INTEGER NOT NULL,
+	pool                       TEXT    NOT NULL,
+	first_position             TEXT    NOT NULL,
+	second_position            TEXT    NOT NULL,
+	first_owner                TEXT    NOT NULL,
+	second_owner               TEXT    NOT NULL,
+	unlocked_liquidity         TEXT    NOT NULL,
+	permanent_locked_liquidity TEXT    NOT NULL,
+	fee_a                      TEXT    NOT NULL,
+	fee_b                      TEXT    NOT NULL,
+	reward_0                   TEXT    NOT NULL,
+	reward_1                   TEXT    NOT NULL,
+	PRIMARY KEY (signature, event_index)
+);
+
+CREATE TABLE IF NOT EXISTS rewards (
+	signature    TEXT    NOT NULL,
+	event_index  INTEGER NOT NULL,
+	slot         INTEGER NOT NULL,
+	pool         TEXT    NOT NULL,
+	action       TEXT    NOT NULL,
+	reward_index INTEGER NOT NULL,
+	mint         TEXT    NOT NULL,
+	account      TEXT    NOT NULL,
+	position     TEXT    NOT NULL,
+	amount       TEXT    NOT NULL,
+	duration     INTEGER NOT NULL,
+	PRIMARY KEY (signature, event_index)
+);
+
+CREATE TABLE IF NOT EXISTS pool_creations (
+	signature        TEXT    NOT NULL,
+	event_index      INTEGER NOT NULL,
+	slot             INTEGER NOT NULL,
+	pool             TEXT    NOT NULL,
+	token_a_mint     TEXT    NOT NULL,
+	token_b_mint     TEXT    NOT NULL,
+	creator          TEXT    NOT NULL,
+	payer            TEXT    NOT NULL,
+	liquidity        TEXT    NOT NULL,
+	sqrt_price       TEXT    NOT NULL,
+	activation_point INTEGER NOT NULL,
+	activation_type  INTEGER NOT NULL,
+	collect_fee_mode INTEGER NOT NULL,
+	pool_type        INTEGER NOT NULL,
+	amount_a         TEXT    NOT NULL,
+	amount_b         TEXT    NOT NULL,
+	PRIMARY KEY (signature, event_index)
+);
+`
+
+// sqliteTables are the tables holding records, events last.
+var sqliteTables = []string{"swaps", "liquidity_changes", "fee_claims", "locks", "splits", "rewards", "pool_creations", "events"}
+
+// SQLiteStore is a Store backed by an embedded SQLite database.
+type SQLiteStore struct {
+	db *sql.DB
+}
+
+// OpenSQLite opens, creating it when needed, the database at path.
+func OpenSQLite(path string) (*SQLiteStore, error) {
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, fmt.Errorf("err opening %s: %w", path, err)
+	}
+	// a single connection serializes writers, which SQLite does anyway.
+	db.SetMaxOpenConns(1)
+
+	for _, stmt := range []string{"PRAGMA journal_mode = WAL", "PRAGMA busy_timeout = 5000", sqliteSchema} {
+		if _, err := db.Exec(stmt); err != nil {
+			db.Close()
+			return nil, fmt.Errorf("err initializing %s: %w", path, err)
+		}
+	}
+	return &SQLiteStore{db: db}, nil
+}
+
+// DB returns the underlying database, e.g to query the tables.
+func (s *SQLiteStore) DB() *sql.DB {
+	return s.db
+}
+
+func (s *SQLiteStore) Put(ctx context.Context, records ...Record) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, rec := range records {
+		res, err := tx.ExecContext(ctx,
+			`INSERT OR IGNORE INTO events (signature, event_index, slot, finalized, name, pool, data) VALUES (?, ?, ?, ?, ?, ?, ?)`,
+			rec.Signature.String(), rec.Index, int64(rec.Slot), rec.Finalized, rec.Name, rec.Pool.String(), string(rec.Data),
+		)
+		if err != nil {
+			return fmt.Errorf("err inserting event: %w", err)
+		}
+		if inserted, err := res.RowsAffected(); err != nil || inserted == 0 {
+			continue
+		}
+		if err := insertNormalized(ctx, tx, rec); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func insertNormalized(ctx context.Context, tx *sql.Tx, rec Record) error {
+	var (
+		table  string
+		values []any
+	)
+	switch {
+	case rec.Swap != nil:
+		e := rec.Swap
+		table = "swaps"
+		values = []any{
+			int64(e.Direction), u64Text(e.AmountIn), u64Text(e.AmountOut), u64Text(e.LpFee), u64Text(e.ProtocolFee),
+			u64Text(e.PartnerFee), u64Text(e.ReferralFee), e.HasReferral, e.NextSqrtPrice.String(), int64(e.Timestamp),
+		}
+	case rec.Liquidity != nil:
+		e := rec.Liquidity
+		table = "liquidity_changes"
+		values = []any{e.Action, e.Position.String(), e.Owner.String(), e.LiquidityDelta.String(), u64Text(e.AmountA), u64Text(e.AmountB)}
+	case rec.FeeClaim != nil:
+		e := rec.FeeClaim
+		table = "fee_claims"
+		values = []any{e.Action, e.Position.String(), e.Owner.String(), u64Text(e.AmountA), u64Text(e.AmountB)}
+	case rec.Lock != nil:
+		e := rec.Lock
+		table = "locks"
+		values = []any{
+			e.Action, e.Position.String(), e.Owner.String(), e.Vesting.String(), e.Liquidity.String(),
+			int64(e.CliffPoint), int64(e.PeriodFrequency), int64(e.NumberOfPeriod),
+		}
+	case rec.Split != nil:
+		e := rec.Split
+		table = "splits"
+		values = []any{
+			e.FirstPosition.String(), e.SecondPosition.String(), e.FirstOwner.String(), e.SecondOwner.String(),
+			e.UnlockedLiquidity.String(), e.PermanentLockedLiquidity.String(),
+			u64Text(e.FeeA), u64Text(e.FeeB), u64Text(e.Reward0), u64Text(e.Reward1),
+		}
+	case rec.Reward != nil:
+		e := rec.Reward
+		table = "rewards"
+		values = []any{
+			e.Action, int64(e.RewardIndex), e.Mint.String(), e.Account.String(), e.Position.String(),
+			u64Text(e.Amount), int64(e.Duration),
+		}
+	case rec.PoolCreation != nil:
+		e := rec.PoolCreation
+		table = "pool_creations"
+		values = []any{
+			e.TokenAMint.String(), e.TokenBMint.String(), e.Creator.String(), e.Payer.String(),
+			e.Liquidity.String(), e.SqrtPrice.String(), int64(e.ActivationPoint), int64(e.ActivationType),
+			int64(e.CollectFeeMode), int64(e.PoolType), u64Text(e.AmountA), u64Text(e.AmountB),
+		}
+	default:
+		return nil
+	}
+
+	// columns follow the schema order, after the key, slot and pool.
+	placeholders := "?, ?, ?, ?"
+	for range values {
+		placeholders += ", ?"
+	}
+	args := append([]any{rec.Signature.String(), rec.Index, int64(rec.Slot), rec.Pool.String()}, values...)
+	if _, err := tx.ExecContext(ctx, `INSERT OR IGNORE INTO `+table+` VALUES (`+placeholders+`)`, args...); err != nil {
+		return fmt.Errorf("err inserting into %s: %w", table, err)
+	}
+	return nil
+}
+
+// u64Text formats a token amount for its TEXT column.
+func u64Text(v uint64) string {
+	return strconv.FormatUint(v, 10)
+}
+
+func (s *SQLiteStore) Pending(ctx context.Context, slot uint64) (map[solana.Signature]uint64, error) {
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT DISTINCT signature, slot FROM events WHERE finalized = 0 AND slot <= ?`, int64(slot),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	res := make(map[solana.Signature]uint64)
+	for rows.Next() {
+		var (
+			signature string
+			slot      int64
+		)
+		if err := rows.Scan(&signature, &slot); err != nil {
+			return nil, err
+		}
+		sig, err := solana.SignatureFromBase58(signature)
+		if err != nil {
+			return nil, fmt.Errorf("err decoding signature %s: %w", signature, err)
+		}
+		res[sig] = uint64(slot)
+	}
+	return res, rows.Err()
+}
+
+func (s *SQLiteStore) Finalize(ctx context.Context, slots map[solana.Signature]uint64) error {
+	if len(slots) == 0 {
+		return nil
+	}
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for sig, slot := range slots {
+		for _, table := range sqliteTables {
+			stmt := `UPDATE ` + table + ` SET slot = ? WHERE signature = ?`
+			if table == "events" {
+				stmt = `UPDATE events SET slot = ?, finalized = 1 WHERE signature = ?`
+			}
+			if _, err := tx.ExecContext(ctx, stmt, int64(slot), sig.String()); err != nil {
+				return fmt.Errorf("err finalizing %s in %s: %w", sig, table, err)
+			}
+		}
+	}
+	return tx.Commit()
+}
+
+func (s *SQLiteStore) Drop(ctx context.Context, signatures ...solana.Signature) error {
+	if len(signatures) == 0 {
+		return nil
+	}
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, sig := range signatures {
+		for _, table := range sqliteTables {
+			if _, err := tx.ExecContext(ctx, `DELETE FROM `+table+` WHERE signature = ?`, sig.String()); err != nil {
+				return fmt.Errorf("err dropping %s from %s: %w", sig, table, err)
+			}
+		}
+	}
+	return tx.Commit()
+}
+
+func (s *SQLiteStore) Close() error {
+	return s.db.Close()
+}
@@ -0,0 +1,313 @@
+// Command filtergen generates the typed memcmp filter builders of package filters
+// from the Borsh layout of the cp_amm IDL accounts.
+//
+//	go run ./internal/cmd/filtergen -idl cp_amm.json -out helpers/filters/filters_gen.go
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"go/format"
+	"log"
+	"os"
+	"strings"
+)
+
+// accounts filters are generated for, in output order.
+var accountNames = []string{
+	"Pool",
+	"Position",
+	"Config",
+	"Vesting",
+	"TokenBadge",
+	"ClaimFeeOperator",
+}
+
+const discriminatorSize = 8
+
+type idl struct {
+	Accounts []struct {
+		Name          string `json:"name"`
+		Discriminator []byte `json:"discriminator"`
+	} `json:"accounts"`
+	Types []idlTypeDef `json:"types"`
+}
+
+type idlTypeDef struct {
+	Name string `json:"name"`
+	Type struct {
+		Kind   string     `json:"kind"`
+		Fields []idlField `json:"fields"`
+	} `json:"type"`
+}
+
+type idlField struct {
+	Name string          `json:"name"`
+	Type json.RawMessage `json:"type"`
+}
+
+// layout is the byte layout of one account field, discriminator included.
+type layout struct {
+	name   string
+	offset int
+	size   int
+	kind   fieldKind
+}
+
+type fieldKind struct {
+	goType string // parameter type of the builder method
+	encode string // expression turning v into []byte
+}
+
+func main() {
+	idlPath := flag.String("idl", "cp_amm.json", "path of the anchor IDL")
+	outPath := flag.String("out", "filters_gen.go", "path of the generated file")
+	flag.Parse()
+
+	raw, err := os.ReadFile(*idlPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	var doc idl
+	if err := json.Unmarshal(raw, &doc); err != nil {
+		log.Fatalf("decoding idl: %v", err)
+	}
+
+	types := make(map[string]idlTypeDef, len(doc.Types))
+	for _, t := range doc.Types {
+		types[t.Name] = t
+	}
+
+	var body bytes.Buffer
+
+	for _, name := range accountNames {
+		var discriminator []byte
+		for _, acc := range doc.Accounts {
+			if acc.Name == name {
+				discriminator = acc.Discriminator
+			}
+		}
+		if discriminator == nil {
+			log.Fatalf("account %s not found in idl", name)
+		}
+
+		def, ok := types[name]
+		if !ok {
+			log.Fatalf("type %s not found in idl", name)
+		}
+
+		fields, size, err := accountLayout(def, types)
+		if err != nil {
+			log.Fatalf("%s: %v", name, err)
+		}
+
+		writeAccount(&body, name, discriminator, fields, size)
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("// Code generated by filtergen from cp_amm.json. DO NOT EDIT.\n\npackage filters\n\nimport (\n")
+	for _, imp := range [][2]string{
+		{"binary.LittleEndian", `"encoding/binary"`},
+		{"ag_binary.", `ag_binary "github.com/gagliardetto/binary"`},
+		{"solana.", `"github.com/gagliardetto/solana-go"`},
+		{"rpc.", `"github.com/gagliardetto/solana-go/rpc"`},
+	} {
+		if bytes.Contains(body.Bytes(), []byte(imp[0])) {
+			fmt.Fprintf(&buf, "\t%s\n", imp[1])
+			if !strings.Contains(imp[1], ".") {
+				// keep the standard library in its own group.
+				buf.WriteString("\n")
+			}
+		}
+	}
+	buf.WriteString(")\n")
+	buf.Write(body.Bytes())
+
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		log.Fatalf("formatting output: %v\n%s", err, buf.Bytes())
+	}
+	if err := os.WriteFile(*outPath, src, 0o644); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func accountLayout(def idlTypeDef, types map[string]idlTypeDef) ([]layout, int, error) {
+	offset := discriminatorSize
+	fields := make([]layout, 0, len(def.Type.Fields))
+
+	for _, f := range def.Type.Fields {
+		size, err := typeSize(f.Type, types)
+		if err != nil {
+			return nil, 0, fmt.Errorf("field %s: %w", f.Name, err)
+		}
+		fields = append(fields, layout{
+			name:   f.Name,
+			offset: offset,
+			size:   size,
+			kind:   kindOf(f.Type, size),
+		})
+		offset += size
+	}
+
+	return fields, offset, nil
+}
+
+func typeSize(raw json.RawMessage, types map[string]idlTypeDef) (int, error) {
+	var primitive string
+	if err := json.Unmarshal(raw, &primitive); err == nil {
+		switch primitive {
+		case "u8", "i8", "bool":
+			return 1, nil
+		case "u16", "i16":
+			return 2, nil
+		case "u32", "i32", "f32":
+			return 4, nil
+		case "u64", "i64", "f64":
+			return 8, nil
+		case "u128", "i128":
+			return 16, nil
+		case "pubkey":
+			return 32, nil
+		}
+		return 0, fmt.Errorf("unsupported primitive %q", primitive)
+	}
+
+	var composite struct {
+		Array   []json.RawMessage `json:"array"`
+		Defined *struct {
+			Name string `json:"name"`
+		} `json:"defined"`
+	}
+	if err := json.Unmarshal(raw, &composite); err != nil {
+		return 0, err
+	}
+
+	switch {
+	case len(composite.Array) == 2:
+		elem, err := typeSize(composite.Array[0], types)
+		if err != nil {
+			return 0, err
+		}
+		var n int
+		if err := json.Unmarshal(composite.Array[1], &n); err != nil {
+			return 0, err
+		}
+		return elem * n, nil
+
+	case composite.Defined != nil:
+		def, ok := types[composite.Defined.Name]
+		if !ok {
+			return 0, fmt.Errorf("unknown type %s", composite.Defined.Name)
+		}
+		if def.Type.Kind != "struct" {
+			return 0, fmt.Errorf("type %s: unsupported kind %s", def.Name, def.Type.Kind)
+		}
+		total := 0
+		for _, f := range def.Type.Fields {
+			size, err := typeSize(f.Type, types)
+			if err != nil {
+				return 0, fmt.Errorf("%s.%s: %w", def.Name, f.Name, err)
+			}
+			total += size
+		}
+		return total, nil
+	}
+
+	return 0, fmt.Errorf("unsupported type %s", raw)
+}
+
+// kindOf picks the builder parameter type, fields with no natural go type take raw bytes.
+func kindOf(raw json.RawMessage, size int) fieldKind {
+	var primitive string
+	if err := json.Unmarshal(raw, &primitive); err == nil {
+		switch primitive {
+		case "u8":
+			return fieldKind{"uint8", "[]byte{v}"}
+		case "bool":
+			return fieldKind{"bool", "boolBytes(v)"}
+		case "u16":
+			return fieldKind{"uint16", "binary.LittleEndian.AppendUint16(nil, v)"}
+		case "u32":
+			return fieldKind{"uint32", "binary.LittleEndian.AppendUint32(nil, v)"}
+		case "u64":
+			return fieldKind{"uint64", "binary.LittleEndian.AppendUint64(nil, v)"}
+		case "u128":
+			return fieldKind{"ag_binary.Uint128", "uint128Bytes(v)"}
+		case "pubkey":
+			return fieldKind{"solana.PublicKey", "v.Bytes()"}
+		}
+	}
+
+	var composite struct {
+		Array []json.RawMessage `json:"array"`
+	}
+	if err := json.Unmarshal(raw, &composite); err == nil && len(composite.Array) == 2 {
+		var elem string
+		if err := json.Unmarshal(composite.Array[0], &elem); err == nil && elem == "u8" {
+			return fieldKind{fmt.Sprintf("[%d]byte", size), "v[:]"}
+		}
+	}
+
+	return fieldKind{"[]byte", "v"}
+}
+
+func writeAccount(buf *bytes.Buffer, name string, discriminator []byte, fields []layout, size int) {
+	typeName := name + "Filters"
+
+	fmt.Fprintf(buf, "\n// %s account layout, offsets include the 8 byte discriminator.\nconst (\n", name)
+	fmt.Fprintf(buf, "\t%sSize = %d\n", name, size)
+	for _, f := range fields {
+		if isPadding(f.name) {
+			continue
+		}
+		fmt.Fprintf(buf, "\t%s%sOffset = %d\n", name, goName(f.name), f.offset)
+	}
+	buf.WriteString(")\n")
+
+	fmt.Fprintf(buf, "\n// %s builds getProgramAccounts filters matching %s accounts.\n", typeName, name)
+	fmt.Fprintf(buf, "type %s struct{}\n\n", typeName)
+	fmt.Fprintf(buf, "// %s is the filter builder of %s accounts.\n", name, name)
+	fmt.Fprintf(buf, "var %s %s\n\n", name, typeName)
+
+	fmt.Fprintf(buf, "// Discriminator matches the %s account discriminator.\n", name)
+	fmt.Fprintf(buf, "func (%s) Discriminator() rpc.RPCFilter {\n", typeName)
+	fmt.Fprintf(buf, "\treturn Memcmp(0, %#v)\n}\n\n", discriminator)
+
+	fmt.Fprintf(buf, "// DataSize matches accounts of the %s account size.\n", name)
+	fmt.Fprintf(buf, "func (%s) DataSize() rpc.RPCFilter {\n", typeName)
+	fmt.Fprintf(buf, "\treturn DataSize(%sSize)\n}\n", name)
+
+	for _, f := range fields {
+		if isPadding(f.name) {
+			continue
+		}
+		method := goName(f.name)
+		fmt.Fprintf(buf, "\n// %s matches the %s field (%d bytes at offset %d).\n", method, f.name, f.size, f.offset)
+		if f.kind.goType == "[]byte" {
+			buf.WriteString("// v is compared against the start of the field, it may be shorter than the field.\n")
+		}
+		fmt.Fprintf(buf, "func (%s) %s(v %s) rpc.RPCFilter {\n", typeName, method, f.kind.goType)
+		fmt.Fprintf(buf, "\treturn Memcmp(%s%sOffset, %s)\n}\n", name, method, f.kind.encode)
+	}
+}
+
+func isPadding(field string) bool {
+	return strings.HasPrefix(strings.TrimPrefix(field, "_"), "padding")
+}
+
+// goName converts a snake_case IDL name to the CamelCase used by the generated cp_amm package.
+func goName(field string) string {
+	var b strings.Builder
+	for _, part := range strings.Split(strings.TrimPrefix(field, "_"), "_") {
+		if part == "" {
+			continue
+		}
+		b.WriteString(strings.ToUpper(part[:1]))
+		b.WriteString(part[1:])
+	}
+	return b.String()
+}
@@ -0,0 +1,19 @@
+// Package eventtest builds the event updates offline tests feed to event consumers.
+package eventtest
+
+import (
+	dammv2gosdk "dammv2GoSDK"
+	cp_amm "dammv2GoSDK/generated/cpAmm"
+
+	"github.com/gagliardetto/solana-go"
+)
+
+// Update returns the update of the event name carrying data, written in slot by the
+// transaction whose signature starts with sig.
+func Update(sig byte, slot uint64, name string, data cp_amm.EventData) dammv2gosdk.EventUpdate {
+	return dammv2gosdk.EventUpdate{
+		Signature: solana.Signature{sig},
+		Slot:      slot,
+		Event:     &cp_amm.Event{Name: name, Data: data},
+	}
+}
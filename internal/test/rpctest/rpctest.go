@@ -0,0 +1,293 @@
+// Package rpctest fakes the Solana json rpc and websocket endpoints for offline tests.
+package rpctest
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/gagliardetto/solana-go/rpc/jsonrpc"
+	"github.com/gorilla/websocket"
+)
+
+// timeout bounds every wait on the fake websocket endpoint.
+const timeout = 5 * time.Second
+
+// Handler answers the rpc method called with params, each one json-encoded.
+// The result is json-encoded in turn and decoded into the caller's output.
+type Handler func(method string, params []json.RawMessage) (any, error)
+
+// NewClient returns an rpc client served by handle.
+func NewClient(handle Handler) *rpc.Client {
+	return rpc.NewWithCustomRPCClient(client{handle})
+}
+
+type client struct{ handle Handler }
+
+func (c client) CallForInto(_ context.Context, out any, method string, params []any) error {
+	encoded := make([]json.RawMessage, len(params))
+	for i, param := range params {
+		raw, err := json.Marshal(param)
+		if err != nil {
+			return err
+		}
+		encoded[i] = raw
+	}
+
+	res, err := c.handle(method, encoded)
+	if err != nil {
+		return err
+	}
+	raw, err := json.Marshal(res)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(raw, out)
+}
+
+func (c client) CallWithCallback(context.Context, string, []any, func(*http.Request, *http.Response) error) error {
+	return errors.New("not implemented")
+}
+
+func (c client) CallBatch(context.Context, jsonrpc.RPCRequests) (jsonrpc.RPCResponses, error) {
+	return nil, errors.New("not implemented")
+}
+
+// WithContext wraps value the way rpc methods reporting their context slot answer.
+func WithContext(slot uint64, value any) any {
+	return map[string]any{"context": map[string]any{"slot": slot}, "value": value}
+}
+
+// Account is the json of an account of owner holding data, as rpc methods and
+// notifications answer it.
+func Account(owner solana.PublicKey, data []byte) any {
+	return map[string]any{
+		"data":       []string{base64.StdEncoding.EncodeToString(data), "base64"},
+		"owner":      owner.String(),
+		"lamports":   1,
+		"executable": false,
+		"rentEpoch":  0,
+	}
+}
+
+// Accounts serves getAccountInfo, getMultipleAccounts and getProgramAccounts out of Data,
+// applying filters and data slices the way a node does. Every account is owned by Owner.
+type Accounts struct {
+	Slot  uint64
+	Owner solana.PublicKey
+	Data  map[solana.PublicKey][]byte
+}
+
+// Handle is the Handler of the accounts, e.g NewClient(accounts.Handle).
+func (a *Accounts) Handle(method string, params []json.RawMessage) (any, error) {
+	var opts struct {
+		DataSlice   *rpc.DataSlice  `json:"dataSlice"`
+		Filters     []rpc.RPCFilter `json:"filters"`
+		WithContext bool            `json:"withContext"`
+	}
+	if len(params) > 1 {
+		if err := json.Unmarshal(params[1], &opts); err != nil {
+			return nil, err
+		}
+	}
+	account := func(key solana.PublicKey) any {
+		data, ok := a.Data[key]
+		if !ok {
+			return nil
+		}
+		if slice := opts.DataSlice; slice != nil {
+			start := min(uint64(len(data)), *slice.Offset)
+			data = data[start:min(uint64(len(data)), start+*slice.Length)]
+		}
+		return Account(a.Owner, data)
+	}
+
+	switch method {
+	case "getAccountInfo":
+		var key solana.PublicKey
+		if err := json.Unmarshal(params[0], &key); err != nil {
+			return nil, err
+		}
+		return WithContext(a.Slot, account(key)), nil
+	case "getMultipleAccounts":
+		var keys []solana.PublicKey
+		if err := json.Unmarshal(params[0], &keys); err != nil {
+			return nil, err
+		}
+		values := make([]any, len(keys))
+		for i, key := range keys {
+			values[i] = account(key)
+		}
+		return WithContext(a.Slot, values), nil
+	case "getProgramAccounts":
+		keys := slices.SortedFunc(maps.Keys(a.Data), func(a, b solana.PublicKey) int {
+			return bytes.Compare(a[:], b[:])
+		})
+		values := []any{}
+		for _, key := range keys {
+			if matches(a.Data[key], opts.Filters) {
+				values = append(values, map[string]any{"pubkey": key.String(), "account": account(key)})
+			}
+		}
+		if opts.WithContext {
+			return WithContext(a.Slot, values), nil
+		}
+		return values, nil
+	default:
+		return nil, errors.New("unexpected method " + method)
+	}
+}
+
+// matches tells whether data passes every filter.
+func matches(data []byte, filters []rpc.RPCFilter) bool {
+	for _, filter := range filters {
+		if filter.DataSize != 0 && uint64(len(data)) != filter.DataSize {
+			return false
+		}
+		if m := filter.Memcmp; m != nil {
+			end := m.Offset + uint64(len(m.Bytes))
+			if end > uint64(len(data)) || !bytes.Equal(data[m.Offset:end], m.Bytes) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// WSServer is a fake websocket endpoint, every connection made to it is handed to Accept.
+type WSServer struct {
+	srv   *httptest.Server
+	conns chan *WSConn
+}
+
+// NewWSServer starts a WSServer, closed along with t.
+func NewWSServer(t testing.TB) *WSServer {
+	s := &WSServer{conns: make(chan *WSConn, 16)}
+	upgrader := websocket.Upgrader{}
+	s.srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		c := &WSConn{conn: conn, messages: make(chan []byte, 16)}
+		go c.read()
+		s.conns <- c
+	}))
+	t.Cleanup(s.srv.Close)
+	return s
+}
+
+// URL returns the ws:// url of the endpoint.
+func (s *WSServer) URL() string {
+	return "ws" + strings.TrimPrefix(s.srv.URL, "http")
+}
+
+// Accept waits for the next connection.
+func (s *WSServer) Accept(t testing.TB) *WSConn {
+	t.Helper()
+	select {
+	case c := <-s.conns:
+		t.Cleanup(c.Close)
+		return c
+	case <-time.After(timeout):
+		t.Fatal("no websocket connection")
+		return nil
+	}
+}
+
+// WSConn is a connection made to a WSServer.
+type WSConn struct {
+	conn     *websocket.Conn
+	messages chan []byte
+
+	mu      sync.Mutex
+	lastSub uint64
+}
+
+// read keeps reading the connection, which also answers the pings of the client.
+func (c *WSConn) read() {
+	defer close(c.messages)
+	for {
+		_, message, err := c.conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		c.messages <- message
+	}
+}
+
+// Subscribe waits for the next subscription request and acknowledges it. It returns the
+// method and params of the request along with the id of the subscription.
+func (c *WSConn) Subscribe(t testing.TB) (method string, params []json.RawMessage, sub uint64) {
+	t.Helper()
+	for {
+		var message []byte
+		select {
+		case m, ok := <-c.messages:
+			if !ok {
+				t.Fatal("websocket connection closed")
+			}
+			message = m
+		case <-time.After(timeout):
+			t.Fatal("no subscription request")
+		}
+
+		var req struct {
+			ID     uint64            `json:"id"`
+			Method string            `json:"method"`
+			Params []json.RawMessage `json:"params"`
+		}
+		if err := json.Unmarshal(message, &req); err != nil {
+			t.Fatal(err)
+		}
+		if !strings.HasSuffix(req.Method, "Subscribe") {
+			continue
+		}
+
+		c.mu.Lock()
+		c.lastSub++
+		sub = c.lastSub
+		c.mu.Unlock()
+		c.write(t, map[string]any{"jsonrpc": "2.0", "id": req.ID, "result": sub})
+		return req.Method, req.Params, sub
+	}
+}
+
+// Notify sends a notification of method, e.g accountNotification, for sub at slot.
+func (c *WSConn) Notify(t testing.TB, method string, sub uint64, slot uint64, value any) {
+	t.Helper()
+	c.write(t, map[string]any{
+		"jsonrpc": "2.0",
+		"method":  method,
+		"params": map[string]any{
+			"subscription": sub,
+			"result":       WithContext(slot, value),
+		},
+	})
+}
+
+// Close drops the connection.
+func (c *WSConn) Close() {
+	c.conn.Close()
+}
+
+func (c *WSConn) write(t testing.TB, message any) {
+	t.Helper()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.conn.WriteJSON(message); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,57 @@
+package dammv2gosdk
+
+import (
+	"context"
+	cp_amm "dammv2GoSDK/generated/cpAmm"
+	"dammv2GoSDK/txn"
+
+	"github.com/gagliardetto/solana-go"
+)
+
+// StaticLookupTableAddresses are the accounts every cp_amm transaction shares:
+// the program, its pool and event authorities and the token programs.
+func StaticLookupTableAddresses() []solana.PublicKey {
+	return []solana.PublicKey{
+		CpAMMProgramId,
+		DerivePoolAuthority(),
+		DeriveEventAuthority(),
+		solana.TokenProgramID,
+		solana.Token2022ProgramID,
+		solana.SystemProgramID,
+		solana.SPLAssociatedTokenAccountProgramID,
+	}
+}
+
+// PoolLookupTableAddresses are the accounts of pool any transaction on it goes through.
+func PoolLookupTableAddresses(pool solana.PublicKey, poolState *cp_amm.PoolAccount) []solana.PublicKey {
+	addresses := []solana.PublicKey{
+		pool,
+		poolState.TokenAMint,
+		poolState.TokenBMint,
+		poolState.TokenAVault,
+		poolState.TokenBVault,
+	}
+	for _, reward := range poolState.RewardInfos {
+		if reward.Initialized != 0 {
+			addresses = append(addresses, reward.Mint, reward.Vault)
+		}
+	}
+	return addresses
+}
+
+// EnsurePoolLookupTable returns a table of manager holding the static accounts and
+// the accounts of pool, creating or extending one when needed.
+func (cp *CpAMM) EnsurePoolLookupTable(
+	ctx context.Context,
+	manager *txn.LookupTableManager,
+	authority, payer txn.Signer,
+	pool solana.PublicKey,
+) (solana.PublicKey, error) {
+	poolState, err := cp.FetchPoolState(ctx, pool)
+	if err != nil {
+		return solana.PublicKey{}, err
+	}
+
+	addresses := append(StaticLookupTableAddresses(), PoolLookupTableAddresses(pool, poolState)...)
+	return manager.Ensure(ctx, authority, payer, addresses)
+}
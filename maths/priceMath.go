@@ -63,3 +63,17 @@ func CalculateInitSqrtPrice(
 	r, _ := result.Int(nil)
 	return r, nil
 }
+
+// GetPriceFromSqrtPrice returns the price of token A in token B, in UI units, out of a
+// Q64.64 sqrt price: (sqrtPrice / 2^64)² * 10^(decimalsA - decimalsB).
+func GetPriceFromSqrtPrice(sqrtPrice *big.Int, decimalsA, decimalsB uint8) *big.Float {
+	sqrt := new(big.Float).Quo(new(big.Float).SetInt(sqrtPrice), big.NewFloat(math.Pow(2, 64)))
+	price := new(big.Float).Mul(sqrt, sqrt)
+
+	exp := int64(max(decimalsA, decimalsB) - min(decimalsA, decimalsB))
+	scale := new(big.Float).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(exp), nil))
+	if decimalsA >= decimalsB {
+		return price.Mul(price, scale)
+	}
+	return price.Quo(price, scale)
+}
@@ -0,0 +1,86 @@
+package dammv2gosdk
+
+import (
+	"context"
+	"dammv2GoSDK/anchor"
+	cp_amm "dammv2GoSDK/generated/cpAmm"
+	"fmt"
+)
+
+// CpAMMMethods returns a fluent builder factory for a cp_amm instruction, with the
+// pool authority, event authority, program, system programs and every PDA of pda.go
+// filled in, e.g:
+//
+//	ix, err := CpAMMMethods(cp, cp_amm.NewClaimPositionFeeInstructionBuilder).
+//		Method().
+//		Accounts(map[string]solana.PublicKey{
+//			"pool":              pool,
+//			"position_nft_mint": nftMint,
+//			"owner":             owner,
+//			"token_a_account":   tokenAAta,
+//			"token_b_account":   tokenBAta,
+//		}).
+//		Instruction(ctx)
+//
+// Go has no generic methods, hence the free function.
+func CpAMMMethods[T anchor.PgMethodI](cp *CpAMM, newInstruction func() T) *anchor.PgMethods[T] {
+	return anchor.NewPgMethods(cp.conn, CpAMMProgramId, newInstruction, cp.AccountResolver())
+}
+
+// AccountResolver derives the cp_amm accounts that follow from the ones already known:
+// positions and their nft accounts from the nft mint, vaults from the pool and mints,
+// token badges from the mint, pools from the config and mints, and token programs from
+// the mint owner when an rpc client is available.
+func (cp *CpAMM) AccountResolver() anchor.AccountResolver {
+	return func(ctx context.Context, accounts *anchor.ResolvedAccounts) error {
+		accounts.Set("pool_authority", cp.poolAuthority)
+
+		if nftMint, ok := accounts.Get("position_nft_mint"); ok {
+			accounts.Set("position", DerivePositionAddress(nftMint))
+			accounts.Set("position_nft_account", DerivePositionNftAccount(nftMint))
+		}
+
+		tokenAMint, hasA := accounts.Get("token_a_mint")
+		tokenBMint, hasB := accounts.Get("token_b_mint")
+		// customizable pools have no config to go by, their address is left to the caller.
+		if config, ok := accounts.Get("config"); ok && hasA && hasB {
+			accounts.Set("pool", DerivePoolAddress(config, tokenAMint, tokenBMint))
+		}
+
+		if pool, ok := accounts.Get("pool"); ok {
+			if hasA {
+				accounts.Set("token_a_vault", DeriveTokenVaultAddress(tokenAMint, pool))
+			}
+			if hasB {
+				accounts.Set("token_b_vault", DeriveTokenVaultAddress(tokenBMint, pool))
+			}
+		}
+
+		if tokenMint, ok := accounts.Get("token_mint"); ok {
+			accounts.Set("token_badge", DeriveTokenBadgeAddress(tokenMint))
+		}
+
+		if accounts.Conn == nil {
+			return nil
+		}
+		for _, side := range [2][2]string{
+			{"token_a_mint", "token_a_program"},
+			{"token_b_mint", "token_b_program"},
+		} {
+			mint, ok := accounts.Get(side[0])
+			if !ok || !accounts.Missing(side[1]) {
+				continue
+			}
+			info, err := accounts.Conn.GetAccountInfo(ctx, mint)
+			if err != nil {
+				return fmt.Errorf("err resolving %s: %w", side[1], err)
+			}
+			accounts.Set(side[1], info.Value.Owner)
+		}
+
+		return nil
+	}
+}
+
+// generated instruction builders are anchor.PgMethodI.
+var _ anchor.PgMethodI = (*cp_amm.SwapInstruction)(nil)
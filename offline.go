@@ -0,0 +1,50 @@
+package dammv2gosdk
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/gagliardetto/solana-go/rpc/jsonrpc"
+)
+
+// ErrOffline is returned by a CpAMM from NewOfflineCpAMM for anything needing an rpc call,
+// e.g a builder called without the state it would otherwise fetch.
+var ErrOffline = errors.New("rpc call attempted in offline mode")
+
+// NewOfflineCpAMM returns a CpAMM that never reaches the network, for signing services
+// with no rpc access. Builders are pure functions of their inputs:
+//
+//   - associated token accounts are created idempotently, or used as they are when
+//     listed with WithExistingATAs;
+//   - the pool state is taken from the PoolState of the params, e.g
+//     types.ClaimPartnerFeeParams.PoolState.
+//
+// Any rpc call fails with ErrOffline instead, and so do the fetchers, subscriptions and
+// simulations. A priority fee has to come from a txn.FixedPriorityFee.
+func NewOfflineCpAMM(opts ...CpAMMOption) *CpAMM {
+	opts = append([]CpAMMOption{WithIdempotentATAs()}, opts...)
+	return NewCpAMM(rpc.NewWithCustomRPCClient(offlineClient{}), opts...)
+}
+
+// offlineClient is an rpc.JSONRPCClient refusing every call.
+type offlineClient struct{}
+
+func (offlineClient) CallForInto(_ context.Context, _ any, method string, _ []any) error {
+	return fmt.Errorf("%w: %s", ErrOffline, method)
+}
+
+func (offlineClient) CallWithCallback(
+	_ context.Context,
+	method string,
+	_ []any,
+	_ func(*http.Request, *http.Response) error,
+) error {
+	return fmt.Errorf("%w: %s", ErrOffline, method)
+}
+
+func (offlineClient) CallBatch(context.Context, jsonrpc.RPCRequests) (jsonrpc.RPCResponses, error) {
+	return nil, fmt.Errorf("%w: batch", ErrOffline)
+}
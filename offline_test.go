@@ -0,0 +1,321 @@
+package dammv2gosdk
+
+import (
+	"context"
+	"dammv2GoSDK/anchor"
+	cp_amm "dammv2GoSDK/generated/cpAmm"
+	"dammv2GoSDK/helpers"
+	"dammv2GoSDK/internal/test/rpctest"
+	"dammv2GoSDK/txn"
+	"dammv2GoSDK/types"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sync"
+	"testing"
+
+	ag_binary "github.com/gagliardetto/binary"
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/gagliardetto/solana-go/rpc/jsonrpc"
+)
+
+// countingClient records every rpc call made through it, and fails them.
+type countingClient struct {
+	mu    sync.Mutex
+	calls []string
+}
+
+func (c *countingClient) record(method string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.calls = append(c.calls, method)
+	return errors.New("unexpected rpc call " + method)
+}
+
+func (c *countingClient) CallForInto(_ context.Context, _ any, method string, _ []any) error {
+	return c.record(method)
+}
+
+func (c *countingClient) CallWithCallback(_ context.Context, method string, _ []any, _ func(*http.Request, *http.Response) error) error {
+	return c.record(method)
+}
+
+func (c *countingClient) CallBatch(context.Context, jsonrpc.RPCRequests) (jsonrpc.RPCResponses, error) {
+	return nil, c.record("batch")
+}
+
+func TestOfflineBuildersMakeNoRPCCall(t *testing.T) {
+	var (
+		ctx      = context.Background()
+		owner    = solana.NewWallet().PublicKey()
+		mintA    = solana.NewWallet().PublicKey()
+		mintB    = solana.NewWallet().PublicKey()
+		pool     = solana.NewWallet().PublicKey()
+		position = solana.NewWallet().PublicKey()
+		state    = &cp_amm.PoolAccount{
+			TokenAMint:  mintA,
+			TokenBMint:  mintB,
+			TokenAVault: solana.NewWallet().PublicKey(),
+			TokenBVault: solana.NewWallet().PublicKey(),
+			Partner:     owner,
+		}
+	)
+	state.RewardInfos[0].Mint = mintA
+	state.RewardInfos[0].Vault = solana.NewWallet().PublicKey()
+
+	ataB, err := helpers.GetAssociatedTokenAddressSync(mintB, owner, true, solana.TokenProgramID, solana.PublicKey{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := &countingClient{}
+	cp := NewCpAMM(rpc.NewWithCustomRPCClient(client), WithIdempotentATAs(), WithExistingATAs(ataB))
+
+	builds := map[string]func() ([]solana.Instruction, error){
+		"Swap": func() ([]solana.Instruction, error) {
+			return cp.Swap(ctx, types.SwapParams{
+				Payer:           owner,
+				Pool:            pool,
+				InputTokenMint:  mintA,
+				OutputTokenMint: mintB,
+				AmountIn:        1_000,
+				TokenAMint:      mintA,
+				TokenBMint:      mintB,
+				TokenAVault:     state.TokenAVault,
+				TokenBVault:     state.TokenBVault,
+				TokenAProgram:   solana.TokenProgramID,
+				TokenBProgram:   solana.TokenProgramID,
+			})
+		},
+		"AddLiquidity": func() ([]solana.Instruction, error) {
+			return cp.AddLiquidity(ctx, types.AddLiquidityParams{
+				Owner:              owner,
+				Position:           position,
+				Pool:               pool,
+				PositionNftAccount: DerivePositionNftAccount(solana.NewWallet().PublicKey()),
+				LiquidityDelta:     ag_binary.Uint128{Lo: 1_000},
+				MaxAmountTokenA:    1_000,
+				MaxAmountTokenB:    1_000,
+				TokenAMint:         mintA,
+				TokenBMint:         mintB,
+				TokenAVault:        state.TokenAVault,
+				TokenBVault:        state.TokenBVault,
+				TokenAProgram:      solana.TokenProgramID,
+				TokenBProgram:      solana.TokenProgramID,
+			})
+		},
+		"ClaimPartnerFee": func() ([]solana.Instruction, error) {
+			return cp.ClaimPartnerFee(ctx, types.ClaimPartnerFeeParams{
+				Partner:    owner,
+				Pool:       pool,
+				MaxAmountA: 1,
+				MaxAmountB: 1,
+				PoolState:  state,
+			})
+		},
+		"WithdrawIneligibleReward": func() ([]solana.Instruction, error) {
+			return cp.WithdrawIneligibleReward(ctx, types.WithdrawIneligibleRewardParams{
+				Pool:      pool,
+				Funder:    owner,
+				PoolState: state,
+			})
+		},
+	}
+
+	for name, build := range builds {
+		ixns, err := build()
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if len(ixns) == 0 {
+			t.Fatalf("%s built no instruction", name)
+		}
+		for _, ix := range ixns {
+			for _, meta := range ix.Accounts() {
+				// ataB is known to exist, only the other accounts may be created.
+				if ix.ProgramID().Equals(solana.SPLAssociatedTokenAccountProgramID) && meta.PublicKey.Equals(ataB) {
+					t.Fatalf("%s creates an existing ATA", name)
+				}
+			}
+		}
+	}
+
+	if len(client.calls) != 0 {
+		t.Fatalf("builders made rpc calls: %v", client.calls)
+	}
+}
+
+func TestOfflineCpAMMRefusesRPC(t *testing.T) {
+	cp := NewOfflineCpAMM()
+
+	_, err := cp.ClaimPartnerFee(context.Background(), types.ClaimPartnerFeeParams{
+		Partner: solana.NewWallet().PublicKey(),
+		Pool:    solana.NewWallet().PublicKey(),
+	})
+	if !errors.Is(err, ErrOffline) {
+		t.Fatalf("expected ErrOffline without a pool state, got %v", err)
+	}
+}
+
+func TestBuildersPrependPriorityFee(t *testing.T) {
+	var (
+		ctx      = context.Background()
+		owner    = solana.NewWallet().PublicKey()
+		pool     = solana.NewWallet().PublicKey()
+		position = solana.NewWallet().PublicKey()
+		nft      = DerivePositionNftAccount(solana.NewWallet().PublicKey())
+	)
+	client := &countingClient{}
+	cp := NewCpAMM(rpc.NewWithCustomRPCClient(client), WithPriorityFee(txn.FixedPriorityFee(1_000)))
+
+	builds := map[string]func() ([]solana.Instruction, error){
+		"CreatePosition": func() ([]solana.Instruction, error) {
+			res, err := cp.CreatePosition(ctx, types.CreatePositionParams{
+				Owner:       owner,
+				Payer:       owner,
+				Pool:        pool,
+				PositionNft: solana.NewWallet().PublicKey(),
+			})
+			return res.Ixns, err
+		},
+		"LockPosition": func() ([]solana.Instruction, error) {
+			return cp.LockPosition(ctx, types.LockPositionParams{
+				Owner:              owner,
+				Payer:              owner,
+				VestingAccount:     solana.NewWallet().PublicKey(),
+				Position:           position,
+				PositionNftAccount: nft,
+				Pool:               pool,
+				NumberOfPeriod:     1,
+			})
+		},
+		"PermanentLockPosition": func() ([]solana.Instruction, error) {
+			return cp.PermanentLockPosition(ctx, types.PermanentLockParams{
+				Owner:              owner,
+				Position:           position,
+				PositionNftAccount: nft,
+				Pool:               pool,
+			})
+		},
+		"RefreshVesting": func() ([]solana.Instruction, error) {
+			return cp.RefreshVesting(ctx, types.RefreshVestingParams{
+				Owner:              owner,
+				Position:           position,
+				PositionNftAccount: nft,
+				Pool:               pool,
+				VestingAccounts:    []solana.PublicKey{solana.NewWallet().PublicKey()},
+			})
+		},
+		"UpdateRewardDuration": func() ([]solana.Instruction, error) {
+			return cp.UpdateRewardDuration(ctx, types.UpdateRewardDurationParams{Pool: pool, Admin: owner, NewDuration: 1})
+		},
+		"UpdateRewardFunder": func() ([]solana.Instruction, error) {
+			return cp.UpdateRewardFunder(ctx, types.UpdateRewardFunderParams{Pool: pool, Admin: owner, NewFunder: owner})
+		},
+		"SplitPosition": func() ([]solana.Instruction, error) {
+			return cp.SplitPosition(ctx, types.SplitPositionParams{
+				FirstPositionOwner:       owner,
+				SecondPositionOwner:      owner,
+				Pool:                     pool,
+				FirstPosition:            position,
+				FirstPositionNftAccount:  nft,
+				SecondPosition:           solana.NewWallet().PublicKey(),
+				SecondPositionNftAccount: DerivePositionNftAccount(solana.NewWallet().PublicKey()),
+				FeeAPercentage:           50,
+			})
+		},
+	}
+
+	for name, build := range builds {
+		ixns, err := build()
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if len(ixns) != 2 || !ixns[0].ProgramID().Equals(solana.ComputeBudget) || !ixns[1].ProgramID().Equals(CpAMMProgramId) {
+			t.Fatalf("%s built %d instructions, want the priority fee and its own", name, len(ixns))
+		}
+	}
+
+	if len(client.calls) != 0 {
+		t.Fatalf("builders made rpc calls: %v", client.calls)
+	}
+}
+
+func TestCachedBuildersLookUpWSOLATAs(t *testing.T) {
+	var (
+		ctx   = context.Background()
+		owner = solana.NewWallet().PublicKey()
+		mintB = solana.NewWallet().PublicKey()
+	)
+	ataSOL, err := helpers.GetAssociatedTokenAddressSync(solana.WrappedSol, owner, true, solana.TokenProgramID, solana.PublicKey{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ataB, err := helpers.GetAssociatedTokenAddressSync(mintB, owner, true, solana.TokenProgramID, solana.PublicKey{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	accounts := &rpctest.Accounts{
+		Slot:  10,
+		Owner: solana.TokenProgramID,
+		Data:  map[solana.PublicKey][]byte{ataSOL: make([]byte, 165), ataB: make([]byte, 165)},
+	}
+	var mu sync.Mutex
+	lookups := 0
+	conn := rpctest.NewClient(func(method string, params []json.RawMessage) (any, error) {
+		mu.Lock()
+		lookups++
+		mu.Unlock()
+		return accounts.Handle(method, params)
+	})
+	// the account cache never expires, the ATA memo does.
+	cp := NewCpAMM(conn, WithAccountCache(anchor.NewAccountCache(0)))
+	if cp.atas.TTL() != ataMemoTTL {
+		t.Fatalf("ATA memo TTL = %v, want %v", cp.atas.TTL(), ataMemoTTL)
+	}
+
+	swap := func() []solana.Instruction {
+		t.Helper()
+		ixns, err := cp.Swap(ctx, types.SwapParams{
+			Payer:           owner,
+			Pool:            solana.NewWallet().PublicKey(),
+			InputTokenMint:  solana.WrappedSol,
+			OutputTokenMint: mintB,
+			AmountIn:        1_000,
+			TokenAMint:      solana.WrappedSol,
+			TokenBMint:      mintB,
+			TokenAVault:     solana.NewWallet().PublicKey(),
+			TokenBVault:     solana.NewWallet().PublicKey(),
+			TokenAProgram:   solana.TokenProgramID,
+			TokenBProgram:   solana.TokenProgramID,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return ixns
+	}
+	creates := func(ixns []solana.Instruction, ata solana.PublicKey) bool {
+		for _, ix := range ixns {
+			if ix.ProgramID().Equals(solana.SPLAssociatedTokenAccountProgramID) {
+				for _, meta := range ix.Accounts() {
+					if meta.PublicKey.Equals(ata) {
+						return true
+					}
+				}
+			}
+		}
+		return false
+	}
+
+	if ixns := swap(); creates(ixns, ataSOL) || creates(ixns, ataB) || lookups != 2 {
+		t.Fatalf("first swap: %d lookups, creating the WSOL ATA %v, token B's %v", lookups, creates(ixns, ataSOL), creates(ixns, ataB))
+	}
+
+	// the first swap closed the WSOL ATA when unwrapping. Only the token B ATA is memoized.
+	delete(accounts.Data, ataSOL)
+	if ixns := swap(); !creates(ixns, ataSOL) || creates(ixns, ataB) || lookups != 3 {
+		t.Fatalf("second swap: %d lookups, creating the WSOL ATA %v, token B's %v", lookups, creates(ixns, ataSOL), creates(ixns, ataB))
+	}
+}
@@ -0,0 +1,68 @@
+package dammv2gosdk
+
+import (
+	"dammv2GoSDK/anchor"
+	"dammv2GoSDK/txn"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+)
+
+// CpAMMOption configures optional behaviour of a CpAMM instance.
+type CpAMMOption func(*CpAMM)
+
+// ataMemoTTL is how long an ATA found to exist is used without a lookup. It bounds the
+// builds missing the create instruction of an ATA closed since.
+const ataMemoTTL = time.Minute
+
+// WithAccountCache makes the pool, config and position fetchers read through cache,
+// and lets prepareTokenAccounts skip the rpc lookup for ATAs found to exist within the
+// last minute. WSOL ATAs, closed by the SOL builders, are always looked up.
+func WithAccountCache(cache *anchor.AccountCache) CpAMMOption {
+	return func(cp *CpAMM) {
+		cp.cache = cache
+		cp.atas = nil
+		if cache != nil {
+			cp.atas = anchor.NewAccountCache(ataMemoTTL)
+		}
+	}
+}
+
+// WithWSEndpoint sets the websocket endpoint used by SubscribePool, SubscribePosition
+// and SubscribeAllPools, e.g rpc.MainNetBeta.WS.
+func WithWSEndpoint(endpoint string) CpAMMOption {
+	return func(cp *CpAMM) {
+		cp.wsEndpoint = endpoint
+	}
+}
+
+// WithPriorityFee makes every builder prepend a SetComputeUnitPrice priced by estimator
+// for the accounts they write-lock, e.g txn.NewRecentFeesEstimator(conn, txn.PriorityFeeHigh).
+func WithPriorityFee(estimator txn.PriorityFeeEstimator) CpAMMOption {
+	return func(cp *CpAMM) {
+		cp.priorityFee = estimator
+	}
+}
+
+// WithIdempotentATAs makes builders create every associated token account they use with an
+// idempotent instruction instead of looking it up, so building makes no rpc call for them.
+// Meant for transactions built offline, e.g with txn.BuildDurable, at the cost of a few
+// bytes and compute units per account that already exists.
+func WithIdempotentATAs() CpAMMOption {
+	return func(cp *CpAMM) {
+		cp.idempotentATAs = true
+	}
+}
+
+// WithExistingATAs lists associated token accounts the caller knows exist. Builders use
+// them as they are, with neither a lookup nor a create instruction.
+func WithExistingATAs(atas ...solana.PublicKey) CpAMMOption {
+	return func(cp *CpAMM) {
+		if cp.existingATAs == nil {
+			cp.existingATAs = make(map[solana.PublicKey]bool, len(atas))
+		}
+		for _, ata := range atas {
+			cp.existingATAs[ata] = true
+		}
+	}
+}
@@ -31,6 +31,16 @@ func DerivePoolAuthority() solana.PublicKey {
 	return pda
 }
 
+func DeriveEventAuthority() solana.PublicKey {
+	pda, _, _ := solana.FindProgramAddress(
+		[][]byte{
+			[]byte("__event_authority"),
+		},
+		CpAMMProgramId,
+	)
+	return pda
+}
+
 func DeriveTokenBadgeAddress(tokenMint solana.PublicKey) solana.PublicKey {
 	pda, _, _ := solana.FindProgramAddress(
 		[][]byte{
@@ -0,0 +1,120 @@
+package dammv2gosdk
+
+import (
+	"context"
+	"dammv2GoSDK/anchor"
+	cp_amm "dammv2GoSDK/generated/cpAmm"
+	"dammv2GoSDK/helpers/filters"
+	"dammv2GoSDK/types"
+	"encoding/binary"
+	"fmt"
+	"math/big"
+
+	ag_binary "github.com/gagliardetto/binary"
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+// pool account fields making up a PoolPriceView.
+var (
+	poolTokenAMintRange = anchor.FieldRange{Offset: filters.PoolTokenAMintOffset, Length: solana.PublicKeyLength}
+	poolTokenBMintRange = anchor.FieldRange{Offset: filters.PoolTokenBMintOffset, Length: solana.PublicKeyLength}
+	poolLiquidityRange  = anchor.FieldRange{Offset: filters.PoolLiquidityOffset, Length: 16}
+	poolSqrtPriceRange  = anchor.FieldRange{Offset: filters.PoolSqrtPriceOffset, Length: 16}
+	poolStatusRange     = anchor.FieldRange{Offset: filters.PoolPoolStatusOffset, Length: 1}
+
+	poolPriceViewRanges = []anchor.FieldRange{
+		poolTokenAMintRange,
+		poolTokenBMintRange,
+		poolLiquidityRange,
+		poolSqrtPriceRange,
+		poolStatusRange,
+	}
+)
+
+// GetPoolPriceViews scans the pools matching filters (every pool when empty) and returns
+// their PoolPriceView, fetching only the bytes spanning the viewed fields.
+func (cp *CpAMM) GetPoolPriceViews(ctx context.Context, filters ...rpc.RPCFilter) ([]types.PoolPriceView, error) {
+	accountSlices, err := anchor.NewPgAccounts(
+		cp.conn,
+		func() *cp_amm.PoolAccount { return &cp_amm.PoolAccount{} },
+	).AllSlices(
+		ctx,
+		CpAMMProgramId,
+		cp_amm.PoolAccountDiscriminator,
+		filters,
+		poolPriceViewRanges...,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	views := make([]types.PoolPriceView, 0, len(accountSlices))
+	for _, slice := range accountSlices {
+		view, err := DecodePoolPriceView(slice)
+		if err != nil {
+			return nil, err
+		}
+		views = append(views, view)
+	}
+
+	return views, nil
+}
+
+// FetchPoolPriceViews returns the PoolPriceView of every pool, index-aligned with pools.
+// Pools that do not exist are left as a zero view holding only their address.
+func (cp *CpAMM) FetchPoolPriceViews(
+	ctx context.Context,
+	pools []solana.PublicKey,
+	commitment rpc.CommitmentType,
+) ([]types.PoolPriceView, error) {
+	accountSlices, err := anchor.NewPgAccounts(
+		cp.conn,
+		func() *cp_amm.PoolAccount { return &cp_amm.PoolAccount{} },
+	).FetchMultipleSlices(ctx, pools, commitment, poolPriceViewRanges...)
+	if err != nil {
+		return nil, err
+	}
+
+	views := make([]types.PoolPriceView, len(accountSlices))
+	for i, slice := range accountSlices {
+		if !slice.Exists() {
+			views[i] = types.PoolPriceView{Pool: slice.PublicKey}
+			continue
+		}
+		if views[i], err = DecodePoolPriceView(slice); err != nil {
+			return nil, err
+		}
+	}
+
+	return views, nil
+}
+
+// DecodePoolPriceView reads a PoolPriceView out of a pool account slice
+// covering the viewed fields.
+func DecodePoolPriceView(slice anchor.AccountSlice) (types.PoolPriceView, error) {
+	// a window covering the span covers every field in it.
+	if _, err := slice.Field(anchor.SpanOf(poolPriceViewRanges...)); err != nil {
+		return types.PoolPriceView{}, fmt.Errorf("decoding pool price view: %w", err)
+	}
+	field := func(r anchor.FieldRange) []byte {
+		b, _ := slice.Field(r)
+		return b
+	}
+
+	return types.PoolPriceView{
+		Pool:       slice.PublicKey,
+		TokenAMint: solana.PublicKeyFromBytes(field(poolTokenAMintRange)),
+		TokenBMint: solana.PublicKeyFromBytes(field(poolTokenBMintRange)),
+		SqrtPrice:  uint128FromLE(field(poolSqrtPriceRange)),
+		Liquidity:  uint128FromLE(field(poolLiquidityRange)),
+		PoolStatus: field(poolStatusRange)[0],
+	}, nil
+}
+
+func uint128FromLE(b []byte) *big.Int {
+	return ag_binary.Uint128{
+		Lo: binary.LittleEndian.Uint64(b[:8]),
+		Hi: binary.LittleEndian.Uint64(b[8:16]),
+	}.BigInt()
+}
@@ -0,0 +1,79 @@
+package dammv2gosdk
+
+import (
+	"context"
+	"dammv2GoSDK/anchor"
+	cp_amm "dammv2GoSDK/generated/cpAmm"
+	"dammv2GoSDK/internal/test/rpctest"
+	"dammv2GoSDK/types"
+	"testing"
+
+	ag_binary "github.com/gagliardetto/binary"
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+func TestPoolPriceViews(t *testing.T) {
+	ctx := context.Background()
+	pool, missing := solana.NewWallet().PublicKey(), solana.NewWallet().PublicKey()
+	// every viewed field holds bytes telling apart its halves and neighbours.
+	state := &cp_amm.PoolAccount{
+		TokenAMint:   solana.NewWallet().PublicKey(),
+		TokenBMint:   solana.NewWallet().PublicKey(),
+		TokenAVault:  solana.NewWallet().PublicKey(),
+		Liquidity:    ag_binary.Uint128{Lo: 1, Hi: 2},
+		SqrtMaxPrice: ag_binary.Uint128{Lo: 3, Hi: 4},
+		SqrtPrice:    ag_binary.Uint128{Lo: 5, Hi: 6},
+		PoolStatus:   1,
+		TokenAFlag:   2,
+	}
+	accounts := &rpctest.Accounts{
+		Owner: CpAMMProgramId,
+		Data:  map[solana.PublicKey][]byte{pool: borshBytes(t, state)},
+	}
+	cp := NewCpAMM(rpctest.NewClient(accounts.Handle))
+
+	want := types.PoolPriceView{
+		Pool:       pool,
+		TokenAMint: state.TokenAMint,
+		TokenBMint: state.TokenBMint,
+		SqrtPrice:  state.SqrtPrice.BigInt(),
+		Liquidity:  state.Liquidity.BigInt(),
+		PoolStatus: state.PoolStatus,
+	}
+	assertView := func(got types.PoolPriceView) {
+		t.Helper()
+		if got.Pool != want.Pool || got.TokenAMint != want.TokenAMint || got.TokenBMint != want.TokenBMint ||
+			got.SqrtPrice.Cmp(want.SqrtPrice) != 0 || got.Liquidity.Cmp(want.Liquidity) != 0 || got.PoolStatus != want.PoolStatus {
+			t.Fatalf("view = %+v, want %+v", got, want)
+		}
+	}
+
+	views, err := cp.GetPoolPriceViews(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(views) != 1 {
+		t.Fatalf("views = %+v", views)
+	}
+	assertView(views[0])
+
+	views, err = cp.FetchPoolPriceViews(ctx, []solana.PublicKey{missing, pool}, rpc.CommitmentConfirmed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(views) != 2 || views[0].Pool != missing || views[0].SqrtPrice != nil {
+		t.Fatalf("views = %+v", views)
+	}
+	assertView(views[1])
+
+	// a window missing the last field can't be decoded.
+	span := anchor.SpanOf(poolPriceViewRanges...)
+	if _, err := DecodePoolPriceView(anchor.AccountSlice{
+		PublicKey: pool,
+		Offset:    span.Offset,
+		Data:      accounts.Data[pool][span.Offset : span.Offset+span.Length-1],
+	}); err == nil {
+		t.Fatal("decoded a truncated window")
+	}
+}
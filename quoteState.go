@@ -0,0 +1,82 @@
+package dammv2gosdk
+
+import (
+	"context"
+	cp_amm "dammv2GoSDK/generated/cpAmm"
+	"dammv2GoSDK/types"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/programs/token"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+// FetchQuoteState reads the pool and both of its mints and fills in the current slot,
+// block time and epoch expected by GetQuote and GetQuoteExactOut. The pool is read first,
+// CurrentSlot being the slot it was read at, then both mints in one getMultipleAccounts
+// call, at least as recent. The epoch and block time are read last.
+// It fails if the pool or either mint is missing or undecodable.
+func (cp *CpAMM) FetchQuoteState(
+	ctx context.Context,
+	pool solana.PublicKey,
+	commitment rpc.CommitmentType,
+) (types.QuoteState, error) {
+	poolInfo, err := cp.conn.GetAccountInfoWithOpts(ctx, pool, &rpc.GetAccountInfoOpts{Commitment: commitment})
+	if err != nil {
+		return types.QuoteState{}, fmt.Errorf("err fetching pool %s: %w", pool, err)
+	}
+	poolState, err := decodeAccountData(poolInfo.Value.Data, func() *cp_amm.PoolAccount { return &cp_amm.PoolAccount{} })
+	if err != nil {
+		return types.QuoteState{}, fmt.Errorf("err decoding pool %s: %w", pool, err)
+	}
+	slot := poolInfo.Context.Slot
+
+	out, err := cp.conn.GetMultipleAccountsWithOpts(
+		ctx,
+		[]solana.PublicKey{poolState.TokenAMint, poolState.TokenBMint},
+		&rpc.GetMultipleAccountsOpts{Commitment: commitment, MinContextSlot: &slot},
+	)
+	if err != nil {
+		return types.QuoteState{}, fmt.Errorf("err fetching mints: %w", err)
+	}
+	if out == nil || len(out.Value) != 2 {
+		return types.QuoteState{}, errors.New("GetMultipleAccounts returned an unexpected number of results")
+	}
+
+	var mints [2]*token.Mint
+	for i, mint := range []solana.PublicKey{poolState.TokenAMint, poolState.TokenBMint} {
+		var data *rpc.DataBytesOrJSON
+		if out.Value[i] != nil {
+			data = out.Value[i].Data
+		}
+		if mints[i], err = decodeAccountData(data, func() *token.Mint { return &token.Mint{} }); err != nil {
+			return types.QuoteState{}, fmt.Errorf("err decoding mint %s: %w", mint, err)
+		}
+	}
+
+	epochInfo, err := cp.conn.GetEpochInfo(ctx, commitment)
+	if err != nil {
+		return types.QuoteState{}, fmt.Errorf("err fetching epoch info: %w", err)
+	}
+
+	currentTime := uint64(time.Now().Unix())
+	if blockTime, err := cp.conn.GetBlockTime(ctx, slot); err == nil && blockTime != nil {
+		currentTime = uint64(*blockTime)
+	}
+
+	return types.QuoteState{
+		PoolState: poolState,
+		TokenAInfo: &types.TokenEpochInfo{
+			Mint:         *mints[0],
+			CurrentEpoch: epochInfo.Epoch,
+		},
+		TokenBInfo: &types.TokenEpochInfo{
+			Mint:         *mints[1],
+			CurrentEpoch: epochInfo.Epoch,
+		},
+		CurrentSlot: slot,
+		CurrentTime: currentTime,
+	}, nil
+}
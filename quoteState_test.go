@@ -0,0 +1,53 @@
+package dammv2gosdk
+
+import (
+	"context"
+	cp_amm "dammv2GoSDK/generated/cpAmm"
+	"dammv2GoSDK/internal/test/rpctest"
+	"encoding/json"
+	"slices"
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/programs/token"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+func TestFetchQuoteState(t *testing.T) {
+	pool := solana.NewWallet().PublicKey()
+	state := &cp_amm.PoolAccount{TokenAMint: solana.NewWallet().PublicKey(), TokenBMint: solana.NewWallet().PublicKey()}
+	accounts := &rpctest.Accounts{
+		Slot:  10,
+		Owner: CpAMMProgramId,
+		Data: map[solana.PublicKey][]byte{
+			pool:             borshBytes(t, state),
+			state.TokenAMint: borshBytes(t, &token.Mint{Decimals: 9, IsInitialized: true}),
+			state.TokenBMint: borshBytes(t, &token.Mint{Decimals: 6, IsInitialized: true}),
+		},
+	}
+	var methods []string
+	cp := NewCpAMM(rpctest.NewClient(func(method string, params []json.RawMessage) (any, error) {
+		methods = append(methods, method)
+		switch method {
+		case "getEpochInfo":
+			return map[string]any{"epoch": 7, "absoluteSlot": 10}, nil
+		case "getBlockTime":
+			return 1_000, nil
+		}
+		return accounts.Handle(method, params)
+	}))
+
+	quoteState, err := cp.FetchQuoteState(context.Background(), pool, rpc.CommitmentConfirmed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the pool is read once, the mints along with each other.
+	if want := []string{"getAccountInfo", "getMultipleAccounts", "getEpochInfo", "getBlockTime"}; !slices.Equal(methods, want) {
+		t.Fatalf("methods = %v, want %v", methods, want)
+	}
+	if quoteState.PoolState.TokenBMint != state.TokenBMint || quoteState.TokenAInfo.Mint.Decimals != 9 ||
+		quoteState.TokenBInfo.Mint.Decimals != 6 || quoteState.TokenBInfo.CurrentEpoch != 7 ||
+		quoteState.CurrentSlot != 10 || quoteState.CurrentTime != 1_000 {
+		t.Fatalf("quote state = %+v", quoteState)
+	}
+}
@@ -0,0 +1,431 @@
+// Package replay rebuilds the state of a pool out of the pool program events, from its
+// EvtInitializePool on, and checks it against the fetched pool account. Every swap and
+// liquidity change is also recomputed with the curve and fee math of the helpers package,
+// which makes the replay of a pool history a regression test of that math.
+package replay
+
+import (
+	"context"
+	dammv2gosdk "dammv2GoSDK"
+	"dammv2GoSDK/constants"
+	cp_amm "dammv2GoSDK/generated/cpAmm"
+	"dammv2GoSDK/helpers"
+	"dammv2GoSDK/types"
+	"fmt"
+	"math/big"
+
+	ag_binary "github.com/gagliardetto/binary"
+	"github.com/gagliardetto/solana-go"
+)
+
+// Fields of the pool state the reducer rebuilds, as reported by Divergence.Field. Event
+// recomputations report the field of the event they disagree with.
+const (
+	FieldLiquidity         = "Liquidity"
+	FieldSqrtPrice         = "SqrtPrice"
+	FieldFeeAPerLiquidity  = "FeeAPerLiquidity"
+	FieldFeeBPerLiquidity  = "FeeBPerLiquidity"
+	FieldProtocolAFee      = "ProtocolAFee"
+	FieldProtocolBFee      = "ProtocolBFee"
+	FieldPartnerAFee       = "PartnerAFee"
+	FieldPartnerBFee       = "PartnerBFee"
+	FieldTotalLpAFee       = "Metrics.TotalLpAFee"
+	FieldTotalLpBFee       = "Metrics.TotalLpBFee"
+	FieldTotalProtocolAFee = "Metrics.TotalProtocolAFee"
+	FieldTotalProtocolBFee = "Metrics.TotalProtocolBFee"
+	FieldTotalPartnerAFee  = "Metrics.TotalPartnerAFee"
+	FieldTotalPartnerBFee  = "Metrics.TotalPartnerBFee"
+	FieldPoolStatus        = "PoolStatus"
+
+	FieldOutputAmount = "SwapResult.OutputAmount"
+	FieldTradeFee     = "SwapResult.TradeFee"
+	FieldTokenAAmount = "TokenAAmount"
+	FieldTokenBAmount = "TokenBAmount"
+)
+
+// Divergence is a value the reducer disagrees with.
+type Divergence struct {
+	// Signature is the offending transaction: the one of the event whose recomputation
+	// diverges, or the last one that changed a rebuilt field diverging from the account.
+	Signature solana.Signature
+	Slot      uint64
+	Field     string
+	// Want is the value of the event or account, Got the rebuilt or recomputed one.
+	Want string
+	Got  string
+}
+
+func (d Divergence) String() string {
+	return fmt.Sprintf("%s: want %s, got %s (%s at slot %d)", d.Field, d.Want, d.Got, d.Signature, d.Slot)
+}
+
+// State is the part of a pool state the reducer rebuilds.
+type State struct {
+	Liquidity        *big.Int
+	SqrtPrice        *big.Int
+	SqrtMinPrice     *big.Int
+	SqrtMaxPrice     *big.Int
+	FeeAPerLiquidity *big.Int
+	FeeBPerLiquidity *big.Int
+	ProtocolAFee     uint64
+	ProtocolBFee     uint64
+	PartnerAFee      uint64
+	PartnerBFee      uint64
+	// Metrics are rebuilt but for TotalPosition.
+	Metrics    cp_amm.PoolMetrics
+	PoolStatus uint8
+}
+
+// Reducer applies the events of a pool, in order, to rebuild its state.
+// It is not safe for concurrent use.
+type Reducer struct {
+	pool        solana.PublicKey
+	initialized bool
+	state       State
+
+	collectFeeMode  types.CollectFeeMode
+	activationType  uint8
+	activationPoint uint64
+	baseFee         cp_amm.BaseFeeParameters
+	dynamicFee      bool
+
+	// changes are the events that last changed the fields of the state.
+	changes map[string]change
+}
+
+type change struct {
+	signature solana.Signature
+	slot      uint64
+}
+
+// NewReducer returns a reducer of pool, expecting its EvtInitializePool first.
+func NewReducer(pool solana.PublicKey) *Reducer {
+	return &Reducer{pool: pool, changes: make(map[string]change)}
+}
+
+// State returns the rebuilt state.
+func (r *Reducer) State() State {
+	s := r.state
+	for _, v := range []**big.Int{
+		&s.Liquidity, &s.SqrtPrice, &s.SqrtMinPrice, &s.SqrtMaxPrice, &s.FeeAPerLiquidity, &s.FeeBPerLiquidity,
+	} {
+		if *v != nil {
+			*v = new(big.Int).Set(*v)
+		}
+	}
+	return s
+}
+
+// Apply applies the event of update, checking the swaps and liquidity changes against the
+// helpers math, and the fee claims against the rebuilt fees. Events of other pools, and
+// updates without event, are ignored.
+func (r *Reducer) Apply(update dammv2gosdk.EventUpdate) ([]Divergence, error) {
+	if update.Event == nil {
+		return nil, nil
+	}
+	if evt, ok := update.Event.Data.(*cp_amm.EvtInitializePoolEventData); ok {
+		if evt.Pool == r.pool {
+			r.initialize(update, evt)
+		}
+		return nil, nil
+	}
+	if pool, ok := helpers.EventPool(update.Event.Data); !ok || pool != r.pool {
+		return nil, nil
+	}
+	if !r.initialized {
+		return nil, fmt.Errorf("%s of %s applied before the pool initialization", update.Event.Name, update.Signature)
+	}
+
+	at := change{signature: update.Signature, slot: update.Slot}
+	switch evt := update.Event.Data.(type) {
+	case *cp_amm.EvtAddLiquidityEventData:
+		divergences := r.checkLiquidityChange(at, evt.Params.LiquidityDelta.BigInt(), evt.TokenAAmount, evt.TokenBAmount, types.RoundingUp)
+		r.state.Liquidity.Add(r.state.Liquidity, evt.Params.LiquidityDelta.BigInt())
+		r.changed(at, FieldLiquidity)
+		return divergences, nil
+
+	case *cp_amm.EvtRemoveLiquidityEventData:
+		divergences := r.checkLiquidityChange(at, evt.Params.LiquidityDelta.BigInt(), evt.TokenAAmount, evt.TokenBAmount, types.RoundingDown)
+		r.state.Liquidity.Sub(r.state.Liquidity, evt.Params.LiquidityDelta.BigInt())
+		r.changed(at, FieldLiquidity)
+		return divergences, nil
+
+	case *cp_amm.EvtSwapEventData:
+		return r.swap(at, evt), nil
+
+	case *cp_amm.EvtClaimProtocolFeeEventData:
+		divergences := append(
+			r.claim(at, FieldProtocolAFee, &r.state.ProtocolAFee, evt.TokenAAmount),
+			r.claim(at, FieldProtocolBFee, &r.state.ProtocolBFee, evt.TokenBAmount)...,
+		)
+		r.changed(at, FieldProtocolAFee, FieldProtocolBFee)
+		return divergences, nil
+
+	case *cp_amm.EvtClaimPartnerFeeEventData:
+		divergences := append(
+			r.claim(at, FieldPartnerAFee, &r.state.PartnerAFee, evt.TokenAAmount),
+			r.claim(at, FieldPartnerBFee, &r.state.PartnerBFee, evt.TokenBAmount)...,
+		)
+		r.changed(at, FieldPartnerAFee, FieldPartnerBFee)
+		return divergences, nil
+
+	case *cp_amm.EvtSetPoolStatusEventData:
+		r.state.PoolStatus = evt.Status
+		r.changed(at, FieldPoolStatus)
+	}
+	return nil, nil
+}
+
+// Compare compares the rebuilt state with pool, the fetched account. It must be fetched
+// once every event up to its slot, and none after, has been applied.
+func (r *Reducer) Compare(pool *cp_amm.PoolAccount) []Divergence {
+	if !r.initialized {
+		return nil
+	}
+	s, m := r.state, pool.Metrics
+	var divergences []Divergence
+	check := func(field string, want, got any) {
+		if fmt.Sprint(want) != fmt.Sprint(got) {
+			at := r.changes[field]
+			divergences = append(divergences, Divergence{
+				Signature: at.signature,
+				Slot:      at.slot,
+				Field:     field,
+				Want:      fmt.Sprint(want),
+				Got:       fmt.Sprint(got),
+			})
+		}
+	}
+	check(FieldLiquidity, pool.Liquidity.BigInt(), s.Liquidity)
+	check(FieldSqrtPrice, pool.SqrtPrice.BigInt(), s.SqrtPrice)
+	check(FieldFeeAPerLiquidity, helpers.U256ToBigInt(pool.FeeAPerLiquidity), s.FeeAPerLiquidity)
+	check(FieldFeeBPerLiquidity, helpers.U256ToBigInt(pool.FeeBPerLiquidity), s.FeeBPerLiquidity)
+	check(FieldProtocolAFee, pool.ProtocolAFee, s.ProtocolAFee)
+	check(FieldProtocolBFee, pool.ProtocolBFee, s.ProtocolBFee)
+	check(FieldPartnerAFee, pool.PartnerAFee, s.PartnerAFee)
+	check(FieldPartnerBFee, pool.PartnerBFee, s.PartnerBFee)
+	check(FieldTotalLpAFee, m.TotalLpAFee, s.Metrics.TotalLpAFee)
+	check(FieldTotalLpBFee, m.TotalLpBFee, s.Metrics.TotalLpBFee)
+	check(FieldTotalProtocolAFee, m.TotalProtocolAFee, s.Metrics.TotalProtocolAFee)
+	check(FieldTotalProtocolBFee, m.TotalProtocolBFee, s.Metrics.TotalProtocolBFee)
+	check(FieldTotalPartnerAFee, m.TotalPartnerAFee, s.Metrics.TotalPartnerAFee)
+	check(FieldTotalPartnerBFee, m.TotalPartnerBFee, s.Metrics.TotalPartnerBFee)
+	check(FieldPoolStatus, pool.PoolStatus, s.PoolStatus)
+	return divergences
+}
+
+// Verify fetches the pool through cp and compares it with the rebuilt state, see Compare.
+func (r *Reducer) Verify(ctx context.Context, cp *dammv2gosdk.CpAMM) ([]Divergence, error) {
+	pool, err := cp.FetchPoolState(ctx, r.pool)
+	if err != nil {
+		return nil, err
+	}
+	return r.Compare(pool), nil
+}
+
+// Rebuild replays the history of pool, backfilled through cp, into a new reducer, handing
+// the divergences of its events to onDivergence. opts.Address and opts.Filter are set to
+// the pool, opts.Until and opts.Checkpoints cleared: the history must start at the pool
+// initialization. Compare the result with an account fetched at the same slot, e.g with
+// opts.Commitment finalized and no transaction since.
+func Rebuild(
+	ctx context.Context,
+	cp *dammv2gosdk.CpAMM,
+	pool solana.PublicKey,
+	opts dammv2gosdk.BackfillOpts,
+	onDivergence func(Divergence),
+) (*Reducer, error) {
+	opts.Address = pool
+	opts.Filter = dammv2gosdk.EventFilter{Pools: []solana.PublicKey{pool}}
+	opts.Until = solana.Signature{}
+	opts.Checkpoints = nil
+
+	r := NewReducer(pool)
+	err := cp.Backfill(ctx, opts, func(update dammv2gosdk.EventUpdate) error {
+		divergences, err := r.Apply(update)
+		if err != nil {
+			return err
+		}
+		for _, d := range divergences {
+			onDivergence(d)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *Reducer) initialize(update dammv2gosdk.EventUpdate, evt *cp_amm.EvtInitializePoolEventData) {
+	r.initialized = true
+	r.state = State{
+		Liquidity:        evt.Liquidity.BigInt(),
+		SqrtPrice:        evt.SqrtPrice.BigInt(),
+		SqrtMinPrice:     evt.SqrtMinPrice.BigInt(),
+		SqrtMaxPrice:     evt.SqrtMaxPrice.BigInt(),
+		FeeAPerLiquidity: new(big.Int),
+		FeeBPerLiquidity: new(big.Int),
+	}
+	r.collectFeeMode = types.CollectFeeMode(evt.CollectFeeMode)
+	r.activationType = evt.ActivationType
+	r.activationPoint = evt.ActivationPoint
+	r.baseFee = evt.PoolFees.BaseFee
+	r.dynamicFee = evt.PoolFees.DynamicFee != nil
+
+	at := change{signature: update.Signature, slot: update.Slot}
+	clear(r.changes)
+	r.changed(at, FieldLiquidity, FieldSqrtPrice, FieldPoolStatus)
+}
+
+// swap applies a swap: its price, then its fees. The price is the event's, the curve and
+// fee math are only checked against it.
+func (r *Reducer) swap(at change, evt *cp_amm.EvtSwapEventData) []Divergence {
+	var divergences []Divergence
+	diverge := func(field string, want, got any) {
+		if fmt.Sprint(want) != fmt.Sprint(got) {
+			divergences = append(divergences, Divergence{
+				Signature: at.signature,
+				Slot:      at.slot,
+				Field:     field,
+				Want:      fmt.Sprint(want),
+				Got:       fmt.Sprint(got),
+			})
+		}
+	}
+
+	result := evt.SwapResult
+	aToB := types.TradeDirection(evt.TradeDirection) == types.TradeDirectionAtoB
+	feeMode := helpers.GetFeeMode(r.collectFeeMode, !aToB)
+	tradeFee := new(big.Int).SetUint64(result.LpFee)
+	for _, fee := range []uint64{result.ProtocolFee, result.PartnerFee, result.ReferralFee} {
+		tradeFee.Add(tradeFee, new(big.Int).SetUint64(fee))
+	}
+
+	// the curve, out of the amount in net of fees on input.
+	amountIn := new(big.Int).SetUint64(evt.ActualAmountIn)
+	if feeMode.FeeOnInput {
+		amountIn.Sub(amountIn, tradeFee)
+	}
+	nextSqrtPrice := helpers.GetNextSqrtPrice(amountIn, r.state.SqrtPrice, r.state.Liquidity, aToB)
+	var amountOut *big.Int
+	if aToB {
+		amountOut = helpers.GetAmountBFromLiquidityDelta(r.state.Liquidity, r.state.SqrtPrice, nextSqrtPrice, types.RoundingDown)
+	} else {
+		amountOut = helpers.GetAmountAFromLiquidityDelta(r.state.Liquidity, r.state.SqrtPrice, nextSqrtPrice, types.RoundingDown)
+	}
+	diverge(FieldSqrtPrice, result.NextSqrtPrice.BigInt(), nextSqrtPrice)
+	feeBase := new(big.Int).SetUint64(evt.ActualAmountIn)
+	if !feeMode.FeeOnInput {
+		feeBase = new(big.Int).Set(amountOut)
+		amountOut.Sub(amountOut, tradeFee)
+	}
+	diverge(FieldOutputAmount, result.OutputAmount, amountOut)
+
+	// the trade fee, unless it depends on the volatility the dynamic fee tracks.
+	if !r.dynamicFee {
+		currentPoint := at.slot
+		if r.activationType == 1 {
+			currentPoint = evt.CurrentTimestamp
+		}
+		feeNumerator := helpers.GetFeeNumerator(
+			currentPoint,
+			new(big.Int).SetUint64(r.activationPoint),
+			r.baseFee.NumberOfPeriod,
+			new(big.Int).SetUint64(r.baseFee.PeriodFrequency),
+			types.FeeSchedulerMode(r.baseFee.FeeSchedulerMode),
+			new(big.Int).SetUint64(r.baseFee.CliffFeeNumerator),
+			new(big.Int).SetUint64(r.baseFee.ReductionFactor),
+			types.DynamicFeeParams{},
+		)
+		diverge(FieldTradeFee, tradeFee, helpers.GetTotalFeeOnAmount(feeBase, feeNumerator))
+	}
+
+	s := &r.state
+	s.SqrtPrice = result.NextSqrtPrice.BigInt()
+	r.changed(at, FieldSqrtPrice)
+
+	feePerLiquidity := new(big.Int)
+	if s.Liquidity.Sign() > 0 {
+		feePerLiquidity.Lsh(new(big.Int).SetUint64(result.LpFee), constants.LiquidityScale)
+		feePerLiquidity.Div(feePerLiquidity, s.Liquidity)
+	}
+	if feeMode.FeesOnTokenA {
+		s.FeeAPerLiquidity.Add(s.FeeAPerLiquidity, feePerLiquidity)
+		s.ProtocolAFee += result.ProtocolFee
+		s.PartnerAFee += result.PartnerFee
+		s.Metrics.TotalLpAFee = addUint128(s.Metrics.TotalLpAFee, result.LpFee)
+		s.Metrics.TotalProtocolAFee += result.ProtocolFee
+		s.Metrics.TotalPartnerAFee += result.PartnerFee
+		r.changed(at, FieldFeeAPerLiquidity, FieldProtocolAFee, FieldPartnerAFee,
+			FieldTotalLpAFee, FieldTotalProtocolAFee, FieldTotalPartnerAFee)
+	} else {
+		s.FeeBPerLiquidity.Add(s.FeeBPerLiquidity, feePerLiquidity)
+		s.ProtocolBFee += result.ProtocolFee
+		s.PartnerBFee += result.PartnerFee
+		s.Metrics.TotalLpBFee = addUint128(s.Metrics.TotalLpBFee, result.LpFee)
+		s.Metrics.TotalProtocolBFee += result.ProtocolFee
+		s.Metrics.TotalPartnerBFee += result.PartnerFee
+		r.changed(at, FieldFeeBPerLiquidity, FieldProtocolBFee, FieldPartnerBFee,
+			FieldTotalLpBFee, FieldTotalProtocolBFee, FieldTotalPartnerBFee)
+	}
+	return divergences
+}
+
+// checkLiquidityChange recomputes the token amounts of a liquidity change of delta at the
+// current price.
+func (r *Reducer) checkLiquidityChange(
+	at change,
+	delta *big.Int,
+	amountA, amountB uint64,
+	rounding types.Rounding,
+) []Divergence {
+	var divergences []Divergence
+	for _, c := range []struct {
+		field string
+		want  uint64
+		got   *big.Int
+	}{
+		{FieldTokenAAmount, amountA, helpers.GetAmountAFromLiquidityDelta(delta, r.state.SqrtPrice, r.state.SqrtMaxPrice, rounding)},
+		{FieldTokenBAmount, amountB, helpers.GetAmountBFromLiquidityDelta(delta, r.state.SqrtPrice, r.state.SqrtMinPrice, rounding)},
+	} {
+		if c.got.Cmp(new(big.Int).SetUint64(c.want)) != 0 {
+			divergences = append(divergences, Divergence{
+				Signature: at.signature,
+				Slot:      at.slot,
+				Field:     c.field,
+				Want:      fmt.Sprint(c.want),
+				Got:       c.got.String(),
+			})
+		}
+	}
+	return divergences
+}
+
+func (r *Reducer) changed(at change, fields ...string) {
+	for _, field := range fields {
+		r.changes[field] = at
+	}
+}
+
+// claim takes amount off the rebuilt fee of field. A claim of more than the rebuilt fee,
+// e.g out of an incomplete history, diverges and leaves no fee.
+func (r *Reducer) claim(at change, field string, fee *uint64, amount uint64) []Divergence {
+	if amount > *fee {
+		divergence := Divergence{
+			Signature: at.signature,
+			Slot:      at.slot,
+			Field:     field,
+			Want:      fmt.Sprint(amount),
+			Got:       fmt.Sprint(*fee),
+		}
+		*fee = 0
+		return []Divergence{divergence}
+	}
+	*fee -= amount
+	return nil
+}
+
+func addUint128(a ag_binary.Uint128, b uint64) ag_binary.Uint128 {
+	return helpers.MustBigIntToUint128(new(big.Int).Add(a.BigInt(), new(big.Int).SetUint64(b)))
+}
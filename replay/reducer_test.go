@@ -0,0 +1,130 @@
+package replay
+
+import (
+	dammv2gosdk "dammv2GoSDK"
+	cp_amm "dammv2GoSDK/generated/cpAmm"
+	"dammv2GoSDK/helpers"
+	"dammv2GoSDK/internal/test/eventtest"
+	"dammv2GoSDK/types"
+	"math/big"
+	"testing"
+
+	ag_binary "github.com/gagliardetto/binary"
+	"github.com/gagliardetto/solana-go"
+)
+
+func TestReducer(t *testing.T) {
+	pool := solana.NewWallet().PublicKey()
+	var (
+		sqrtPrice    = ag_binary.Uint128{Hi: 2}
+		sqrtMinPrice = ag_binary.Uint128{Hi: 1}
+		sqrtMaxPrice = ag_binary.Uint128{Hi: 1 << 40}
+		liquidity    = ag_binary.Uint128{Hi: 1_000_000}
+		// 0.25%, with the fee denominator of 1e9.
+		feeNumerator = big.NewInt(2_500_000)
+	)
+	r := NewReducer(pool)
+	apply := func(update dammv2gosdk.EventUpdate) []Divergence {
+		t.Helper()
+		divergences, err := r.Apply(update)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return divergences
+	}
+
+	if _, err := r.Apply(eventtest.Update(1, 9, "EvtSwap", &cp_amm.EvtSwapEventData{Pool: pool})); err == nil {
+		t.Fatal("events before the initialization can't be applied")
+	}
+	apply(eventtest.Update(1, 10, "EvtInitializePool", &cp_amm.EvtInitializePoolEventData{
+		Pool:         pool,
+		PoolFees:     cp_amm.PoolFeeParameters{BaseFee: cp_amm.BaseFeeParameters{CliffFeeNumerator: feeNumerator.Uint64()}},
+		SqrtMinPrice: sqrtMinPrice,
+		SqrtMaxPrice: sqrtMaxPrice,
+		Liquidity:    liquidity,
+		SqrtPrice:    sqrtPrice,
+	}))
+
+	add := &cp_amm.EvtAddLiquidityEventData{
+		Pool:         pool,
+		Params:       cp_amm.AddLiquidityParameters{LiquidityDelta: liquidity},
+		TokenAAmount: helpers.GetAmountAFromLiquidityDelta(liquidity.BigInt(), sqrtPrice.BigInt(), sqrtMaxPrice.BigInt(), types.RoundingUp).Uint64(),
+		TokenBAmount: helpers.GetAmountBFromLiquidityDelta(liquidity.BigInt(), sqrtPrice.BigInt(), sqrtMinPrice.BigInt(), types.RoundingUp).Uint64(),
+	}
+	if divergences := apply(eventtest.Update(2, 11, "EvtAddLiquidity", add)); len(divergences) != 0 {
+		t.Fatalf("divergences = %v", divergences)
+	}
+
+	// fees of A to B swaps are charged in token B, on the output. 20% of them go to the protocol.
+	totalLiquidity := new(big.Int).Lsh(liquidity.BigInt(), 1)
+	quote := helpers.GetSwapAmount(big.NewInt(1_000_000), sqrtPrice.BigInt(), totalLiquidity, feeNumerator, true, types.CollectFeeModeBothToken)
+	protocolFee := quote.TotalFee.Uint64() / 5
+	lpFee := quote.TotalFee.Uint64() - protocolFee
+	swap := &cp_amm.EvtSwapEventData{
+		Pool:           pool,
+		TradeDirection: uint8(types.TradeDirectionAtoB),
+		ActualAmountIn: 1_000_000,
+		SwapResult: cp_amm.SwapResult{
+			OutputAmount:  quote.AmountOut.Uint64(),
+			NextSqrtPrice: helpers.MustBigIntToUint128(quote.NextSqrtPrice),
+			LpFee:         lpFee,
+			ProtocolFee:   protocolFee,
+		},
+	}
+	if divergences := apply(eventtest.Update(3, 12, "EvtSwap", swap)); len(divergences) != 0 {
+		t.Fatalf("divergences = %v", divergences)
+	}
+	claim := &cp_amm.EvtClaimProtocolFeeEventData{Pool: pool, TokenBAmount: protocolFee}
+	if divergences := apply(eventtest.Update(4, 13, "EvtClaimProtocolFee", claim)); len(divergences) != 0 {
+		t.Fatalf("divergences = %v", divergences)
+	}
+	// events of other pools are ignored.
+	apply(eventtest.Update(5, 13, "EvtRemoveLiquidity", &cp_amm.EvtRemoveLiquidityEventData{
+		Pool:   solana.NewWallet().PublicKey(),
+		Params: cp_amm.RemoveLiquidityParameters{LiquidityDelta: liquidity},
+	}))
+
+	feePerLiquidity := new(big.Int).Lsh(new(big.Int).SetUint64(lpFee), 128)
+	feePerLiquidity.Div(feePerLiquidity, totalLiquidity)
+	account := &cp_amm.PoolAccount{
+		Liquidity:    helpers.MustBigIntToUint128(totalLiquidity),
+		SqrtPrice:    swap.SwapResult.NextSqrtPrice,
+		SqrtMinPrice: sqrtMinPrice,
+		SqrtMaxPrice: sqrtMaxPrice,
+		Metrics: cp_amm.PoolMetrics{
+			TotalLpBFee:       ag_binary.Uint128{Lo: lpFee},
+			TotalProtocolBFee: protocolFee,
+		},
+	}
+	feePerLiquidity.FillBytes(account.FeeBPerLiquidity[:])
+	ag_binary.ReverseBytes(account.FeeBPerLiquidity[:])
+	if divergences := r.Compare(account); len(divergences) != 0 {
+		t.Fatalf("divergences = %v", divergences)
+	}
+
+	// the liquidity of the account diverges since the liquidity was added.
+	account.Liquidity = liquidity
+	divergences := r.Compare(account)
+	if len(divergences) != 1 || divergences[0].Field != FieldLiquidity || divergences[0].Signature != (solana.Signature{2}) {
+		t.Fatalf("divergences = %v", divergences)
+	}
+
+	// a swap paying out one token more than the curve.
+	quote = helpers.GetSwapAmount(big.NewInt(1_000_000), quote.NextSqrtPrice, totalLiquidity, feeNumerator, true, types.CollectFeeModeBothToken)
+	swap.SwapResult = cp_amm.SwapResult{
+		OutputAmount:  quote.AmountOut.Uint64() + 1,
+		NextSqrtPrice: helpers.MustBigIntToUint128(quote.NextSqrtPrice),
+		LpFee:         quote.TotalFee.Uint64(),
+	}
+	divergences = apply(eventtest.Update(6, 14, "EvtSwap", swap))
+	if len(divergences) != 1 || divergences[0].Field != FieldOutputAmount || divergences[0].Signature != (solana.Signature{6}) {
+		t.Fatalf("divergences = %v", divergences)
+	}
+
+	// a claim of fees the history didn't collect, e.g out of an incomplete one.
+	divergences = apply(eventtest.Update(7, 15, "EvtClaimPartnerFee", &cp_amm.EvtClaimPartnerFeeEventData{Pool: pool, TokenAAmount: 1}))
+	if len(divergences) != 1 || divergences[0].Field != FieldPartnerAFee || divergences[0].Want != "1" ||
+		divergences[0].Got != "0" || r.State().PartnerAFee != 0 {
+		t.Fatalf("divergences = %v", divergences)
+	}
+}
@@ -0,0 +1,98 @@
+package dammv2gosdk
+
+import (
+	"context"
+	"dammv2GoSDK/anchor"
+	cp_amm "dammv2GoSDK/generated/cpAmm"
+	"dammv2GoSDK/types"
+	"fmt"
+
+	"github.com/gagliardetto/solana-go"
+)
+
+// Views returns the simulation API of the pool program, running any instruction
+// through simulateTransaction without signing or sending it.
+func (cp *CpAMM) Views() *anchor.Views {
+	return anchor.NewViews(cp.conn, CpAMMProgramId)
+}
+
+// SimulateSwap simulates the transaction Swap builds and returns the exact on-chain
+// outcome of the swap: amounts, fees and the next sqrt price as emitted by EvtSwap.
+// A failing swap returns the program error, e.g cp_amm.ErrExceededSlippage.
+func (cp *CpAMM) SimulateSwap(
+	ctx context.Context,
+	param types.SwapParams,
+) (*types.SimulatedQuote[cp_amm.EvtSwapEventData], error) {
+	ixns, err := cp.Swap(ctx, param)
+	if err != nil {
+		return nil, err
+	}
+	return simulateQuote[cp_amm.EvtSwapEventData](ctx, cp, param.Payer, param.Pool, ixns, "EvtSwap")
+}
+
+// SimulateAddLiquidity simulates the transaction AddLiquidity builds and returns
+// the token amounts actually deposited, as emitted by EvtAddLiquidity.
+func (cp *CpAMM) SimulateAddLiquidity(
+	ctx context.Context,
+	param types.AddLiquidityParams,
+) (*types.SimulatedQuote[cp_amm.EvtAddLiquidityEventData], error) {
+	ixns, err := cp.AddLiquidity(ctx, param)
+	if err != nil {
+		return nil, err
+	}
+	return simulateQuote[cp_amm.EvtAddLiquidityEventData](ctx, cp, param.Owner, param.Pool, ixns, "EvtAddLiquidity")
+}
+
+// SimulateRemoveLiquidity simulates the transaction RemoveLiquidity builds and returns
+// the token amounts actually withdrawn, as emitted by EvtRemoveLiquidity.
+func (cp *CpAMM) SimulateRemoveLiquidity(
+	ctx context.Context,
+	param types.RemoveLiquidityParams,
+) (*types.SimulatedQuote[cp_amm.EvtRemoveLiquidityEventData], error) {
+	ixns, err := cp.RemoveLiquidity(ctx, param)
+	if err != nil {
+		return nil, err
+	}
+	return simulateQuote[cp_amm.EvtRemoveLiquidityEventData](ctx, cp, param.Owner, param.Pool, ixns, "EvtRemoveLiquidity")
+}
+
+func simulateQuote[E any](
+	ctx context.Context,
+	cp *CpAMM,
+	feePayer solana.PublicKey,
+	pool solana.PublicKey,
+	ixns []solana.Instruction,
+	eventName string,
+) (*types.SimulatedQuote[E], error) {
+	res, err := cp.Views().Simulate(ctx, feePayer, ixns, anchor.ViewOpts{
+		Accounts: []solana.PublicKey{pool},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if res.Err != nil {
+		return nil, res.Err
+	}
+
+	event, ok := any(res.Event(eventName)).(*E)
+	if !ok {
+		return nil, fmt.Errorf("simulation emitted no %s event", eventName)
+	}
+
+	poolState, err := anchor.DecodeViewAccount(
+		res,
+		pool,
+		func() *cp_amm.PoolAccount { return &cp_amm.PoolAccount{} },
+	)
+	if err != nil {
+		return nil, fmt.Errorf("err decoding simulated pool state: %w", err)
+	}
+
+	return &types.SimulatedQuote[E]{
+		Event:         event,
+		PoolState:     poolState,
+		UnitsConsumed: res.UnitsConsumed,
+		Slot:          res.Slot,
+		Logs:          res.Logs,
+	}, nil
+}
@@ -0,0 +1,541 @@
+package dammv2gosdk
+
+import (
+	"context"
+	"dammv2GoSDK/anchor"
+	cp_amm "dammv2GoSDK/generated/cpAmm"
+	"errors"
+	"fmt"
+	"time"
+
+	ag_binary "github.com/gagliardetto/binary"
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/gagliardetto/solana-go/rpc/ws"
+)
+
+// BackpressurePolicy decides what a subscription does when its output channel is full.
+type BackpressurePolicy uint8
+
+const (
+	// BackpressureDropOldest discards the oldest buffered update to make room for the newest one,
+	// the reader never stalls and consumers always converge on the latest state.
+	BackpressureDropOldest BackpressurePolicy = iota
+	// BackpressureBlock waits for the consumer. Updates are never dropped locally,
+	// the websocket isn't read while the consumer stays behind.
+	BackpressureBlock
+)
+
+// SubscriptionOpts configures SubscribePool, SubscribePosition, SubscribeAllPools and SubscribeEvents.
+type SubscriptionOpts struct {
+	// Commitment of the notifications, defaults to confirmed.
+	Commitment rpc.CommitmentType
+	// BufferSize is the capacity of the returned channel, defaults to 16.
+	BufferSize int
+	// Backpressure is the policy applied when the returned channel is full.
+	Backpressure BackpressurePolicy
+	// MinReconnectDelay and MaxReconnectDelay bound the exponential backoff between reconnects,
+	// they default to 500ms and 30s.
+	MinReconnectDelay time.Duration
+	MaxReconnectDelay time.Duration
+	// OnError, when set, is called with every connection, subscription or decoding error.
+	// The subscription keeps running after reporting it.
+	OnError func(error)
+}
+
+func (o SubscriptionOpts) withDefaults() SubscriptionOpts {
+	if o.Commitment == "" {
+		o.Commitment = rpc.CommitmentConfirmed
+	}
+	if o.BufferSize <= 0 {
+		o.BufferSize = 16
+	}
+	if o.MinReconnectDelay <= 0 {
+		o.MinReconnectDelay = 500 * time.Millisecond
+	}
+	if o.MaxReconnectDelay < o.MinReconnectDelay {
+		o.MaxReconnectDelay = max(30*time.Second, o.MinReconnectDelay)
+	}
+	return o
+}
+
+// PoolUpdate is a decoded pool account notification.
+type PoolUpdate struct {
+	Pool  solana.PublicKey
+	Slot  uint64
+	State *cp_amm.PoolAccount
+}
+
+// PositionUpdate is a decoded position account notification.
+type PositionUpdate struct {
+	Position solana.PublicKey
+	Slot     uint64
+	State    *cp_amm.PositionAccount
+}
+
+var errNoWSEndpoint = errors.New("no websocket endpoint configured, see WithWSEndpoint")
+
+// SubscribePool streams every change of the pool account until ctx is done.
+// The channel is closed once the subscription stops.
+//
+// Every reconnect delivers the pool as read right after resubscribing, so changes made
+// while disconnected are caught up on.
+func (cp *CpAMM) SubscribePool(
+	ctx context.Context,
+	pool solana.PublicKey,
+	opts SubscriptionOpts,
+) (<-chan PoolUpdate, error) {
+	if cp.wsEndpoint == "" {
+		return nil, errNoWSEndpoint
+	}
+
+	opts = opts.withDefaults()
+	newPool := func() *cp_amm.PoolAccount { return &cp_amm.PoolAccount{} }
+
+	// subscribed is only touched from the subscription goroutine.
+	var subscribed bool
+	return runSubscription(
+		ctx,
+		cp.wsEndpoint,
+		opts,
+		func(client *ws.Client) (func(context.Context) ([]PoolUpdate, error), func(), error) {
+			sub, err := client.AccountSubscribe(pool, opts.Commitment)
+			if err != nil {
+				return nil, nil, err
+			}
+
+			resync := subscribed
+			subscribed = true
+			recv := func(ctx context.Context) ([]PoolUpdate, error) {
+				if resync {
+					resync = false
+					state, slot, err := resyncAccount(ctx, cp.conn, pool, opts.Commitment, newPool)
+					if err != nil {
+						return nil, err
+					}
+					cp.storeSubscribed(pool, state, slot)
+					return []PoolUpdate{{Pool: pool, Slot: slot, State: state}}, nil
+				}
+
+				res, err := sub.Recv(ctx)
+				if err != nil {
+					return nil, err
+				}
+				state, err := decodeAccountData(res.Value.Data, newPool)
+				if err != nil {
+					return nil, &decodeError{fmt.Errorf("decoding pool %s: %w", pool, err)}
+				}
+				cp.storeSubscribed(pool, state, res.Context.Slot)
+
+				return []PoolUpdate{{Pool: pool, Slot: res.Context.Slot, State: state}}, nil
+			}
+			return recv, sub.Unsubscribe, nil
+		},
+	), nil
+}
+
+// SubscribePosition streams every change of the position account until ctx is done.
+// The channel is closed once the subscription stops.
+//
+// Every reconnect delivers the position as read right after resubscribing, so changes
+// made while disconnected are caught up on.
+func (cp *CpAMM) SubscribePosition(
+	ctx context.Context,
+	position solana.PublicKey,
+	opts SubscriptionOpts,
+) (<-chan PositionUpdate, error) {
+	if cp.wsEndpoint == "" {
+		return nil, errNoWSEndpoint
+	}
+
+	opts = opts.withDefaults()
+	newPosition := func() *cp_amm.PositionAccount { return &cp_amm.PositionAccount{} }
+
+	// subscribed is only touched from the subscription goroutine.
+	var subscribed bool
+	return runSubscription(
+		ctx,
+		cp.wsEndpoint,
+		opts,
+		func(client *ws.Client) (func(context.Context) ([]PositionUpdate, error), func(), error) {
+			sub, err := client.AccountSubscribe(position, opts.Commitment)
+			if err != nil {
+				return nil, nil, err
+			}
+
+			resync := subscribed
+			subscribed = true
+			recv := func(ctx context.Context) ([]PositionUpdate, error) {
+				if resync {
+					resync = false
+					state, slot, err := resyncAccount(ctx, cp.conn, position, opts.Commitment, newPosition)
+					if err != nil {
+						return nil, err
+					}
+					cp.storeSubscribed(position, state, slot)
+					return []PositionUpdate{{Position: position, Slot: slot, State: state}}, nil
+				}
+
+				res, err := sub.Recv(ctx)
+				if err != nil {
+					return nil, err
+				}
+				state, err := decodeAccountData(res.Value.Data, newPosition)
+				if err != nil {
+					return nil, &decodeError{fmt.Errorf("decoding position %s: %w", position, err)}
+				}
+				cp.storeSubscribed(position, state, res.Context.Slot)
+
+				return []PositionUpdate{{Position: position, Slot: res.Context.Slot, State: state}}, nil
+			}
+			return recv, sub.Unsubscribe, nil
+		},
+	), nil
+}
+
+// SubscribeAllPools streams changes of every pool account owned by the program until ctx is done.
+// Extra filters are and-ed with the pool discriminator filter.
+// The channel is closed once the subscription stops.
+//
+// Every reconnect delivers each pool passing the filters as read right after resubscribing,
+// so changes made while disconnected are caught up on.
+func (cp *CpAMM) SubscribeAllPools(
+	ctx context.Context,
+	filters []rpc.RPCFilter,
+	opts SubscriptionOpts,
+) (<-chan PoolUpdate, error) {
+	if cp.wsEndpoint == "" {
+		return nil, errNoWSEndpoint
+	}
+
+	opts = opts.withDefaults()
+	programFilters := make([]rpc.RPCFilter, 0, 1+len(filters))
+	programFilters = append(programFilters, rpc.RPCFilter{
+		Memcmp: &rpc.RPCFilterMemcmp{
+			Offset: 0,
+			Bytes:  cp_amm.PoolAccountDiscriminator[:],
+		},
+	})
+	programFilters = append(programFilters, filters...)
+	newPool := func() *cp_amm.PoolAccount { return &cp_amm.PoolAccount{} }
+
+	// subscribed is only touched from the subscription goroutine.
+	var subscribed bool
+	return runSubscription(
+		ctx,
+		cp.wsEndpoint,
+		opts,
+		func(client *ws.Client) (func(context.Context) ([]PoolUpdate, error), func(), error) {
+			sub, err := client.ProgramSubscribeWithOpts(
+				CpAMMProgramId,
+				opts.Commitment,
+				solana.EncodingBase64,
+				programFilters,
+			)
+			if err != nil {
+				return nil, nil, err
+			}
+
+			resync := subscribed
+			subscribed = true
+			recv := func(ctx context.Context) ([]PoolUpdate, error) {
+				if resync {
+					resync = false
+					pools, err := anchor.NewPgAccounts(cp.conn, newPool).AllResults(
+						ctx,
+						CpAMMProgramId,
+						cp_amm.PoolAccountDiscriminator,
+						filters,
+						nil,
+						anchor.FetchModeLenient,
+					)
+					if err != nil {
+						return nil, err
+					}
+
+					updates := make([]PoolUpdate, 0, len(pools))
+					var errs []error
+					for _, res := range pools {
+						if !res.Ok() {
+							errs = append(errs, fmt.Errorf("resyncing pool %s: %w", res.PublicKey, res.Err))
+							continue
+						}
+						cp.storeSubscribed(res.PublicKey, res.Value, res.Slot)
+						updates = append(updates, PoolUpdate{Pool: res.PublicKey, Slot: res.Slot, State: res.Value})
+					}
+					if len(errs) > 0 {
+						return updates, &decodeError{errors.Join(errs...)}
+					}
+					return updates, nil
+				}
+
+				res, err := sub.Recv(ctx)
+				if err != nil {
+					return nil, err
+				}
+				if res.Value.Account == nil {
+					return nil, nil
+				}
+				state, err := decodeAccountData(res.Value.Account.Data, newPool)
+				if err != nil {
+					return nil, &decodeError{fmt.Errorf("decoding pool %s: %w", res.Value.Pubkey, err)}
+				}
+				cp.storeSubscribed(res.Value.Pubkey, state, res.Context.Slot)
+
+				return []PoolUpdate{{Pool: res.Value.Pubkey, Slot: res.Context.Slot, State: state}}, nil
+			}
+			return recv, sub.Unsubscribe, nil
+		},
+	), nil
+}
+
+// SubscribeEvents streams the events the pool program emits in successful transactions,
+// as they pass filter, until ctx is done. The channel is closed once the subscription stops.
+//
+// Events are decoded from the program's "Program data:" log lines. When the logs can't
+// hold every event, i.e the program emitted them through self invocations or the logs
+// were truncated, the transaction is read instead.
+//
+// Every reconnect after the first notification delivers an EventUpdate with a Gap
+// before the events that follow it. Consider BackpressureBlock, events dropped under
+// BackpressureDropOldest are not reported.
+func (cp *CpAMM) SubscribeEvents(
+	ctx context.Context,
+	filter EventFilter,
+	opts SubscriptionOpts,
+) (<-chan EventUpdate, error) {
+	if cp.wsEndpoint == "" {
+		return nil, errNoWSEndpoint
+	}
+
+	opts = opts.withDefaults()
+	mentions := filter.mentions()
+
+	// lastSlot is only touched from the subscription goroutine.
+	var lastSlot uint64
+	return runSubscription(
+		ctx,
+		cp.wsEndpoint,
+		opts,
+		func(client *ws.Client) (func(context.Context) ([]EventUpdate, error), func(), error) {
+			sub, err := client.LogsSubscribeMentions(mentions, opts.Commitment)
+			if err != nil {
+				return nil, nil, err
+			}
+
+			resumed := lastSlot != 0
+			recv := func(ctx context.Context) ([]EventUpdate, error) {
+				res, err := sub.Recv(ctx)
+				if err != nil {
+					return nil, err
+				}
+
+				slot := res.Context.Slot
+				var updates []EventUpdate
+				if resumed {
+					resumed = false
+					updates = append(updates, EventUpdate{Slot: slot, Gap: &SlotGap{FromSlot: lastSlot, ToSlot: slot}})
+				}
+				lastSlot = max(lastSlot, slot)
+
+				if res.Value.Err != nil {
+					return updates, nil
+				}
+
+				signature := res.Value.Signature
+				events, complete, err := decodeLogEvents(res.Value.Logs)
+				if err == nil && !complete {
+					events, err = cp.transactionEvents(ctx, signature, opts.Commitment, nil)
+				}
+				if err != nil {
+					// the gap, if any, is still worth delivering.
+					return updates, &decodeError{fmt.Errorf("decoding events of %s: %w", signature, err)}
+				}
+
+				for i, evt := range events {
+					if filter.Match(evt) {
+						updates = append(updates, EventUpdate{Signature: signature, Slot: slot, Event: evt, Index: i})
+					}
+				}
+				return updates, nil
+			}
+			return recv, sub.Unsubscribe, nil
+		},
+	), nil
+}
+
+// storeSubscribed keeps the account cache in step with websocket notifications.
+func (cp *CpAMM) storeSubscribed(key solana.PublicKey, state any, slot uint64) {
+	if cp.cache == nil {
+		return
+	}
+	cp.cache.Set(key, state, slot)
+}
+
+// resyncAccount reads the state of key at commitment, to catch up on the notifications
+// missed while a subscription was down. Decoding failures, a missing account included,
+// are decode errors.
+func resyncAccount[T anchor.PgAccountI](
+	ctx context.Context,
+	conn *rpc.Client,
+	key solana.PublicKey,
+	commitment rpc.CommitmentType,
+	newAccount func() T,
+) (T, uint64, error) {
+	var zeroValue T
+	res, err := anchor.NewPgAccounts(conn, newAccount).FetchMultipleResults(
+		ctx,
+		[]solana.PublicKey{key},
+		&rpc.GetMultipleAccountsOpts{Commitment: commitment},
+		anchor.FetchModeLenient,
+	)
+	if err != nil {
+		return zeroValue, 0, err
+	}
+	if res[0].Err != nil {
+		return zeroValue, 0, &decodeError{fmt.Errorf("resyncing %s: %w", key, res[0].Err)}
+	}
+	return res[0].Value, res[0].Slot, nil
+}
+
+func decodeAccountData[T interface {
+	UnmarshalWithDecoder(*ag_binary.Decoder) error
+}](data *rpc.DataBytesOrJSON, newAccount func() T) (T, error) {
+	account := newAccount()
+	if data == nil || len(data.GetBinary()) == 0 {
+		var zeroValue T
+		return zeroValue, errors.New("empty account data")
+	}
+	if err := account.UnmarshalWithDecoder(ag_binary.NewBorshDecoder(data.GetBinary())); err != nil {
+		var zeroValue T
+		return zeroValue, err
+	}
+	return account, nil
+}
+
+// runSubscription keeps a websocket subscription alive until ctx is done: it dials,
+// subscribes through open, forwards every received value to the returned channel and,
+// on any failure, reconnects and resubscribes with exponential backoff.
+func runSubscription[T any](
+	ctx context.Context,
+	endpoint string,
+	opts SubscriptionOpts,
+	open func(client *ws.Client) (recv func(context.Context) ([]T, error), unsubscribe func(), err error),
+) <-chan T {
+	out := make(chan T, opts.BufferSize)
+
+	reportErr := func(err error) {
+		if opts.OnError != nil && err != nil && ctx.Err() == nil {
+			opts.OnError(err)
+		}
+	}
+
+	go func() {
+		defer close(out)
+
+		backoff := reconnectBackoff{min: opts.MinReconnectDelay, max: opts.MaxReconnectDelay}
+		for ctx.Err() == nil {
+			received := func() bool {
+				client, err := ws.Connect(ctx, endpoint)
+				if err != nil {
+					reportErr(fmt.Errorf("ws connect: %w", err))
+					return false
+				}
+				defer client.Close()
+
+				recv, unsubscribe, err := open(client)
+				if err != nil {
+					reportErr(fmt.Errorf("ws subscribe: %w", err))
+					return false
+				}
+				defer unsubscribe()
+
+				received := false
+				for {
+					// values may come along a decode error, they are delivered all the same.
+					values, err := recv(ctx)
+					for _, v := range values {
+						if !deliver(ctx, out, v, opts.Backpressure) {
+							return received
+						}
+					}
+					if err != nil {
+						if ctx.Err() != nil {
+							return received
+						}
+						reportErr(err)
+						// a decode error leaves the subscription healthy, anything else forces a reconnect.
+						if !isDecodeErr(err) {
+							return received
+						}
+						continue
+					}
+					received = true
+				}
+			}()
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(backoff.next(received)):
+			}
+		}
+	}()
+
+	return out
+}
+
+// reconnectBackoff doubles the delay between reconnects from min up to max,
+// and starts over from min once a connection received something.
+type reconnectBackoff struct {
+	min, max, delay time.Duration
+}
+
+// next returns the delay to wait before reconnecting, received tells whether the
+// connection that just went down received anything.
+func (b *reconnectBackoff) next(received bool) time.Duration {
+	if received || b.delay == 0 {
+		b.delay = b.min
+	}
+	delay := b.delay
+	b.delay = min(b.delay*2, b.max)
+	return delay
+}
+
+func isDecodeErr(err error) bool {
+	var target *decodeError
+	return errors.As(err, &target)
+}
+
+// decodeError marks errors raised while decoding a notification, as opposed to transport errors.
+type decodeError struct{ err error }
+
+func (e *decodeError) Error() string { return e.err.Error() }
+func (e *decodeError) Unwrap() error { return e.err }
+
+// deliver forwards v according to policy, it returns false once ctx is done.
+func deliver[T any](ctx context.Context, out chan T, v T, policy BackpressurePolicy) bool {
+	if policy == BackpressureBlock {
+		select {
+		case out <- v:
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	}
+
+	for {
+		select {
+		case out <- v:
+			return true
+		case <-ctx.Done():
+			return false
+		default:
+		}
+		// channel full: drop the oldest buffered value and retry.
+		select {
+		case <-out:
+		default:
+		}
+	}
+}
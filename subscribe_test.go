@@ -0,0 +1,159 @@
+package dammv2gosdk
+
+import (
+	"context"
+	cp_amm "dammv2GoSDK/generated/cpAmm"
+	"dammv2GoSDK/internal/test/rpctest"
+	"encoding/json"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	ag_binary "github.com/gagliardetto/binary"
+	"github.com/gagliardetto/solana-go"
+)
+
+func TestDeliver(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("drop oldest", func(t *testing.T) {
+		out := make(chan int, 2)
+		for v := range 4 {
+			if !deliver(ctx, out, v, BackpressureDropOldest) {
+				t.Fatal("deliver stopped")
+			}
+		}
+		if got := []int{<-out, <-out}; !slices.Equal(got, []int{2, 3}) {
+			t.Fatalf("buffered %v, want the newest values", got)
+		}
+	})
+
+	t.Run("block", func(t *testing.T) {
+		out := make(chan int, 1)
+		deliver(ctx, out, 0, BackpressureBlock)
+
+		delivered := make(chan bool)
+		go func() { delivered <- deliver(ctx, out, 1, BackpressureBlock) }()
+		select {
+		case <-delivered:
+			t.Fatal("deliver didn't wait for the consumer")
+		case <-time.After(10 * time.Millisecond):
+		}
+		if v := <-out; v != 0 {
+			t.Fatalf("received %d, want 0", v)
+		}
+		if !<-delivered {
+			t.Fatal("deliver stopped")
+		}
+		if v := <-out; v != 1 {
+			t.Fatalf("received %d, want 1", v)
+		}
+	})
+
+	for _, policy := range []BackpressurePolicy{BackpressureDropOldest, BackpressureBlock} {
+		ctx, cancel := context.WithCancel(ctx)
+		cancel()
+		out := make(chan int)
+		if deliver(ctx, out, 0, policy) {
+			t.Fatalf("policy %d delivered once ctx is done", policy)
+		}
+	}
+}
+
+func TestReconnectBackoff(t *testing.T) {
+	const ms = time.Millisecond
+	for _, tt := range []struct {
+		name     string
+		received []bool
+		want     []time.Duration
+	}{
+		{
+			name:     "doubles up to max",
+			received: []bool{false, false, false, false, false},
+			want:     []time.Duration{10 * ms, 20 * ms, 40 * ms, 50 * ms, 50 * ms},
+		},
+		{
+			name:     "starts over once received",
+			received: []bool{false, false, true, false},
+			want:     []time.Duration{10 * ms, 20 * ms, 10 * ms, 20 * ms},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			b := reconnectBackoff{min: 10 * ms, max: 50 * ms}
+			var got []time.Duration
+			for _, received := range tt.received {
+				got = append(got, b.next(received))
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Fatalf("delays = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSubscribePoolReconnect(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	pool := solana.NewWallet().PublicKey()
+	poolAt := func(slot uint64) []byte {
+		return borshBytes(t, &cp_amm.PoolAccount{SqrtPrice: ag_binary.Uint128{Lo: slot}})
+	}
+	server := rpctest.NewWSServer(t)
+	conn := rpctest.NewClient(func(method string, params []json.RawMessage) (any, error) {
+		if method != "getMultipleAccounts" {
+			return nil, errors.New("unexpected method " + method)
+		}
+		return rpctest.WithContext(12, []any{rpctest.Account(CpAMMProgramId, poolAt(12))}), nil
+	})
+	cp := NewCpAMM(conn, WithWSEndpoint(server.URL()))
+
+	errs := make(chan error, 16)
+	updates, err := cp.SubscribePool(ctx, pool, SubscriptionOpts{
+		MinReconnectDelay: time.Millisecond,
+		OnError:           func(err error) { errs <- err },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	next := func(wantSlot uint64) {
+		t.Helper()
+		select {
+		case update := <-updates:
+			if update.Pool != pool || update.Slot != wantSlot || update.State.SqrtPrice.Lo != wantSlot {
+				t.Fatalf("update = %+v, want slot %d", update, wantSlot)
+			}
+		case <-ctx.Done():
+			t.Fatalf("no update at slot %d", wantSlot)
+		}
+	}
+
+	node := server.Accept(t)
+	method, _, sub := node.Subscribe(t)
+	if method != "accountSubscribe" {
+		t.Fatalf("method = %s", method)
+	}
+	node.Notify(t, "accountNotification", sub, 10, rpctest.Account(CpAMMProgramId, poolAt(10)))
+	next(10)
+
+	// an undecodable notification is reported and skipped, the connection is kept.
+	node.Notify(t, "accountNotification", sub, 11, rpctest.Account(CpAMMProgramId, []byte{1}))
+	if err := <-errs; !isDecodeErr(err) {
+		t.Fatalf("error = %v, want a decode error", err)
+	}
+	node.Notify(t, "accountNotification", sub, 11, rpctest.Account(CpAMMProgramId, poolAt(11)))
+	next(11)
+
+	// the pool changed at slot 12 while disconnected, it is read again once resubscribed.
+	node.Close()
+	node = server.Accept(t)
+	_, _, sub = node.Subscribe(t)
+	next(12)
+	node.Notify(t, "accountNotification", sub, 13, rpctest.Account(CpAMMProgramId, poolAt(13)))
+	next(13)
+
+	cancel()
+	for range updates {
+	}
+}
@@ -0,0 +1,15 @@
+package dammv2gosdk
+
+import (
+	"dammv2GoSDK/txn"
+)
+
+// TransactionService returns a service sending the instructions built by CpAMM,
+// with the pool program events decoded out of the confirmed transactions, e.g:
+//
+//	ixns, _ := cp.Swap(ctx, params)
+//	res, err := cp.TransactionService().SendInstructions(ctx, ixns, txn.KeypairSigner(payer), nil, txn.SendOpts{})
+//	swap := res.Event("EvtSwap").(*cp_amm.EvtSwapEventData)
+func (cp *CpAMM) TransactionService() *txn.Service {
+	return txn.NewService(cp.conn, CpAMMProgramId)
+}
@@ -0,0 +1,134 @@
+package txn
+
+import (
+	"context"
+	"dammv2GoSDK/anchor"
+	"dammv2GoSDK/constants"
+	"fmt"
+	"slices"
+
+	"github.com/gagliardetto/solana-go"
+	computebudget "github.com/gagliardetto/solana-go/programs/compute-budget"
+)
+
+// cuBufferPercent is the share of the simulated units added on top of them,
+// before clamping between constants.MinCuBuffer and constants.MaxCuBuffer.
+const cuBufferPercent = 10
+
+// ComputeBudgetOpts configures the compute-budget instructions Build prepends.
+//
+// Compute-budget instructions already among the instructions are kept when nothing
+// is asked of the same kind: a SetComputeUnitLimit of the caller is not re-estimated
+// and a SetComputeUnitPrice of the caller is not dropped. UnitLimit, UnitPrice and
+// a PriorityFee estimate replace them.
+type ComputeBudgetOpts struct {
+	// Disabled leaves the instructions untouched.
+	Disabled bool
+	// UnitLimit overrides the estimated compute unit limit.
+	UnitLimit uint32
+	// UnitPrice in micro-lamports per compute unit, no SetComputeUnitPrice is added when zero.
+	UnitPrice uint64
+	// PriorityFee prices the compute unit when UnitPrice is zero, from the accounts
+	// the instructions write-lock.
+	PriorityFee PriorityFeeEstimator
+}
+
+// EstimateComputeUnits simulates ixns and returns the units they consumed plus a 10% buffer,
+// the buffer clamped between constants.MinCuBuffer and constants.MaxCuBuffer.
+// Compute-budget limits among ixns are lifted for the simulation.
+func (s *Service) EstimateComputeUnits(
+	ctx context.Context,
+	feePayer solana.PublicKey,
+	ixns []solana.Instruction,
+	tables map[solana.PublicKey]solana.PublicKeySlice,
+) (uint32, error) {
+	simIxns := slices.DeleteFunc(slices.Clone(ixns), func(ix solana.Instruction) bool {
+		return computeBudgetKind(ix) == computebudget.Instruction_SetComputeUnitLimit
+	})
+	simIxns = slices.Insert(simIxns, 0, solana.Instruction(
+		computebudget.NewSetComputeUnitLimitInstruction(computebudget.MAX_COMPUTE_UNIT_LIMIT).Build(),
+	))
+
+	res, err := anchor.NewViews(s.conn, s.programID).Simulate(ctx, feePayer, simIxns, anchor.ViewOpts{
+		AddressTables: tables,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("err simulating for compute units: %w", err)
+	}
+	if res.Err != nil {
+		return 0, fmt.Errorf("err simulating for compute units: %w", res.Err)
+	}
+
+	return computeUnitsWithBuffer(res.UnitsConsumed), nil
+}
+
+func computeUnitsWithBuffer(consumed uint64) uint32 {
+	buffer := min(max(consumed*cuBufferPercent/100, constants.MinCuBuffer), constants.MaxCuBuffer)
+	return uint32(min(consumed+buffer, computebudget.MAX_COMPUTE_UNIT_LIMIT))
+}
+
+// withComputeBudget returns ixns with the compute-budget instructions opts asks for
+// in front, see ComputeBudgetOpts.
+func (s *Service) withComputeBudget(
+	ctx context.Context,
+	feePayer solana.PublicKey,
+	ixns []solana.Instruction,
+	tables map[solana.PublicKey]solana.PublicKeySlice,
+	opts ComputeBudgetOpts,
+) ([]solana.Instruction, error) {
+	if opts.Disabled {
+		return ixns, nil
+	}
+
+	if opts.UnitPrice == 0 && opts.PriorityFee != nil {
+		var err error
+		if opts.UnitPrice, err = opts.PriorityFee.EstimatePriorityFee(ctx, WritableAccounts(ixns)); err != nil {
+			return nil, err
+		}
+	}
+
+	hasLimit := slices.ContainsFunc(ixns, func(ix solana.Instruction) bool {
+		return computeBudgetKind(ix) == computebudget.Instruction_SetComputeUnitLimit
+	})
+
+	var budget []solana.Instruction
+	unitLimit := opts.UnitLimit
+	if unitLimit == 0 && !hasLimit {
+		var err error
+		if unitLimit, err = s.EstimateComputeUnits(ctx, feePayer, ixns, tables); err != nil {
+			return nil, err
+		}
+	}
+	if unitLimit != 0 {
+		budget = append(budget, computebudget.NewSetComputeUnitLimitInstruction(unitLimit).Build())
+	}
+	if opts.UnitPrice != 0 {
+		budget = append(budget, computebudget.NewSetComputeUnitPriceInstruction(opts.UnitPrice).Build())
+	}
+
+	// a transaction holding two instructions of the same kind fails, drop the replaced ones.
+	rest := slices.DeleteFunc(slices.Clone(ixns), func(ix solana.Instruction) bool {
+		switch computeBudgetKind(ix) {
+		case computebudget.Instruction_SetComputeUnitLimit:
+			return unitLimit != 0
+		case computebudget.Instruction_SetComputeUnitPrice:
+			return opts.UnitPrice != 0
+		}
+		return false
+	})
+
+	return append(budget, rest...), nil
+}
+
+// computeBudgetKind returns the instruction id of a compute-budget instruction,
+// or 0xff for instructions of other programs.
+func computeBudgetKind(ix solana.Instruction) uint8 {
+	if !ix.ProgramID().Equals(solana.ComputeBudget) {
+		return 0xff
+	}
+	data, err := ix.Data()
+	if err != nil || len(data) == 0 {
+		return 0xff
+	}
+	return data[0]
+}
@@ -0,0 +1,96 @@
+package txn
+
+import (
+	"context"
+	cp_amm "dammv2GoSDK/generated/cpAmm"
+	"encoding/binary"
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+	computebudget "github.com/gagliardetto/solana-go/programs/compute-budget"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+func TestComputeUnitsWithBuffer(t *testing.T) {
+	for _, tc := range []struct {
+		consumed uint64
+		want     uint32
+	}{
+		{consumed: 100_000, want: 150_000},     // 10% is under MinCuBuffer
+		{consumed: 1_000_000, want: 1_100_000}, // 10% as is
+		{consumed: 1_350_000, want: 1_400_000}, // capped at the max limit
+	} {
+		if got := computeUnitsWithBuffer(tc.consumed); got != tc.want {
+			t.Errorf("computeUnitsWithBuffer(%d) = %d, want %d", tc.consumed, got, tc.want)
+		}
+	}
+}
+
+func TestBuildComputeBudget(t *testing.T) {
+	var (
+		payer = solana.NewWallet().PublicKey()
+		ix    = solana.NewInstruction(cp_amm.ProgramID, solana.AccountMetaSlice{}, []byte{0})
+		price = computebudget.NewSetComputeUnitPriceInstruction(7).Build()
+		limit = computebudget.NewSetComputeUnitLimitInstruction(300_000).Build()
+	)
+
+	for _, tc := range []struct {
+		name      string
+		ixns      []solana.Instruction
+		opts      ComputeBudgetOpts
+		wantLimit uint32
+		wantPrice uint64
+		simulates bool
+	}{
+		{name: "estimated", ixns: []solana.Instruction{ix}, wantLimit: 150_000, simulates: true},
+		{name: "caller price kept", ixns: []solana.Instruction{price, ix}, wantLimit: 150_000, wantPrice: 7, simulates: true},
+		{name: "caller limit kept", ixns: []solana.Instruction{limit, ix}, wantLimit: 300_000},
+		{
+			name:      "override",
+			ixns:      []solana.Instruction{limit, price, ix},
+			opts:      ComputeBudgetOpts{UnitLimit: 500_000, UnitPrice: 9},
+			wantLimit: 500_000,
+			wantPrice: 9,
+		},
+		{name: "disabled", ixns: []solana.Instruction{ix}, opts: ComputeBudgetOpts{Disabled: true}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			fake := &fakeCluster{}
+			service := NewService(rpc.NewWithCustomRPCClient(fake), cp_amm.ProgramID)
+
+			built, err := service.Build(context.Background(), payer, tc.ixns, SendOpts{ComputeBudget: tc.opts})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if simulated := fake.simulated != ""; simulated != tc.simulates {
+				t.Fatalf("simulated = %v, want %v", simulated, tc.simulates)
+			}
+
+			var (
+				limits, prices int
+				gotLimit       uint32
+				gotPrice       uint64
+			)
+			msg := built.Transaction.Message
+			for _, compiled := range msg.Instructions {
+				if !msg.AccountKeys[compiled.ProgramIDIndex].Equals(solana.ComputeBudget) {
+					continue
+				}
+				switch compiled.Data[0] {
+				case computebudget.Instruction_SetComputeUnitLimit:
+					limits++
+					gotLimit = binary.LittleEndian.Uint32(compiled.Data[1:])
+				case computebudget.Instruction_SetComputeUnitPrice:
+					prices++
+					gotPrice = binary.LittleEndian.Uint64(compiled.Data[1:])
+				}
+			}
+			if limits > 1 || prices > 1 {
+				t.Fatalf("%d limit and %d price instructions", limits, prices)
+			}
+			if gotLimit != tc.wantLimit || gotPrice != tc.wantPrice {
+				t.Fatalf("limit = %d price = %d, want %d and %d", gotLimit, gotPrice, tc.wantLimit, tc.wantPrice)
+			}
+		})
+	}
+}
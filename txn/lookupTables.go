@@ -0,0 +1,363 @@
+package txn
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"sync"
+
+	"github.com/gagliardetto/solana-go"
+	addresslookuptable "github.com/gagliardetto/solana-go/programs/address-lookup-table"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+var AddressLookupTableProgramID = solana.MustPublicKeyFromBase58("AddressLookupTab1e1111111111111111111111111")
+
+const (
+	lookupTableCreate uint32 = 0
+	lookupTableExtend uint32 = 2
+
+	// extendChunk addresses fit a single transaction along with a create instruction.
+	extendChunk = 20
+)
+
+// LookupTable is an entry of the LookupTableManager index.
+type LookupTable struct {
+	Authority solana.PublicKey      `json:"authority"`
+	Addresses solana.PublicKeySlice `json:"addresses"`
+}
+
+// LookupTableManager creates and extends address lookup tables, keeps an index of the
+// tables it knows about and picks the ones to compile v0 transactions against.
+// The index is persisted as JSON at the path it was created with, when there is one.
+//
+// Addresses added to a table can only be looked up from the slot after, a table fresh
+// out of Create or Extend is best left unused until then.
+//
+// It is safe for concurrent use by multiple goroutines.
+type LookupTableManager struct {
+	// SendOpts the create and extend transactions are sent with.
+	SendOpts SendOpts
+
+	service *Service
+	path    string
+
+	mu     sync.Mutex
+	tables map[solana.PublicKey]*LookupTable
+}
+
+// NewLookupTableManager returns a manager sending through service, loading the index at
+// indexPath if it exists. An empty indexPath keeps the index in memory only.
+func NewLookupTableManager(service *Service, indexPath string) (*LookupTableManager, error) {
+	m := &LookupTableManager{
+		service: service,
+		path:    indexPath,
+		tables:  make(map[solana.PublicKey]*LookupTable),
+	}
+	if indexPath == "" {
+		return m, nil
+	}
+
+	raw, err := os.ReadFile(indexPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return m, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("err reading lookup table index: %w", err)
+	}
+	if err := json.Unmarshal(raw, &m.tables); err != nil {
+		return nil, fmt.Errorf("err decoding lookup table index %s: %w", indexPath, err)
+	}
+	return m, nil
+}
+
+// Tables returns the address lists of every table of the index, as taken by SendOpts.AddressTables.
+func (m *LookupTableManager) Tables() map[solana.PublicKey]solana.PublicKeySlice {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	res := make(map[solana.PublicKey]solana.PublicKeySlice, len(m.tables))
+	for key, table := range m.tables {
+		res[key] = slices.Clone(table.Addresses)
+	}
+	return res
+}
+
+// Refresh reads tables from chain into the index, e.g tables created elsewhere.
+func (m *LookupTableManager) Refresh(ctx context.Context, tables ...solana.PublicKey) error {
+	for _, key := range tables {
+		state, err := addresslookuptable.GetAddressLookupTable(ctx, m.service.conn, key)
+		if err != nil {
+			return fmt.Errorf("err fetching address lookup table %s: %w", key, err)
+		}
+
+		table := &LookupTable{Addresses: state.Addresses}
+		if state.Authority != nil {
+			table.Authority = *state.Authority
+		}
+		m.mu.Lock()
+		m.tables[key] = table
+		m.mu.Unlock()
+	}
+	return m.save()
+}
+
+// Create creates a table owned by authority holding addresses and adds it to the index.
+func (m *LookupTableManager) Create(
+	ctx context.Context,
+	authority, payer Signer,
+	addresses []solana.PublicKey,
+) (solana.PublicKey, error) {
+	recentSlot, err := m.service.conn.GetSlot(ctx, rpc.CommitmentFinalized)
+	if err != nil {
+		return solana.PublicKey{}, fmt.Errorf("err fetching recent slot: %w", err)
+	}
+
+	createIx, table, err := NewCreateLookupTableInstruction(authority.PublicKey(), payer.PublicKey(), recentSlot)
+	if err != nil {
+		return solana.PublicKey{}, err
+	}
+
+	addresses = slices.Compact(slices.SortedFunc(slices.Values(addresses), comparePublicKeys))
+	first := addresses[:min(len(addresses), extendChunk)]
+	ixns := []solana.Instruction{createIx}
+	if len(first) > 0 {
+		ixns = append(ixns, NewExtendLookupTableInstruction(table, authority.PublicKey(), payer.PublicKey(), first))
+	}
+
+	if _, err := m.service.SendInstructions(ctx, ixns, payer, []Signer{authority}, m.SendOpts); err != nil {
+		return solana.PublicKey{}, fmt.Errorf("err creating address lookup table: %w", err)
+	}
+
+	m.mu.Lock()
+	m.tables[table] = &LookupTable{
+		Authority: authority.PublicKey(),
+		Addresses: slices.Clone(first),
+	}
+	m.mu.Unlock()
+
+	if err := m.Extend(ctx, table, authority, payer, addresses[len(first):]); err != nil {
+		return table, err
+	}
+	return table, m.save()
+}
+
+// Extend adds to table the addresses it does not hold yet.
+func (m *LookupTableManager) Extend(
+	ctx context.Context,
+	table solana.PublicKey,
+	authority, payer Signer,
+	addresses []solana.PublicKey,
+) error {
+	m.mu.Lock()
+	entry, ok := m.tables[table]
+	var missing []solana.PublicKey
+	if ok {
+		for _, address := range addresses {
+			if !entry.Addresses.Contains(address) && !slices.Contains(missing, address) {
+				missing = append(missing, address)
+			}
+		}
+	}
+	m.mu.Unlock()
+
+	if !ok {
+		return fmt.Errorf("address lookup table %s is not in the index", table)
+	}
+	if len(entry.Addresses)+len(missing) > addresslookuptable.LOOKUP_TABLE_MAX_ADDRESSES {
+		return fmt.Errorf("address lookup table %s cannot hold %d more addresses", table, len(missing))
+	}
+
+	for chunk := range slices.Chunk(missing, extendChunk) {
+		ix := NewExtendLookupTableInstruction(table, authority.PublicKey(), payer.PublicKey(), chunk)
+		if _, err := m.service.SendInstructions(ctx, []solana.Instruction{ix}, payer, []Signer{authority}, m.SendOpts); err != nil {
+			return fmt.Errorf("err extending address lookup table %s: %w", table, err)
+		}
+
+		m.mu.Lock()
+		entry.Addresses = append(entry.Addresses, chunk...)
+		m.mu.Unlock()
+	}
+
+	return m.save()
+}
+
+// Ensure returns a table of the index holding every address, extending one owned by
+// authority with room left, or creating one, when none does.
+func (m *LookupTableManager) Ensure(
+	ctx context.Context,
+	authority, payer Signer,
+	addresses []solana.PublicKey,
+) (solana.PublicKey, error) {
+	var (
+		best        solana.PublicKey
+		bestMissing = -1
+	)
+	m.mu.Lock()
+	for key, table := range m.tables {
+		missing := 0
+		for _, address := range addresses {
+			if !table.Addresses.Contains(address) {
+				missing++
+			}
+		}
+		if missing == 0 {
+			m.mu.Unlock()
+			return key, nil
+		}
+		if table.Authority.Equals(authority.PublicKey()) &&
+			len(table.Addresses)+missing <= addresslookuptable.LOOKUP_TABLE_MAX_ADDRESSES &&
+			(bestMissing < 0 || missing < bestMissing) {
+			best, bestMissing = key, missing
+		}
+	}
+	m.mu.Unlock()
+
+	if bestMissing < 0 {
+		return m.Create(ctx, authority, payer, addresses)
+	}
+	return best, m.Extend(ctx, best, authority, payer, addresses)
+}
+
+// Select picks the tables of the index to compile ixns against, see SelectTables.
+func (m *LookupTableManager) Select(ixns []solana.Instruction) map[solana.PublicKey]solana.PublicKeySlice {
+	return SelectTables(ixns, m.Tables())
+}
+
+// SelectTables picks the tables to compile ixns against, greedily taking the table
+// looking up the most remaining accounts for as long as one saves space.
+// Signers and invoked programs cannot be looked up and are left out.
+func SelectTables(
+	ixns []solana.Instruction,
+	tables map[solana.PublicKey]solana.PublicKeySlice,
+) map[solana.PublicKey]solana.PublicKeySlice {
+	var (
+		programs  = make(map[solana.PublicKey]bool)
+		remaining = make(map[solana.PublicKey]bool)
+	)
+	for _, ix := range ixns {
+		programs[ix.ProgramID()] = true
+	}
+	for _, ix := range ixns {
+		for _, meta := range ix.Accounts() {
+			if meta != nil && !meta.IsSigner && !programs[meta.PublicKey] {
+				remaining[meta.PublicKey] = true
+			}
+		}
+	}
+
+	selected := make(map[solana.PublicKey]solana.PublicKeySlice)
+	for len(remaining) > 0 {
+		var (
+			best    solana.PublicKey
+			covered int
+		)
+		for key, addresses := range tables {
+			if _, ok := selected[key]; ok {
+				continue
+			}
+			n := 0
+			for _, address := range addresses {
+				if remaining[address] {
+					n++
+				}
+			}
+			// ties go to the smallest key, so the same input always gives the same tables.
+			if n > covered || (n == covered && n > 0 && comparePublicKeys(key, best) < 0) {
+				best, covered = key, n
+			}
+		}
+		// a table costs about as much as the one account it would look up.
+		if covered < 2 {
+			break
+		}
+
+		selected[best] = tables[best]
+		for _, address := range tables[best] {
+			delete(remaining, address)
+		}
+	}
+	return selected
+}
+
+func (m *LookupTableManager) save() error {
+	if m.path == "" {
+		return nil
+	}
+
+	m.mu.Lock()
+	raw, err := json.MarshalIndent(m.tables, "", "  ")
+	m.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	// write then rename, so a crash never leaves a truncated index behind.
+	tmp := m.path + ".tmp"
+	if err := os.WriteFile(tmp, raw, 0o644); err != nil {
+		return fmt.Errorf("err writing lookup table index: %w", err)
+	}
+	return os.Rename(tmp, m.path)
+}
+
+// NewCreateLookupTableInstruction returns the instruction creating the lookup table of
+// authority derived from recentSlot, along with the table address.
+func NewCreateLookupTableInstruction(
+	authority, payer solana.PublicKey,
+	recentSlot uint64,
+) (solana.Instruction, solana.PublicKey, error) {
+	slotBytes := binary.LittleEndian.AppendUint64(nil, recentSlot)
+	table, bump, err := solana.FindProgramAddress(
+		[][]byte{authority[:], slotBytes},
+		AddressLookupTableProgramID,
+	)
+	if err != nil {
+		return nil, solana.PublicKey{}, err
+	}
+
+	data := binary.LittleEndian.AppendUint32(nil, lookupTableCreate)
+	data = append(data, slotBytes...)
+	data = append(data, bump)
+
+	return solana.NewInstruction(
+		AddressLookupTableProgramID,
+		solana.AccountMetaSlice{
+			solana.Meta(table).WRITE(),
+			solana.Meta(authority).SIGNER(),
+			solana.Meta(payer).WRITE().SIGNER(),
+			solana.Meta(solana.SystemProgramID),
+		},
+		data,
+	), table, nil
+}
+
+// NewExtendLookupTableInstruction returns the instruction appending addresses to table.
+func NewExtendLookupTableInstruction(
+	table, authority, payer solana.PublicKey,
+	addresses []solana.PublicKey,
+) solana.Instruction {
+	data := binary.LittleEndian.AppendUint32(nil, lookupTableExtend)
+	data = binary.LittleEndian.AppendUint64(data, uint64(len(addresses)))
+	for _, address := range addresses {
+		data = append(data, address[:]...)
+	}
+
+	return solana.NewInstruction(
+		AddressLookupTableProgramID,
+		solana.AccountMetaSlice{
+			solana.Meta(table).WRITE(),
+			solana.Meta(authority).SIGNER(),
+			solana.Meta(payer).WRITE().SIGNER(),
+			solana.Meta(solana.SystemProgramID),
+		},
+		data,
+	)
+}
+
+func comparePublicKeys(a, b solana.PublicKey) int {
+	return slices.Compare(a[:], b[:])
+}
@@ -0,0 +1,73 @@
+package txn
+
+import (
+	"context"
+	cp_amm "dammv2GoSDK/generated/cpAmm"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+func TestLookupTableManager(t *testing.T) {
+	var (
+		ctx       = context.Background()
+		indexPath = filepath.Join(t.TempDir(), "tables.json")
+		payer     = KeypairSigner(solana.NewWallet().PrivateKey)
+		addresses = make([]solana.PublicKey, 25)
+	)
+	for i := range addresses {
+		addresses[i] = solana.NewWallet().PublicKey()
+	}
+
+	fake := &fakeCluster{}
+	service := NewService(rpc.NewWithCustomRPCClient(fake), cp_amm.ProgramID)
+	manager, err := NewLookupTableManager(service, indexPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// shorten the resend loop of the service.
+	manager.SendOpts = SendOpts{RetryInterval: time.Millisecond}
+
+	table, err := manager.Create(ctx, payer, payer, addresses)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// a create with the first chunk of addresses, then an extend with the rest.
+	if sends := fake.sends.Load(); sends != 2 {
+		t.Fatalf("sent %d transactions, want 2", sends)
+	}
+
+	reloaded, err := NewLookupTableManager(service, indexPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	reloaded.SendOpts = manager.SendOpts
+	if got := reloaded.Tables()[table]; len(got) != len(addresses) || !got.ContainsAll(addresses) {
+		t.Fatalf("reloaded table holds %v, want the %d addresses", got, len(addresses))
+	}
+
+	ensured, err := reloaded.Ensure(ctx, payer, payer, addresses[:10])
+	if err != nil || !ensured.Equals(table) {
+		t.Fatalf("Ensure = %s, %v, want the existing table %s", ensured, err, table)
+	}
+
+	signer := solana.NewWallet().PublicKey()
+	ix := solana.NewInstruction(cp_amm.ProgramID, solana.AccountMetaSlice{
+		solana.Meta(signer).WRITE().SIGNER(),
+		solana.Meta(addresses[0]).WRITE(),
+		solana.Meta(addresses[1]),
+	}, []byte{0})
+	selected := reloaded.Select([]solana.Instruction{ix})
+	if _, ok := selected[table]; len(selected) != 1 || !ok {
+		t.Fatalf("selected %v, want %s", selected, table)
+	}
+
+	lone := solana.NewInstruction(cp_amm.ProgramID, solana.AccountMetaSlice{solana.Meta(addresses[0])}, []byte{0})
+	if selected := reloaded.Select([]solana.Instruction{lone}); len(selected) != 0 {
+		t.Fatalf("selected %v for a single lookup, want none", selected)
+	}
+}
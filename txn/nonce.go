@@ -0,0 +1,92 @@
+package txn
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	ag_binary "github.com/gagliardetto/binary"
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/programs/system"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+// ErrNonceAdvanced is returned by Send when the durable nonce of the transaction was
+// advanced by another transaction, the transaction can then never land.
+var ErrNonceAdvanced = errors.New("durable nonce advanced before the transaction was confirmed")
+
+// Nonce is the state of a durable nonce account, what BuildDurable needs to build a
+// transaction that does not expire.
+type Nonce struct {
+	Account   solana.PublicKey
+	Authority solana.PublicKey
+	// Value is the current nonce, used as the blockhash of the transaction.
+	Value solana.Hash
+}
+
+// FetchNonce reads the nonce account, typically on an online machine before the
+// transaction is built and signed offline.
+func FetchNonce(ctx context.Context, conn *rpc.Client, nonceAccount solana.PublicKey) (Nonce, error) {
+	info, err := conn.GetAccountInfoWithOpts(ctx, nonceAccount, &rpc.GetAccountInfoOpts{
+		Commitment: rpc.CommitmentConfirmed,
+	})
+	if err != nil {
+		return Nonce{}, fmt.Errorf("err fetching nonce account %s: %w", nonceAccount, err)
+	}
+
+	var state system.NonceAccount
+	if err := state.UnmarshalWithDecoder(ag_binary.NewBinDecoder(info.GetBinary())); err != nil {
+		return Nonce{}, fmt.Errorf("err decoding nonce account %s: %w", nonceAccount, err)
+	}
+	if state.State == 0 {
+		return Nonce{}, fmt.Errorf("nonce account %s is not initialized", nonceAccount)
+	}
+
+	return Nonce{
+		Account:   nonceAccount,
+		Authority: state.AuthorizedPubkey,
+		Value:     solana.Hash(state.Nonce),
+	}, nil
+}
+
+// BuildDurable compiles ixns into a transaction paid by feePayer whose blockhash is the
+// durable nonce, with the AdvanceNonceAccount instruction the runtime requires first.
+// The nonce authority has to sign it on top of the signers of ixns.
+//
+// No rpc call is made and no compute-budget instruction is added, the transaction can
+// be built on an offline machine and signed much later, see EncodeMessage.
+func BuildDurable(
+	feePayer solana.PublicKey,
+	nonce Nonce,
+	ixns []solana.Instruction,
+	tables map[solana.PublicKey]solana.PublicKeySlice,
+) (*Built, error) {
+	advance := system.NewAdvanceNonceAccountInstruction(
+		nonce.Account,
+		solana.SysVarRecentBlockHashesPubkey,
+		nonce.Authority,
+	).Build()
+
+	txOpts := []solana.TransactionOption{solana.TransactionPayer(feePayer)}
+	if len(tables) > 0 {
+		txOpts = append(txOpts, solana.TransactionAddressTables(tables))
+	}
+	tx, err := solana.NewTransaction(append([]solana.Instruction{advance}, ixns...), nonce.Value, txOpts...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Built{
+		Transaction: tx,
+		Nonce:       &nonce,
+	}, nil
+}
+
+// nonceAdvanced reports whether the nonce of built no longer matches its transaction.
+func (s *Service) nonceAdvanced(ctx context.Context, built *Built) (bool, error) {
+	current, err := FetchNonce(ctx, s.conn, built.Nonce.Account)
+	if err != nil {
+		return false, err
+	}
+	return !current.Value.Equals(built.Transaction.Message.RecentBlockhash), nil
+}
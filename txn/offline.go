@@ -0,0 +1,218 @@
+package txn
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	ag_binary "github.com/gagliardetto/binary"
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/programs/system"
+	"github.com/mr-tron/base58"
+)
+
+// OfflineSignature is a signature made away from the transaction, e.g on a cold wallet.
+type OfflineSignature struct {
+	Signer    solana.PublicKey
+	Signature solana.Signature
+}
+
+// String encodes the signature as "<signer>=<signature>", both base58, one per line in signature files.
+func (s OfflineSignature) String() string {
+	return s.Signer.String() + "=" + s.Signature.String()
+}
+
+// ParseOfflineSignature decodes the output of OfflineSignature.String.
+func ParseOfflineSignature(encoded string) (OfflineSignature, error) {
+	signer, signature, ok := strings.Cut(strings.TrimSpace(encoded), "=")
+	if !ok {
+		return OfflineSignature{}, fmt.Errorf("malformed offline signature %q", encoded)
+	}
+
+	var (
+		res OfflineSignature
+		err error
+	)
+	if res.Signer, err = solana.PublicKeyFromBase58(signer); err != nil {
+		return OfflineSignature{}, fmt.Errorf("malformed offline signature signer: %w", err)
+	}
+	if res.Signature, err = solana.SignatureFromBase58(signature); err != nil {
+		return OfflineSignature{}, fmt.Errorf("malformed offline signature: %w", err)
+	}
+	return res, nil
+}
+
+// EncodeMessage encodes the message of tx, what its signers sign, as base64 or base58.
+func EncodeMessage(tx *solana.Transaction, encoding solana.EncodingType) (string, error) {
+	message, err := tx.Message.MarshalBinary()
+	if err != nil {
+		return "", fmt.Errorf("err encoding message: %w", err)
+	}
+	return encode(message, encoding)
+}
+
+// DecodeMessage decodes the output of EncodeMessage into an unsigned transaction.
+func DecodeMessage(encoded string, encoding solana.EncodingType) (*solana.Transaction, error) {
+	raw, err := decode(strings.TrimSpace(encoded), encoding)
+	if err != nil {
+		return nil, err
+	}
+
+	var message solana.Message
+	if err := message.UnmarshalWithDecoder(ag_binary.NewBinDecoder(raw)); err != nil {
+		return nil, fmt.Errorf("err decoding message: %w", err)
+	}
+
+	return &solana.Transaction{
+		Signatures: make([]solana.Signature, message.Header.NumRequiredSignatures),
+		Message:    message,
+	}, nil
+}
+
+// WriteMessageFile writes the encoded message of tx to path, for offline signers to pick up.
+func WriteMessageFile(path string, tx *solana.Transaction, encoding solana.EncodingType) error {
+	encoded, err := EncodeMessage(tx, encoding)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, []byte(encoded+"\n"), 0o644)
+}
+
+// ReadMessageFile reads the unsigned transaction written by WriteMessageFile.
+func ReadMessageFile(path string, encoding solana.EncodingType) (*solana.Transaction, error) {
+	encoded, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return DecodeMessage(string(encoded), encoding)
+}
+
+// SignOffline signs the message of tx with signer, without touching tx. The result is
+// meant to be carried back to the machine assembling the transaction, see AddSignatures.
+func SignOffline(ctx context.Context, tx *solana.Transaction, signer Signer) (OfflineSignature, error) {
+	if !tx.Message.Signers().Contains(signer.PublicKey()) {
+		return OfflineSignature{}, fmt.Errorf("%s is not a signer of the transaction", signer.PublicKey())
+	}
+
+	message, err := tx.Message.MarshalBinary()
+	if err != nil {
+		return OfflineSignature{}, fmt.Errorf("err encoding message: %w", err)
+	}
+	signature, err := signer.SignMessage(ctx, message)
+	if err != nil {
+		return OfflineSignature{}, err
+	}
+
+	return OfflineSignature{
+		Signer:    signer.PublicKey(),
+		Signature: signature,
+	}, nil
+}
+
+// AddSignatures places signatures on tx, each verified against its message first.
+func AddSignatures(tx *solana.Transaction, signatures ...OfflineSignature) error {
+	message, err := tx.Message.MarshalBinary()
+	if err != nil {
+		return fmt.Errorf("err encoding message: %w", err)
+	}
+
+	signers := tx.Message.Signers()
+	if len(tx.Signatures) != len(signers) {
+		resized := make([]solana.Signature, len(signers))
+		copy(resized, tx.Signatures)
+		tx.Signatures = resized
+	}
+
+	for _, sig := range signatures {
+		idx := -1
+		for i, signer := range signers {
+			if signer.Equals(sig.Signer) {
+				idx = i
+				break
+			}
+		}
+		if idx < 0 {
+			return fmt.Errorf("%s is not a signer of the transaction", sig.Signer)
+		}
+		if !sig.Signature.Verify(sig.Signer, message) {
+			return fmt.Errorf("signature of %s does not match the transaction", sig.Signer)
+		}
+		tx.Signatures[idx] = sig.Signature
+	}
+
+	return nil
+}
+
+// Assemble returns the fully signed tx encoded for broadcast, e.g with sendTransaction.
+func Assemble(tx *solana.Transaction, encoding solana.EncodingType) (string, error) {
+	if missing := MissingSigners(tx); len(missing) > 0 {
+		return "", fmt.Errorf("transaction is missing signatures of %v", missing)
+	}
+
+	raw, err := tx.MarshalBinary()
+	if err != nil {
+		return "", fmt.Errorf("err encoding transaction: %w", err)
+	}
+	return encode(raw, encoding)
+}
+
+// SendDurable sends an assembled durable nonce transaction, see BuildDurable and Send.
+func (s *Service) SendDurable(ctx context.Context, tx *solana.Transaction, opts SendOpts) (*Result, error) {
+	nonce, err := DurableNonce(tx)
+	if err != nil {
+		return nil, err
+	}
+	return s.Send(ctx, &Built{Transaction: tx, Nonce: nonce}, opts)
+}
+
+// DurableNonce returns the nonce tx was built against, from its AdvanceNonceAccount instruction.
+func DurableNonce(tx *solana.Transaction) (*Nonce, error) {
+	if len(tx.Message.Instructions) == 0 {
+		return nil, errors.New("transaction has no instructions")
+	}
+
+	first := tx.Message.Instructions[0]
+	program, err := tx.Message.Program(first.ProgramIDIndex)
+	if err != nil {
+		return nil, err
+	}
+	if !program.Equals(solana.SystemProgramID) || len(first.Data) < 4 || len(first.Accounts) < 3 ||
+		ag_binary.LE.Uint32(first.Data) != system.Instruction_AdvanceNonceAccount {
+		return nil, errors.New("transaction does not start with an AdvanceNonceAccount instruction")
+	}
+
+	accounts, err := first.ResolveInstructionAccounts(&tx.Message)
+	if err != nil {
+		return nil, err
+	}
+	return &Nonce{
+		Account:   accounts[0].PublicKey,
+		Authority: accounts[2].PublicKey,
+		Value:     tx.Message.RecentBlockhash,
+	}, nil
+}
+
+func encode(raw []byte, encoding solana.EncodingType) (string, error) {
+	switch encoding {
+	case solana.EncodingBase64:
+		return base64.StdEncoding.EncodeToString(raw), nil
+	case solana.EncodingBase58:
+		return base58.Encode(raw), nil
+	default:
+		return "", fmt.Errorf("unsupported encoding %s", encoding)
+	}
+}
+
+func decode(encoded string, encoding solana.EncodingType) ([]byte, error) {
+	switch encoding {
+	case solana.EncodingBase64:
+		return base64.StdEncoding.DecodeString(encoded)
+	case solana.EncodingBase58:
+		return base58.Decode(encoded)
+	default:
+		return nil, fmt.Errorf("unsupported encoding %s", encoding)
+	}
+}
@@ -0,0 +1,104 @@
+package txn
+
+import (
+	"context"
+	cp_amm "dammv2GoSDK/generated/cpAmm"
+	"path/filepath"
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+)
+
+func TestOfflineSigningWorkflow(t *testing.T) {
+	var (
+		ctx       = context.Background()
+		feePayer  = solana.NewWallet().PrivateKey
+		owner     = solana.NewWallet().PrivateKey
+		authority = solana.NewWallet().PrivateKey
+		nonce     = Nonce{
+			Account:   solana.NewWallet().PublicKey(),
+			Authority: authority.PublicKey(),
+			Value:     solana.Hash{7},
+		}
+	)
+
+	ix := solana.NewInstruction(
+		cp_amm.ProgramID,
+		solana.AccountMetaSlice{solana.Meta(owner.PublicKey()).SIGNER()},
+		[]byte{0},
+	)
+	built, err := BuildDurable(feePayer.PublicKey(), nonce, []solana.Instruction{ix}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, encoding := range []solana.EncodingType{solana.EncodingBase64, solana.EncodingBase58} {
+		path := filepath.Join(t.TempDir(), "message")
+		if err := WriteMessageFile(path, built.Transaction, encoding); err != nil {
+			t.Fatal(err)
+		}
+
+		// every signer signs its own copy of the message, as it would on its own machine.
+		var signatures []OfflineSignature
+		for _, key := range []solana.PrivateKey{feePayer, owner, authority} {
+			unsigned, err := ReadMessageFile(path, encoding)
+			if err != nil {
+				t.Fatal(err)
+			}
+			sig, err := SignOffline(ctx, unsigned, KeypairSigner(key))
+			if err != nil {
+				t.Fatal(err)
+			}
+			parsed, err := ParseOfflineSignature(sig.String())
+			if err != nil || parsed != sig {
+				t.Fatalf("ParseOfflineSignature(%s) = %v, %v", sig, parsed, err)
+			}
+			signatures = append(signatures, parsed)
+		}
+
+		tx, err := ReadMessageFile(path, encoding)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := Assemble(tx, encoding); err == nil {
+			t.Fatal("assembled a transaction without signatures")
+		}
+		if err := AddSignatures(tx, signatures...); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := Assemble(tx, encoding); err != nil {
+			t.Fatal(err)
+		}
+		if err := tx.VerifySignatures(); err != nil {
+			t.Fatal(err)
+		}
+
+		got, err := DurableNonce(tx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if *got != nonce {
+			t.Fatalf("DurableNonce = %+v, want %+v", got, nonce)
+		}
+	}
+}
+
+func TestAddSignaturesRejectsForeignSignature(t *testing.T) {
+	var (
+		feePayer = solana.NewWallet().PrivateKey
+		nonce    = Nonce{Account: solana.NewWallet().PublicKey(), Authority: feePayer.PublicKey()}
+	)
+	built, err := BuildDurable(feePayer.PublicKey(), nonce, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	signature, err := feePayer.Sign([]byte("another message"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = AddSignatures(built.Transaction, OfflineSignature{Signer: feePayer.PublicKey(), Signature: signature})
+	if err == nil {
+		t.Fatal("accepted a signature of another message")
+	}
+}
@@ -0,0 +1,222 @@
+package txn
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/gagliardetto/solana-go"
+	computebudget "github.com/gagliardetto/solana-go/programs/compute-budget"
+)
+
+const (
+	// MaxTransactionSize is the size limit of a serialized transaction, signatures included.
+	MaxTransactionSize = 1232
+	// MaxTransactionAccounts is the number of accounts a transaction may lock.
+	MaxTransactionAccounts = 64
+)
+
+// ErrGroupTooLarge is returned by Plan for a group that does not fit a transaction on its own.
+var ErrGroupTooLarge = errors.New("instruction group does not fit a single transaction")
+
+// InstructionGroup is a set of instructions that must land atomically, in order and in the
+// same transaction, e.g a refresh vesting, remove all liquidity and close position.
+type InstructionGroup struct {
+	Instructions []solana.Instruction
+	// ComputeUnits the group consumes. Zero leaves the group out of the compute limit
+	// checks, see Service.EstimateGroups.
+	ComputeUnits uint32
+}
+
+// PlanOpts configures Plan.
+type PlanOpts struct {
+	// AddressTables the transactions may be compiled against, each transaction is
+	// compiled against the ones that shrink it.
+	AddressTables map[solana.PublicKey]solana.PublicKeySlice
+	// MaxComputeUnits a transaction may use, defaults to the 1.4M limit.
+	MaxComputeUnits uint32
+	// NoComputeBudget skips reserving room for the compute-budget instructions
+	// Service.Build prepends.
+	NoComputeBudget bool
+}
+
+// PlannedTransaction is a transaction of an execution plan.
+type PlannedTransaction struct {
+	// Groups are the indices of the groups the transaction holds, in order.
+	Groups       []int
+	Instructions []solana.Instruction
+	// AddressTables to compile the transaction against, pass them on as SendOpts.AddressTables.
+	AddressTables map[solana.PublicKey]solana.PublicKeySlice
+	// Size of the signed transaction, compute-budget instructions included.
+	Size         int
+	ComputeUnits uint32
+}
+
+// Plan packs groups into as few transactions paid by feePayer as the packet size, account
+// and compute limits allow. Groups are never split nor reordered: executing the returned
+// transactions in order executes the groups in order.
+//
+// Plan makes no rpc call, the returned plan is meant for Service.SendPlan or for sending
+// the transactions one by one.
+func Plan(feePayer solana.PublicKey, groups []InstructionGroup, opts PlanOpts) ([]PlannedTransaction, error) {
+	if opts.MaxComputeUnits == 0 {
+		opts.MaxComputeUnits = computebudget.MAX_COMPUTE_UNIT_LIMIT
+	}
+
+	var (
+		plan    []PlannedTransaction
+		current *PlannedTransaction
+	)
+	for i, group := range groups {
+		if current != nil {
+			candidate, fits, err := extendPlanned(feePayer, current, i, group, opts)
+			if err != nil {
+				return nil, err
+			}
+			if fits {
+				*current = candidate
+				continue
+			}
+			plan = append(plan, *current)
+		}
+
+		candidate, fits, err := extendPlanned(feePayer, &PlannedTransaction{}, i, group, opts)
+		if err != nil {
+			return nil, err
+		}
+		if !fits {
+			return nil, fmt.Errorf("%w: group %d, %d bytes and %d compute units",
+				ErrGroupTooLarge, i, candidate.Size, candidate.ComputeUnits)
+		}
+		current = &candidate
+	}
+	if current != nil {
+		plan = append(plan, *current)
+	}
+
+	return plan, nil
+}
+
+// extendPlanned returns tx with group appended and whether the result fits the limits.
+func extendPlanned(
+	feePayer solana.PublicKey,
+	tx *PlannedTransaction,
+	index int,
+	group InstructionGroup,
+	opts PlanOpts,
+) (PlannedTransaction, bool, error) {
+	candidate := PlannedTransaction{
+		Groups:       append(append([]int(nil), tx.Groups...), index),
+		Instructions: append(append([]solana.Instruction(nil), tx.Instructions...), group.Instructions...),
+		ComputeUnits: tx.ComputeUnits + group.ComputeUnits,
+	}
+	if candidate.ComputeUnits > opts.MaxComputeUnits {
+		return candidate, false, nil
+	}
+
+	ixns := candidate.Instructions
+	if !opts.NoComputeBudget {
+		// the largest values, so the reserved room holds whatever Build ends up prepending.
+		ixns = append([]solana.Instruction{
+			computebudget.NewSetComputeUnitLimitInstruction(computebudget.MAX_COMPUTE_UNIT_LIMIT).Build(),
+			computebudget.NewSetComputeUnitPriceInstruction(^uint64(0)).Build(),
+		}, ixns...)
+	}
+
+	candidate.AddressTables = SelectTables(ixns, opts.AddressTables)
+	size, accounts, err := transactionSize(feePayer, ixns, candidate.AddressTables)
+	if err != nil {
+		return candidate, false, err
+	}
+	candidate.Size = size
+
+	return candidate, size <= MaxTransactionSize && accounts <= MaxTransactionAccounts, nil
+}
+
+// transactionSize returns the size of the signed transaction compiled from ixns and the
+// number of accounts it locks.
+func transactionSize(
+	feePayer solana.PublicKey,
+	ixns []solana.Instruction,
+	tables map[solana.PublicKey]solana.PublicKeySlice,
+) (int, int, error) {
+	txOpts := []solana.TransactionOption{solana.TransactionPayer(feePayer)}
+	if len(tables) > 0 {
+		txOpts = append(txOpts, solana.TransactionAddressTables(tables))
+	}
+	tx, err := solana.NewTransaction(ixns, solana.Hash{}, txOpts...)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	message, err := tx.Message.MarshalBinary()
+	if err != nil {
+		return 0, 0, err
+	}
+
+	signatures := int(tx.Message.Header.NumRequiredSignatures)
+	accounts := len(tx.Message.AccountKeys)
+	for _, lookup := range tx.Message.AddressTableLookups {
+		accounts += len(lookup.WritableIndexes) + len(lookup.ReadonlyIndexes)
+	}
+
+	return shortVecLen(signatures) + signatures*solana.SignatureLength + len(message), accounts, nil
+}
+
+// shortVecLen is the size of n encoded as a compact-u16.
+func shortVecLen(n int) int {
+	switch {
+	case n < 1<<7:
+		return 1
+	case n < 1<<14:
+		return 2
+	default:
+		return 3
+	}
+}
+
+// EstimateGroups fills in the ComputeUnits of the groups lacking them by simulating
+// each group on its own, with the buffer of EstimateComputeUnits. A group depending
+// on the state an earlier group leaves behind is best given its units by the caller.
+func (s *Service) EstimateGroups(
+	ctx context.Context,
+	feePayer solana.PublicKey,
+	groups []InstructionGroup,
+	tables map[solana.PublicKey]solana.PublicKeySlice,
+) error {
+	for i := range groups {
+		if groups[i].ComputeUnits != 0 {
+			continue
+		}
+		units, err := s.EstimateComputeUnits(ctx, feePayer, groups[i].Instructions, tables)
+		if err != nil {
+			return fmt.Errorf("err estimating group %d: %w", i, err)
+		}
+		groups[i].ComputeUnits = units
+	}
+	return nil
+}
+
+// SendPlan sends the transactions of plan one after the other, each confirmed before the
+// next is sent. It stops at the first failure, returning the results of the transactions
+// that landed before it.
+func (s *Service) SendPlan(
+	ctx context.Context,
+	plan []PlannedTransaction,
+	feePayer Signer,
+	signers []Signer,
+	opts SendOpts,
+) ([]*Result, error) {
+	results := make([]*Result, 0, len(plan))
+	for i, planned := range plan {
+		txOpts := opts
+		txOpts.AddressTables = planned.AddressTables
+
+		res, err := s.SendInstructions(ctx, planned.Instructions, feePayer, signers, txOpts)
+		if err != nil {
+			return results, fmt.Errorf("err sending transaction %d of %d: %w", i+1, len(plan), err)
+		}
+		results = append(results, res)
+	}
+	return results, nil
+}
@@ -0,0 +1,103 @@
+package txn
+
+import (
+	cp_amm "dammv2GoSDK/generated/cpAmm"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+)
+
+// claimGroup looks like a claim position fee: shared pool accounts and per-position ones.
+func claimGroup(shared []solana.PublicKey) InstructionGroup {
+	metas := solana.AccountMetaSlice{}
+	for _, key := range shared {
+		metas = append(metas, solana.Meta(key))
+	}
+	for range 4 {
+		metas = append(metas, solana.Meta(solana.NewWallet().PublicKey()).WRITE())
+	}
+	return InstructionGroup{
+		Instructions: []solana.Instruction{solana.NewInstruction(cp_amm.ProgramID, metas, make([]byte, 8))},
+		ComputeUnits: 60_000,
+	}
+}
+
+func TestPlan(t *testing.T) {
+	var (
+		payer  = solana.NewWallet().PublicKey()
+		shared = make([]solana.PublicKey, 6)
+		groups = make([]InstructionGroup, 20)
+	)
+	for i := range shared {
+		shared[i] = solana.NewWallet().PublicKey()
+	}
+	for i := range groups {
+		groups[i] = claimGroup(shared)
+	}
+
+	plan, err := Plan(payer, groups, PlanOpts{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(plan) < 2 {
+		t.Fatalf("planned %d transactions, 20 groups cannot fit one", len(plan))
+	}
+
+	var order []int
+	for _, tx := range plan {
+		if tx.Size > MaxTransactionSize {
+			t.Fatalf("transaction of %d bytes", tx.Size)
+		}
+		order = append(order, tx.Groups...)
+	}
+	want := make([]int, len(groups))
+	for i := range want {
+		want[i] = i
+	}
+	if !slices.Equal(order, want) {
+		t.Fatalf("groups planned as %v, want %v", order, want)
+	}
+
+	// the per-position accounts in a table make the transactions smaller.
+	var lookedUp []solana.PublicKey
+	for _, group := range groups {
+		for _, meta := range group.Instructions[0].Accounts() {
+			lookedUp = append(lookedUp, meta.PublicKey)
+		}
+	}
+	table := solana.NewWallet().PublicKey()
+	withTables, err := Plan(payer, groups, PlanOpts{
+		AddressTables: map[solana.PublicKey]solana.PublicKeySlice{
+			table: slices.Compact(slices.SortedFunc(slices.Values(lookedUp), comparePublicKeys))[:256],
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(withTables) >= len(plan) {
+		t.Fatalf("planned %d transactions with a lookup table, %d without", len(withTables), len(plan))
+	}
+
+	// compute units bound the packing too.
+	byUnits, err := Plan(payer, groups[:4], PlanOpts{MaxComputeUnits: 120_000})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(byUnits) != 2 {
+		t.Fatalf("planned %d transactions, want 2 of 2 groups", len(byUnits))
+	}
+}
+
+func TestPlanGroupTooLarge(t *testing.T) {
+	group := InstructionGroup{}
+	for range 40 {
+		group.Instructions = append(group.Instructions, claimGroup(nil).Instructions...)
+	}
+
+	_, err := Plan(solana.NewWallet().PublicKey(), []InstructionGroup{group}, PlanOpts{})
+	if !errors.Is(err, ErrGroupTooLarge) {
+		t.Fatalf("err = %v, want ErrGroupTooLarge", err)
+	}
+}
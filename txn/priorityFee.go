@@ -0,0 +1,153 @@
+package txn
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"slices"
+
+	"github.com/gagliardetto/solana-go"
+	computebudget "github.com/gagliardetto/solana-go/programs/compute-budget"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+// maxFeeAccounts is the number of accounts getRecentPrioritizationFees accepts.
+const maxFeeAccounts = 128
+
+// PriorityFeeEstimator prices a compute unit, in micro-lamports, for a transaction
+// write-locking accounts.
+type PriorityFeeEstimator interface {
+	EstimatePriorityFee(ctx context.Context, accounts []solana.PublicKey) (uint64, error)
+}
+
+// FixedPriorityFee is a PriorityFeeEstimator always pricing at the same micro-lamports.
+type FixedPriorityFee uint64
+
+func (f FixedPriorityFee) EstimatePriorityFee(context.Context, []solana.PublicKey) (uint64, error) {
+	return uint64(f), nil
+}
+
+// PriorityFeeLevel is a preset percentile of the recent prioritization fees.
+type PriorityFeeLevel int
+
+const (
+	PriorityFeeLow    PriorityFeeLevel = iota // 25th percentile
+	PriorityFeeMedium                         // 50th percentile
+	PriorityFeeHigh                           // 75th percentile
+	PriorityFeeUrgent                         // 95th percentile
+)
+
+func (l PriorityFeeLevel) Percentile() float64 {
+	switch l {
+	case PriorityFeeLow:
+		return 25
+	case PriorityFeeHigh:
+		return 75
+	case PriorityFeeUrgent:
+		return 95
+	default:
+		return 50
+	}
+}
+
+// RecentFeesEstimator prices from the prioritization fees paid over the last blocks,
+// as reported by getRecentPrioritizationFees, by transactions write-locking the same
+// accounts: quiet pools come out cheap and busy ones expensive.
+type RecentFeesEstimator struct {
+	conn *rpc.Client
+	// Percentile of the recent fees to pay, between 0 and 100.
+	Percentile float64
+	// MinPrice and MaxPrice clamp the estimate, MaxPrice is ignored when zero.
+	MinPrice, MaxPrice uint64
+}
+
+func NewRecentFeesEstimator(conn *rpc.Client, level PriorityFeeLevel) *RecentFeesEstimator {
+	return &RecentFeesEstimator{
+		conn:       conn,
+		Percentile: level.Percentile(),
+	}
+}
+
+func (e *RecentFeesEstimator) EstimatePriorityFee(ctx context.Context, accounts []solana.PublicKey) (uint64, error) {
+	if len(accounts) > maxFeeAccounts {
+		accounts = accounts[:maxFeeAccounts]
+	}
+
+	recent, err := e.conn.GetRecentPrioritizationFees(ctx, accounts)
+	if err != nil {
+		return 0, fmt.Errorf("err fetching recent prioritization fees: %w", err)
+	}
+
+	fees := make([]uint64, len(recent))
+	for i, fee := range recent {
+		fees[i] = fee.PrioritizationFee
+	}
+
+	price := max(FeePercentile(fees, e.Percentile), e.MinPrice)
+	if e.MaxPrice != 0 {
+		price = min(price, e.MaxPrice)
+	}
+	return price, nil
+}
+
+// FeePercentile returns the nearest-rank percentile of fees, 0 when there are none.
+func FeePercentile(fees []uint64, percentile float64) uint64 {
+	if len(fees) == 0 {
+		return 0
+	}
+	sorted := slices.Sorted(slices.Values(fees))
+
+	rank := int(math.Ceil(min(max(percentile, 0), 100) / 100 * float64(len(sorted))))
+	return sorted[max(rank-1, 0)]
+}
+
+// WritableAccounts returns the accounts ixns write-lock, without signers: fee markets are
+// local to the contended accounts, e.g the pool, its vaults and the position, and wallets
+// only add noise.
+func WritableAccounts(ixns []solana.Instruction) []solana.PublicKey {
+	var (
+		seen     = make(map[solana.PublicKey]struct{})
+		writable []solana.PublicKey
+	)
+	for _, ix := range ixns {
+		for _, meta := range ix.Accounts() {
+			if meta == nil || !meta.IsWritable || meta.IsSigner {
+				continue
+			}
+			if _, ok := seen[meta.PublicKey]; ok {
+				continue
+			}
+			seen[meta.PublicKey] = struct{}{}
+			writable = append(writable, meta.PublicKey)
+		}
+	}
+	return writable
+}
+
+// WithPriorityFee prepends a SetComputeUnitPrice priced by estimator for the accounts
+// ixns write-lock. ixns are returned as is when they already set a price, or when the
+// estimate is zero.
+func WithPriorityFee(
+	ctx context.Context,
+	estimator PriorityFeeEstimator,
+	ixns []solana.Instruction,
+) ([]solana.Instruction, error) {
+	if estimator == nil || slices.ContainsFunc(ixns, func(ix solana.Instruction) bool {
+		return computeBudgetKind(ix) == computebudget.Instruction_SetComputeUnitPrice
+	}) {
+		return ixns, nil
+	}
+
+	price, err := estimator.EstimatePriorityFee(ctx, WritableAccounts(ixns))
+	if err != nil {
+		return nil, err
+	}
+	if price == 0 {
+		return ixns, nil
+	}
+
+	return slices.Insert(
+		slices.Clone(ixns), 0,
+		solana.Instruction(computebudget.NewSetComputeUnitPriceInstruction(price).Build()),
+	), nil
+}
@@ -0,0 +1,73 @@
+package txn
+
+import (
+	"context"
+	cp_amm "dammv2GoSDK/generated/cpAmm"
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+	computebudget "github.com/gagliardetto/solana-go/programs/compute-budget"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+func TestFeePercentile(t *testing.T) {
+	fees := []uint64{0, 0, 100, 5_000, 1_000, 0, 200, 300, 400, 10_000}
+	for _, tc := range []struct {
+		level PriorityFeeLevel
+		want  uint64
+	}{
+		{PriorityFeeLow, 0},
+		{PriorityFeeMedium, 200},
+		{PriorityFeeHigh, 1_000},
+		{PriorityFeeUrgent, 10_000},
+	} {
+		if got := FeePercentile(fees, tc.level.Percentile()); got != tc.want {
+			t.Errorf("level %d: got %d, want %d", tc.level, got, tc.want)
+		}
+	}
+	if got := FeePercentile(nil, 50); got != 0 {
+		t.Errorf("no fees: got %d, want 0", got)
+	}
+}
+
+func TestWithPriorityFee(t *testing.T) {
+	var (
+		owner = solana.NewWallet().PublicKey()
+		pool  = solana.NewWallet().PublicKey()
+		vault = solana.NewWallet().PublicKey()
+		ix    = solana.NewInstruction(cp_amm.ProgramID, solana.AccountMetaSlice{
+			solana.Meta(owner).WRITE().SIGNER(),
+			solana.Meta(pool).WRITE(),
+			solana.Meta(vault).WRITE(),
+			solana.Meta(cp_amm.ProgramID),
+		}, []byte{0})
+	)
+
+	fake := &fakeCluster{fees: []uint64{10, 20, 30, 40}}
+	estimator := NewRecentFeesEstimator(rpc.NewWithCustomRPCClient(fake), PriorityFeeHigh)
+
+	ixns, err := WithPriorityFee(context.Background(), estimator, []solana.Instruction{ix})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ixns) != 2 || computeBudgetKind(ixns[0]) != computebudget.Instruction_SetComputeUnitPrice {
+		t.Fatalf("ixns = %v, want a SetComputeUnitPrice first", ixns)
+	}
+	if data, _ := ixns[0].Data(); data[1] != 30 {
+		t.Fatalf("price = %d, want 30", data[1])
+	}
+
+	accounts := fake.feeAccounts[0].(solana.PublicKeySlice)
+	if len(accounts) != 2 || !accounts[0].Equals(pool) || !accounts[1].Equals(vault) {
+		t.Fatalf("fees queried for %v, want the pool and vault", accounts)
+	}
+
+	// a price already set is kept.
+	again, err := WithPriorityFee(context.Background(), estimator, ixns)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(again) != 2 {
+		t.Fatalf("got %d instructions, want the 2 given", len(again))
+	}
+}
@@ -0,0 +1,355 @@
+package txn
+
+import (
+	"context"
+	"dammv2GoSDK/anchor"
+	cp_amm "dammv2GoSDK/generated/cpAmm"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+// ErrBlockhashExpired is returned by Send when the blockhash of the transaction expired
+// before the transaction landed, it is then safe to rebuild and send it again.
+var ErrBlockhashExpired = errors.New("blockhash expired before the transaction was confirmed")
+
+// Service assembles, signs, sends and confirms transactions.
+type Service struct {
+	conn      *rpc.Client
+	programID solana.PublicKey
+}
+
+// NewService returns a Service sending through conn. Events emitted by programID
+// are decoded out of confirmed transactions.
+func NewService(conn *rpc.Client, programID solana.PublicKey) *Service {
+	return &Service{
+		conn:      conn,
+		programID: programID,
+	}
+}
+
+// SendOpts configures Build, Send and SendInstructions.
+type SendOpts struct {
+	// AddressTables, when set, compiles a v0 transaction against these lookup tables.
+	AddressTables map[solana.PublicKey]solana.PublicKeySlice
+	// Commitment the transaction is confirmed at, defaults to confirmed.
+	Commitment rpc.CommitmentType
+	// RetryInterval is the delay between two sends of the same transaction, defaults to 2s.
+	RetryInterval time.Duration
+	SkipPreflight bool
+	// ComputeBudget configures the compute-budget instructions Build prepends,
+	// by default a simulated unit limit and no unit price.
+	ComputeBudget ComputeBudgetOpts
+}
+
+func (o SendOpts) withDefaults() SendOpts {
+	if o.Commitment == "" {
+		o.Commitment = rpc.CommitmentConfirmed
+	}
+	if o.RetryInterval <= 0 {
+		o.RetryInterval = 2 * time.Second
+	}
+	return o
+}
+
+// Built is an unsigned transaction along with what tells when it expires: the last block
+// height its blockhash is valid at, or the durable nonce it was built against.
+type Built struct {
+	Transaction          *solana.Transaction
+	LastValidBlockHeight uint64
+	// Nonce is set for transactions of BuildDurable, which expire once the nonce advances.
+	Nonce *Nonce
+}
+
+// Result is the outcome of a confirmed transaction.
+type Result struct {
+	Signature solana.Signature
+	Slot      uint64
+	// Events emitted by the program of the Service, e.g EvtSwap or EvtAddLiquidity.
+	Events []*cp_amm.Event
+	// Transaction is the confirmed transaction as returned by getTransaction.
+	Transaction *rpc.GetTransactionResult
+}
+
+// Event returns the data of the first event named name (e.g "EvtSwap"), or nil.
+func (r *Result) Event(name string) cp_amm.EventData {
+	for _, evt := range r.Events {
+		if evt.Name == name {
+			return evt.Data
+		}
+	}
+	return nil
+}
+
+// Build compiles ixns into a transaction paid by feePayer against the latest blockhash,
+// a v0 transaction when opts has address tables and a legacy one otherwise.
+// Compute-budget instructions are prepended as opts.ComputeBudget asks.
+func (s *Service) Build(
+	ctx context.Context,
+	feePayer solana.PublicKey,
+	ixns []solana.Instruction,
+	opts SendOpts,
+) (*Built, error) {
+	opts = opts.withDefaults()
+
+	ixns, err := s.withComputeBudget(ctx, feePayer, ixns, opts.AddressTables, opts.ComputeBudget)
+	if err != nil {
+		return nil, err
+	}
+
+	latest, err := s.conn.GetLatestBlockhash(ctx, opts.Commitment)
+	if err != nil {
+		return nil, fmt.Errorf("err fetching latest blockhash: %w", err)
+	}
+
+	txOpts := []solana.TransactionOption{solana.TransactionPayer(feePayer)}
+	if len(opts.AddressTables) > 0 {
+		txOpts = append(txOpts, solana.TransactionAddressTables(opts.AddressTables))
+	}
+	tx, err := solana.NewTransaction(ixns, latest.Value.Blockhash, txOpts...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Built{
+		Transaction:          tx,
+		LastValidBlockHeight: latest.Value.LastValidBlockHeight,
+	}, nil
+}
+
+// SendInstructions builds ixns into a transaction paid by feePayer, signs it with
+// feePayer and signers, then sends and confirms it, see Send.
+func (s *Service) SendInstructions(
+	ctx context.Context,
+	ixns []solana.Instruction,
+	feePayer Signer,
+	signers []Signer,
+	opts SendOpts,
+) (*Result, error) {
+	built, err := s.Build(ctx, feePayer.PublicKey(), ixns, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := Sign(ctx, built.Transaction, append([]Signer{feePayer}, signers...)...); err != nil {
+		return nil, err
+	}
+
+	return s.Send(ctx, built, opts)
+}
+
+// Send sends the signed transaction of built every opts.RetryInterval until it lands,
+// waits for it to reach opts.Commitment, and then fetches it and decodes its events. It gives up with
+// ErrBlockhashExpired once the block height passes built.LastValidBlockHeight, or
+// with ErrNonceAdvanced once the durable nonce of built moved on without it.
+func (s *Service) Send(ctx context.Context, built *Built, opts SendOpts) (*Result, error) {
+	opts = opts.withDefaults()
+
+	tx := built.Transaction
+	if missing := MissingSigners(tx); len(missing) > 0 {
+		return nil, fmt.Errorf("transaction is missing signatures of %v", missing)
+	}
+
+	rawTx, err := tx.MarshalBinary()
+	if err != nil {
+		return nil, fmt.Errorf("err encoding transaction: %w", err)
+	}
+	signature := tx.Signatures[0]
+
+	var maxRetries uint = 0
+	sendOpts := rpc.TransactionOpts{
+		SkipPreflight:       opts.SkipPreflight,
+		PreflightCommitment: opts.Commitment,
+		// retries are handled here, against the blockhash expiry.
+		MaxRetries: &maxRetries,
+	}
+
+	ticker := time.NewTicker(opts.RetryInterval)
+	defer ticker.Stop()
+
+	// landed is set while the cluster reports a status for the transaction, which is then
+	// neither resent nor checked for expiry.
+	for first, landed := true, false; ; first = false {
+		// later sends are best effort, the transaction may already have landed.
+		if !landed {
+			if _, err := s.conn.SendRawTransactionWithOpts(ctx, rawTx, sendOpts); err != nil && first {
+				return nil, fmt.Errorf("err sending transaction: %w", anchor.DecodeRPCError(err, s.programID, &tx.Message))
+			}
+			// preflight already ran once, there is no need to simulate resends.
+			sendOpts.SkipPreflight = true
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-ticker.C:
+		}
+
+		statuses, err := s.conn.GetSignatureStatuses(ctx, false, signature)
+		if err != nil && !errors.Is(err, rpc.ErrNotFound) {
+			return nil, fmt.Errorf("err fetching signature status: %w", err)
+		}
+		if statuses != nil && len(statuses.Value) == 1 && statuses.Value[0] != nil {
+			status := statuses.Value[0]
+			if status.Err != nil {
+				return nil, fmt.Errorf("transaction %s failed: %w", signature, s.failure(ctx, signature, tx, status.Err, opts))
+			}
+			if reached(status.ConfirmationStatus, opts.Commitment) {
+				return s.fetchResult(ctx, signature, opts)
+			}
+			// landed, but not yet at the commitment asked for, nothing to resend.
+			landed = true
+			continue
+		}
+		// not landed, or landed on a fork the cluster abandoned since.
+		landed = false
+
+		if built.Nonce != nil {
+			advanced, err := s.nonceAdvanced(ctx, built)
+			if err != nil {
+				return nil, err
+			}
+			// the transaction itself advances the nonce, it may have landed since the status check.
+			if advanced && !s.landed(ctx, signature) {
+				return nil, fmt.Errorf("%w: %s", ErrNonceAdvanced, signature)
+			}
+			continue
+		}
+
+		blockHeight, err := s.conn.GetBlockHeight(ctx, opts.Commitment)
+		if err != nil {
+			return nil, fmt.Errorf("err fetching block height: %w", err)
+		}
+		if blockHeight > built.LastValidBlockHeight {
+			return nil, fmt.Errorf("%w: %s", ErrBlockhashExpired, signature)
+		}
+	}
+}
+
+// landed reports whether the cluster knows of signature, at any commitment.
+func (s *Service) landed(ctx context.Context, signature solana.Signature) bool {
+	statuses, err := s.conn.GetSignatureStatuses(ctx, false, signature)
+	return err == nil && len(statuses.Value) == 1 && statuses.Value[0] != nil
+}
+
+// failure decodes the error of a failed transaction, with its logs when the node serves them.
+func (s *Service) failure(
+	ctx context.Context,
+	signature solana.Signature,
+	tx *solana.Transaction,
+	txErr any,
+	opts SendOpts,
+) *anchor.TransactionError {
+	commitment := opts.Commitment
+	if commitment == rpc.CommitmentProcessed {
+		commitment = rpc.CommitmentConfirmed
+	}
+
+	var (
+		maxVersion uint64 = 0
+		logs       []string
+	)
+	txResult, err := s.conn.GetTransaction(ctx, signature, &rpc.GetTransactionOpts{
+		Encoding:                       solana.EncodingBase64,
+		Commitment:                     commitment,
+		MaxSupportedTransactionVersion: &maxVersion,
+	})
+	if err == nil && txResult.Meta != nil {
+		logs = txResult.Meta.LogMessages
+	}
+	return anchor.NewTransactionError(txErr, s.programID, &tx.Message, logs)
+}
+
+func (s *Service) fetchResult(ctx context.Context, signature solana.Signature, opts SendOpts) (*Result, error) {
+	// getTransaction does not serve processed transactions.
+	commitment := opts.Commitment
+	if commitment == rpc.CommitmentProcessed {
+		commitment = rpc.CommitmentConfirmed
+	}
+
+	var maxVersion uint64 = 0
+	var (
+		txResult *rpc.GetTransactionResult
+		err      error
+	)
+	// the status can run ahead of the ledger the node serves getTransaction from.
+	for range 10 {
+		txResult, err = s.conn.GetTransaction(ctx, signature, &rpc.GetTransactionOpts{
+			Encoding:                       solana.EncodingBase64,
+			Commitment:                     commitment,
+			MaxSupportedTransactionVersion: &maxVersion,
+		})
+		if !errors.Is(err, rpc.ErrNotFound) {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(opts.RetryInterval / 4):
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("err fetching confirmed transaction %s: %w", signature, err)
+	}
+
+	events, err := cp_amm.DecodeEvents(txResult, s.programID, s.addressTables(ctx, opts.AddressTables))
+	if err != nil {
+		return nil, fmt.Errorf("err decoding events of %s: %w", signature, err)
+	}
+
+	return &Result{
+		Signature:   signature,
+		Slot:        txResult.Slot,
+		Events:      events,
+		Transaction: txResult,
+	}, nil
+}
+
+// addressTables serves the lookup tables the transaction was built with, fetching the others.
+func (s *Service) addressTables(
+	ctx context.Context,
+	known map[solana.PublicKey]solana.PublicKeySlice,
+) func([]solana.PublicKey) (map[solana.PublicKey]solana.PublicKeySlice, error) {
+	fetch := anchor.AddressTablesFetcher(ctx, s.conn)
+	return func(altAddresses []solana.PublicKey) (map[solana.PublicKey]solana.PublicKeySlice, error) {
+		res := make(map[solana.PublicKey]solana.PublicKeySlice, len(altAddresses))
+		var unknown []solana.PublicKey
+		for _, address := range altAddresses {
+			if table, ok := known[address]; ok {
+				res[address] = table
+			} else {
+				unknown = append(unknown, address)
+			}
+		}
+		if len(unknown) == 0 {
+			return res, nil
+		}
+
+		fetched, err := fetch(unknown)
+		if err != nil {
+			return nil, err
+		}
+		for address, table := range fetched {
+			res[address] = table
+		}
+		return res, nil
+	}
+}
+
+var commitmentRank = map[string]int{
+	string(rpc.CommitmentProcessed): 0,
+	string(rpc.CommitmentConfirmed): 1,
+	string(rpc.CommitmentFinalized): 2,
+}
+
+// reached reports whether a transaction at status is at least at commitment.
+func reached(status rpc.ConfirmationStatusType, commitment rpc.CommitmentType) bool {
+	have, ok := commitmentRank[string(status)]
+	if !ok {
+		return false
+	}
+	return have >= commitmentRank[string(commitment)]
+}
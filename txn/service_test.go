@@ -0,0 +1,216 @@
+package txn
+
+import (
+	"bytes"
+	"context"
+	cp_amm "dammv2GoSDK/generated/cpAmm"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	ag_binary "github.com/gagliardetto/binary"
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/gagliardetto/solana-go/rpc/jsonrpc"
+)
+
+// fakeCluster lands every transaction sent to it after landAfter sends, reporting it
+// processed for the first processed status polls after that, confirmed then. The block
+// height moves one block per status poll.
+type fakeCluster struct {
+	landAfter   int32
+	processed   int32
+	landedPolls atomic.Int32
+	logs        []string
+	sends       atomic.Int32
+	blockHeight atomic.Uint64
+	sent        string
+	simulated   string
+	fees        []uint64
+	feeAccounts []any
+}
+
+func (f *fakeCluster) CallForInto(_ context.Context, out any, method string, params []any) error {
+	var res any
+	switch method {
+	case "getLatestBlockhash":
+		res = map[string]any{
+			"context": map[string]any{"slot": 1},
+			"value": map[string]any{
+				"blockhash":            solana.Hash{1}.String(),
+				"lastValidBlockHeight": 3,
+			},
+		}
+	case "simulateTransaction":
+		f.simulated = params[0].(string)
+		res = map[string]any{
+			"context": map[string]any{"slot": 1},
+			"value":   map[string]any{"err": nil, "logs": []string{}, "unitsConsumed": 100_000},
+		}
+	case "sendTransaction":
+		f.sends.Add(1)
+		f.sent = params[0].(string)
+		res = solana.Signature{}.String()
+	case "getSignatureStatuses":
+		f.blockHeight.Add(1)
+		var status any
+		if f.sends.Load() > f.landAfter {
+			commitment := "confirmed"
+			if f.landedPolls.Add(1) <= f.processed {
+				commitment = "processed"
+			}
+			status = map[string]any{"slot": 9, "confirmationStatus": commitment}
+		}
+		res = map[string]any{
+			"context": map[string]any{"slot": 9},
+			"value":   []any{status},
+		}
+	case "getRecentPrioritizationFees":
+		f.feeAccounts = params
+		fees := make([]any, len(f.fees))
+		for i, fee := range f.fees {
+			fees[i] = map[string]any{"slot": i, "prioritizationFee": fee}
+		}
+		res = fees
+	case "getSlot":
+		res = 100
+	case "getBlockHeight":
+		res = f.blockHeight.Load()
+	case "getTransaction":
+		res = map[string]any{
+			"slot":        9,
+			"transaction": []string{f.sent, "base64"},
+			"meta":        map[string]any{"logMessages": f.logs, "err": nil},
+		}
+	default:
+		return errors.New("unexpected method " + method)
+	}
+
+	raw, err := json.Marshal(res)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(raw, out)
+}
+
+func (f *fakeCluster) CallWithCallback(context.Context, string, []any, func(*http.Request, *http.Response) error) error {
+	return errors.New("not implemented")
+}
+
+func (f *fakeCluster) CallBatch(context.Context, jsonrpc.RPCRequests) (jsonrpc.RPCResponses, error) {
+	return nil, errors.New("not implemented")
+}
+
+func TestSendInstructions(t *testing.T) {
+	var (
+		payer = solana.NewWallet().PrivateKey
+		pool  = solana.NewWallet().PublicKey()
+	)
+
+	var evt bytes.Buffer
+	if err := (cp_amm.EvtSwapEventData{Pool: pool}).MarshalWithEncoder(ag_binary.NewBorshEncoder(&evt)); err != nil {
+		t.Fatal(err)
+	}
+
+	fake := &fakeCluster{
+		landAfter: 1,
+		logs:      []string{"Program data: " + base64.StdEncoding.EncodeToString(evt.Bytes())},
+	}
+	service := NewService(rpc.NewWithCustomRPCClient(fake), cp_amm.ProgramID)
+
+	ix := solana.NewInstruction(cp_amm.ProgramID, solana.AccountMetaSlice{solana.Meta(pool).WRITE()}, []byte{0})
+	res, err := service.SendInstructions(
+		context.Background(),
+		[]solana.Instruction{ix},
+		KeypairSigner(payer),
+		nil,
+		SendOpts{RetryInterval: time.Millisecond},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if sends := fake.sends.Load(); sends != 2 {
+		t.Fatalf("sent %d times, want 2", sends)
+	}
+	if res.Slot != 9 {
+		t.Fatalf("slot = %d, want 9", res.Slot)
+	}
+	swap, ok := res.Event("EvtSwap").(*cp_amm.EvtSwapEventData)
+	if !ok || !swap.Pool.Equals(pool) {
+		t.Fatalf("events = %v, want an EvtSwap of %s", res.Events, pool)
+	}
+}
+
+func TestSendLandedNotResent(t *testing.T) {
+	// the transaction lands on the first send, then stays processed past the expiry of its
+	// blockhash, at block height 3.
+	fake := &fakeCluster{processed: 5}
+	service := NewService(rpc.NewWithCustomRPCClient(fake), cp_amm.ProgramID)
+
+	ix := solana.NewInstruction(cp_amm.ProgramID, solana.AccountMetaSlice{}, []byte{0})
+	if _, err := service.SendInstructions(
+		context.Background(),
+		[]solana.Instruction{ix},
+		KeypairSigner(solana.NewWallet().PrivateKey),
+		nil,
+		SendOpts{RetryInterval: time.Millisecond},
+	); err != nil {
+		t.Fatal(err)
+	}
+	if sends := fake.sends.Load(); sends != 1 {
+		t.Fatalf("sent %d times, want 1", sends)
+	}
+}
+
+func TestSendBlockhashExpired(t *testing.T) {
+	fake := &fakeCluster{landAfter: 1 << 30}
+	service := NewService(rpc.NewWithCustomRPCClient(fake), cp_amm.ProgramID)
+
+	ix := solana.NewInstruction(cp_amm.ProgramID, solana.AccountMetaSlice{}, []byte{0})
+	_, err := service.SendInstructions(
+		context.Background(),
+		[]solana.Instruction{ix},
+		KeypairSigner(solana.NewWallet().PrivateKey),
+		nil,
+		SendOpts{RetryInterval: time.Millisecond},
+	)
+	if !errors.Is(err, ErrBlockhashExpired) {
+		t.Fatalf("err = %v, want ErrBlockhashExpired", err)
+	}
+}
+
+func TestSignPartially(t *testing.T) {
+	var (
+		payer  = solana.NewWallet().PrivateKey
+		signer = solana.NewWallet().PrivateKey
+	)
+
+	ix := solana.NewInstruction(
+		cp_amm.ProgramID,
+		solana.AccountMetaSlice{solana.Meta(signer.PublicKey()).SIGNER()},
+		[]byte{0},
+	)
+	tx, err := solana.NewTransaction([]solana.Instruction{ix}, solana.Hash{1}, solana.TransactionPayer(payer.PublicKey()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Sign(context.Background(), tx, KeypairSigner(payer)); err != nil {
+		t.Fatal(err)
+	}
+	if missing := MissingSigners(tx); len(missing) != 1 || !missing[0].Equals(signer.PublicKey()) {
+		t.Fatalf("missing = %v, want %s", missing, signer.PublicKey())
+	}
+
+	if err := Sign(context.Background(), tx, KeypairSigner(signer)); err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.VerifySignatures(); err != nil {
+		t.Fatal(err)
+	}
+}
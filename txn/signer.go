@@ -0,0 +1,69 @@
+package txn
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/gagliardetto/solana-go"
+)
+
+// Signer signs transaction messages on behalf of PublicKey, e.g a local keypair,
+// a hardware wallet or a remote signing service.
+type Signer interface {
+	PublicKey() solana.PublicKey
+	SignMessage(ctx context.Context, message []byte) (solana.Signature, error)
+}
+
+// KeypairSigner is a Signer backed by a private key held in memory.
+type KeypairSigner solana.PrivateKey
+
+func (k KeypairSigner) PublicKey() solana.PublicKey {
+	return solana.PrivateKey(k).PublicKey()
+}
+
+func (k KeypairSigner) SignMessage(_ context.Context, message []byte) (solana.Signature, error) {
+	return solana.PrivateKey(k).Sign(message)
+}
+
+// Sign signs tx with every signer it requires, signers tx does not require are ignored.
+// Signatures already present are kept, so a transaction can be signed in several passes;
+// a required signer missing from signers is left unsigned.
+func Sign(ctx context.Context, tx *solana.Transaction, signers ...Signer) error {
+	message, err := tx.Message.MarshalBinary()
+	if err != nil {
+		return fmt.Errorf("err encoding message: %w", err)
+	}
+
+	required := tx.Message.Signers()
+	if len(tx.Signatures) != len(required) {
+		signatures := make([]solana.Signature, len(required))
+		copy(signatures, tx.Signatures)
+		tx.Signatures = signatures
+	}
+
+	for _, signer := range signers {
+		idx := slices.Index(required, signer.PublicKey())
+		if idx < 0 {
+			continue
+		}
+		signature, err := signer.SignMessage(ctx, message)
+		if err != nil {
+			return fmt.Errorf("err signing with %s: %w", signer.PublicKey(), err)
+		}
+		tx.Signatures[idx] = signature
+	}
+
+	return nil
+}
+
+// MissingSigners returns the required signers of tx that have not signed it yet.
+func MissingSigners(tx *solana.Transaction) []solana.PublicKey {
+	var missing []solana.PublicKey
+	for i, key := range tx.Message.Signers() {
+		if i >= len(tx.Signatures) || tx.Signatures[i].IsZero() {
+			missing = append(missing, key)
+		}
+	}
+	return missing
+}
@@ -27,3 +27,12 @@ const (
 	TradeDirectionAtoB TradeDirection = iota
 	TradeDirectionBtoA
 )
+
+type PoolSortBy uint8
+
+const (
+	// PoolSortByNone keeps the order returned by the rpc.
+	PoolSortByNone PoolSortBy = iota
+	PoolSortByLiquidity
+	PoolSortBySqrtPrice
+)
@@ -7,6 +7,7 @@ import (
 	ag_binary "github.com/gagliardetto/binary"
 	"github.com/gagliardetto/solana-go"
 	"github.com/gagliardetto/solana-go/programs/token"
+	"github.com/gagliardetto/solana-go/rpc"
 )
 
 type PrepareTokenAccountParams struct {
@@ -171,14 +172,11 @@ type FeeMode struct {
 	HasReferral  bool
 }
 type GetQuoteParams struct {
-	InAmount        *big.Int
-	InputTokenMint  solana.PublicKey
-	Slippage        float64
-	PoolState       *cp_amm.PoolAccount
-	CurrentTime     uint64
-	CurrentSlot     uint64
-	InputTokenInfo  *TokenEpochInfo
-	OutputTokenInfo *TokenEpochInfo
+	InAmount       *big.Int
+	InputTokenMint solana.PublicKey
+	Slippage       float64
+	// QuoteState is the pool to swap through, e.g as read by CpAMM.FetchQuoteState.
+	QuoteState
 }
 
 type TokenEpochInfo struct {
@@ -459,12 +457,16 @@ type FundRewardParams struct {
 	Pool         solana.PublicKey
 	CarryForward bool
 	Amount       uint64
+	// PoolState of Pool, fetched when nil.
+	PoolState *cp_amm.PoolAccount
 }
 
 type WithdrawIneligibleRewardParams struct {
 	RewardIndex uint8
 	Pool        solana.PublicKey
 	Funder      solana.PublicKey
+	// PoolState of Pool, fetched when nil.
+	PoolState *cp_amm.PoolAccount
 }
 
 type ClaimPartnerFeeParams struct {
@@ -475,6 +477,8 @@ type ClaimPartnerFeeParams struct {
 	Receiver        solana.PublicKey
 	FeePayer        solana.PublicKey
 	TempWSolAccount solana.PublicKey
+	// PoolState of Pool, fetched when nil.
+	PoolState *cp_amm.PoolAccount
 }
 
 type ClaimPositionFeeParams struct {
@@ -534,11 +538,8 @@ type GetQuoteExactOutParams struct {
 	OutAmount       *big.Int
 	OutputTokenMint solana.PublicKey
 	Slippage        float64
-	PoolState       *cp_amm.PoolAccount
-	CurrentTime     uint64
-	CurrentSlot     uint64
-	InputTokenInfo  *TokenEpochInfo
-	OutputTokenInfo *TokenEpochInfo
+	// QuoteState is the pool to swap through, e.g as read by CpAMM.FetchQuoteState.
+	QuoteState
 }
 
 type QuoteExactOutResult struct {
@@ -563,3 +564,135 @@ type SplitPositionParams struct {
 	Reward0Percentage                  float64
 	Reward1Percentage                  float64
 }
+
+type GetPoolsOpts struct {
+	// SortBy orders the result, descending when Descending is set.
+	SortBy     PoolSortBy
+	Descending bool
+	// Configs are checked on the derived fast path of GetPoolsByMints,
+	// alongside the customizable pool address.
+	Configs []solana.PublicKey
+	// DerivedOnly skips the getProgramAccounts scan, only pools found through
+	// derived addresses are returned.
+	DerivedOnly bool
+}
+
+type GetPoolsByConfigOpts struct {
+	GetPoolsOpts
+	// TokenPairs, when set, are derived against the config instead of
+	// scanning every pool of the program.
+	TokenPairs [][2]solana.PublicKey
+}
+
+// PoolPriceView is the subset of a pool account needed for price indexing,
+// decoded from a dataSlice read instead of the full account.
+type PoolPriceView struct {
+	Pool       solana.PublicKey
+	TokenAMint solana.PublicKey
+	TokenBMint solana.PublicKey
+	SqrtPrice  *big.Int
+	Liquidity  *big.Int
+	PoolStatus uint8
+}
+
+// QuoteState holds the on-chain inputs of GetQuote and GetQuoteExactOut.
+// The token infos are only needed for token 2022 mints, nil ones are taken as
+// mints without transfer fee.
+type QuoteState struct {
+	PoolState   *cp_amm.PoolAccount
+	TokenAInfo  *TokenEpochInfo
+	TokenBInfo  *TokenEpochInfo
+	CurrentSlot uint64
+	CurrentTime uint64
+}
+
+// SwapTokenInfos returns the token infos of the input and output of a swap, aToB telling its direction.
+func (s QuoteState) SwapTokenInfos(aToB bool) (input, output *TokenEpochInfo) {
+	if aToB {
+		return s.TokenAInfo, s.TokenBInfo
+	}
+	return s.TokenBInfo, s.TokenAInfo
+}
+
+// SimulatedQuote is the on-chain result of simulating an instruction, E being the event it emits.
+type SimulatedQuote[E any] struct {
+	Event *E
+	// PoolState is the pool as left by the simulated instruction.
+	PoolState     *cp_amm.PoolAccount
+	UnitsConsumed uint64
+	Slot          uint64
+	Logs          []string
+}
+
+// ExplainOpts configures ExplainSignature, ExplainTransactionResult and ExplainTransaction.
+type ExplainOpts struct {
+	// Symbols names mints in summaries, e.g USDC. Other mints show as an abbreviated
+	// address, wrapped SOL as SOL.
+	Symbols map[solana.PublicKey]string
+	// Commitment the transaction and the accounts it refers to are read at, defaults to confirmed.
+	Commitment rpc.CommitmentType
+}
+
+// TokenAmount is an amount of raw units of Mint.
+type TokenAmount struct {
+	Mint     solana.PublicKey `json:"mint"`
+	Amount   uint64           `json:"amount"`
+	Decimals uint8            `json:"decimals"`
+	// Symbol of Mint, see ExplainOpts.Symbols.
+	Symbol string `json:"symbol"`
+}
+
+// SwapFees are the fees of a swap, all in the token the pool collects fees in.
+type SwapFees struct {
+	Lp       TokenAmount `json:"lp"`
+	Protocol TokenAmount `json:"protocol"`
+	Partner  TokenAmount `json:"partner"`
+	Referral TokenAmount `json:"referral"`
+}
+
+// TransactionExplanation is a human oriented summary of a transaction involving the pool program.
+type TransactionExplanation struct {
+	Signature solana.Signature `json:"signature"`
+	Slot      uint64           `json:"slot,omitempty"`
+	BlockTime int64            `json:"blockTime,omitempty"`
+	FeePayer  solana.PublicKey `json:"feePayer"`
+	// Error of a failed transaction.
+	Error string `json:"error,omitempty"`
+	// Executed is false for transactions explained from their instructions alone, the
+	// actions then tell what the transaction asks for rather than what it did.
+	Executed     bool                   `json:"executed"`
+	Actions      []ExplainedAction      `json:"actions"`
+	Instructions []ExplainedInstruction `json:"instructions"`
+}
+
+// ExplainedAction is an action of a transaction on a pool, e.g a swap or a lock.
+type ExplainedAction struct {
+	// Kind is the instruction behind the action, e.g Swap or LockPosition.
+	Kind     string            `json:"kind"`
+	Pool     solana.PublicKey  `json:"pool"`
+	Position *solana.PublicKey `json:"position,omitempty"`
+	// Amounts moved by the action, in the order Summary names them.
+	Amounts []TokenAmount `json:"amounts,omitempty"`
+	Fees    *SwapFees     `json:"fees,omitempty"`
+	// LockedPercentage of the position liquidity a lock covers, against the current liquidity of the position.
+	LockedPercentage float64 `json:"lockedPercentage,omitempty"`
+	Summary          string  `json:"summary"`
+}
+
+// ExplainedInstruction is an instruction of the pool program, top-level or invoked through CPI.
+type ExplainedInstruction struct {
+	// Index of the top-level instruction, the one invoking the pool program for inner instructions.
+	Index int    `json:"index"`
+	Inner bool   `json:"inner,omitempty"`
+	Name  string `json:"name"`
+	// Accounts of the instruction, lookup table accounts resolved.
+	Accounts []ExplainedAccount `json:"accounts"`
+}
+
+type ExplainedAccount struct {
+	Address  solana.PublicKey `json:"address"`
+	Writable bool             `json:"writable,omitempty"`
+	Signer   bool             `json:"signer,omitempty"`
+	// LookupTable is set for accounts loaded from an address lookup table.
+	LookupTable bool `json:"lookupTable,omitempty"`
+}